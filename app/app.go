@@ -3,10 +3,12 @@ package app
 import (
 	"github.com/gofiber/cors"
 	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/utils"
 )
 
 func SetupApp() *fiber.App {
-	app := fiber.New()
+	// the uploads are the largest requests
+	app := fiber.New(&fiber.Settings{BodyLimit: utils.MediaMaxBytes() + 1024*1024})
 
 	app.Use(cors.New())
 
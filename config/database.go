@@ -17,12 +17,7 @@ type MongoInstance struct {
 	DB     *mongo.Database
 }
 
-type MongoOptions struct {
-	New options.FindOneAndUpdateOptions
-}
-
 var Mongo MongoInstance
-var MongoOps MongoOptions
 
 func SetupDB() {
 	// Database settings
@@ -56,15 +51,4 @@ func SetupDB() {
 		Client: client,
 		DB:     db,
 	}
-
-	// setup some options var
-	upsert := true
-	after := options.After
-	opt := options.FindOneAndUpdateOptions{
-		ReturnDocument: &after,
-		Upsert:         &upsert,
-	}
-	MongoOps = MongoOptions{
-		New: opt,
-	}
 }
@@ -8,9 +8,7 @@ require (
 	github.com/franela/goblin v0.0.0-20200611003024-99f9a98191cf
 	github.com/gofiber/cors v0.2.0
 	github.com/gofiber/fiber v1.12.1
-	github.com/gofiber/jwt v0.1.0
 	github.com/stretchr/testify v1.6.1
 	go.mongodb.org/mongo-driver v1.3.4
-	github.com/stretchr/testify v1.3.0
 	golang.org/x/crypto v0.0.0-20190530122614-20be4c3c3ed5
 )
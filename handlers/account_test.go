@@ -0,0 +1,250 @@
+package handlers_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAccountDeletion(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var s store.Store
+	var blobs store.BlobStore
+	var dir, token, userId string
+
+	oid := func(hex string) primitive.ObjectID {
+		id, err := primitive.ObjectIDFromHex(hex)
+		g.Assert(err == nil).IsTrue()
+		return id
+	}
+
+	comment := func(token, postId string) string {
+		resp := TSend(app, "POST", "/api/v1/comment", token, map[string]string{"postId": postId, "message": "nice post"})
+		g.Assert(resp.StatusCode).Equal(201)
+
+		var c models.Comment
+		TDecode(resp, &c)
+		return c.ID
+	}
+
+	purge := func() int {
+		n, err := jobs.PurgeDeletedAccounts(context.Background(), s, blobs, time.Now().Add(utils.AccountDeletionGrace()+time.Minute))
+		g.Assert(err == nil).IsTrue()
+		return n
+	}
+
+	g.Describe("Account Deletion Test", func() {
+		g.BeforeEach(func() {
+			s = store.NewMemory()
+
+			var err error
+			dir, err = ioutil.TempDir("", "gotter-blobs")
+			g.Assert(err == nil).IsTrue()
+			blobs = store.NewLocalBlobs(dir)
+
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  s,
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+				Blobs:  blobs,
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("needs the password", func() {
+			resp := TSend(app, "DELETE", "/api/v1/user", token, map[string]string{"password": "wrong-password"})
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp = TSend(app, "DELETE", "/api/v1/user", token, map[string]string{})
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp = TSend(app, "GET", "/api/v1/user", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("can be cancelled during the grace period", func() {
+			resp := TSend(app, "DELETE", "/api/v1/user", token, map[string]string{"password": TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(202)
+
+			// the account still works until it's purged
+			resp = TSend(app, "GET", "/api/v1/user", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var user models.User
+			TDecode(resp, &user)
+			g.Assert(user.DeleteAt != nil).IsTrue()
+
+			// nothing is due yet
+			n, err := jobs.PurgeDeletedAccounts(context.Background(), s, blobs, time.Now())
+			g.Assert(err == nil).IsTrue()
+			g.Assert(n).Equal(0)
+
+			resp = TSend(app, "POST", "/api/v1/user/restore", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "POST", "/api/v1/user/restore", token, nil)
+			g.Assert(resp.StatusCode).Equal(400)
+
+			g.Assert(purge()).Equal(0)
+
+			resp = TSend(app, "GET", "/api/v1/user", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("removes every trace of the user once purged", func() {
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, otherUser := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, otherLogin := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			otherToken := otherLogin.Data.Token
+
+			// the user posts, and comments and likes the other user's post
+			resp, _, ownPost := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, _, otherPost := TCreatePost(app, otherToken)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			otherComment := comment(otherToken, ownPost.ID)
+			ownComment := comment(token, otherPost.ID)
+			otherCommentOnOwnPost := comment(otherToken, otherPost.ID)
+
+			g.Assert(TSend(app, "POST", "/api/v1/post/"+otherPost.ID, token, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "POST", "/api/v1/comment/"+otherCommentOnOwnPost, token, nil).StatusCode).Equal(200)
+
+			// they follow each other
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherUser.ID, token, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+userId, otherToken, nil).StatusCode).Equal(200)
+
+			resp = TSend(app, "DELETE", "/api/v1/user", token, map[string]string{"password": TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(202)
+
+			g.Assert(purge()).Equal(1)
+
+			ctx := context.Background()
+
+			_, err := s.Users.FindByID(ctx, oid(userId))
+			g.Assert(err).Equal(store.ErrNotFound)
+
+			_, err = s.Posts.FindByID(ctx, oid(ownPost.ID))
+			g.Assert(err).Equal(store.ErrNotFound)
+
+			_, err = s.Comments.FindByID(ctx, oid(otherComment))
+			g.Assert(err).Equal(store.ErrNotFound)
+
+			_, err = s.Comments.FindByID(ctx, oid(ownComment))
+			g.Assert(err).Equal(store.ErrNotFound)
+
+			post, err := s.Posts.FindByID(ctx, oid(otherPost.ID))
+			g.Assert(err == nil).IsTrue()
+			g.Assert(len(post.Likes)).Equal(0)
+			g.Assert(post.Comments).Equal([]primitive.ObjectID{oid(otherCommentOnOwnPost)})
+
+			c, err := s.Comments.FindByID(ctx, oid(otherCommentOnOwnPost))
+			g.Assert(err == nil).IsTrue()
+			g.Assert(len(c.Likes)).Equal(0)
+
+			u, err := s.Users.FindByID(ctx, oid(otherUser.ID))
+			g.Assert(err == nil).IsTrue()
+			g.Assert(len(u.Following)).Equal(0)
+			g.Assert(len(u.Followers)).Equal(0)
+
+			// and the user can't log in anymore
+			resp = TSend(app, "GET", "/api/v1/user", token, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp, _ = TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+
+		g.It("deletes the files the user uploaded once purged", func() {
+			upload := func(target string) (string, string) {
+				resp := TUpload(app, target, token, "me.jpg", testPhoto(), nil)
+				g.Assert(resp.StatusCode / 100).Equal(2)
+
+				var data struct {
+					ID  string `json:"id"`
+					URL string `json:"url"`
+				}
+				TDecode(resp, &data)
+				return data.ID, data.URL
+			}
+
+			fetch := func(url string) int {
+				return TSend(app, "GET", strings.TrimPrefix(url, utils.AppURL()), "", nil).StatusCode
+			}
+
+			_, avatar := upload("/api/v1/user/avatar")
+			_, banner := upload("/api/v1/user/banner")
+			attachedId, attached := upload("/api/v1/media")
+			unattachedId, unattached := upload("/api/v1/media")
+
+			resp := TSend(app, "POST", "/api/v1/post", token, map[string]interface{}{
+				"title":       "Holidays",
+				"description": "with a photo",
+				"media":       []map[string]string{{"id": attachedId}},
+			})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			urls := []string{avatar, banner, attached, unattached}
+			for _, url := range urls {
+				g.Assert(fetch(url)).Equal(200)
+			}
+
+			resp = TSend(app, "DELETE", "/api/v1/user", token, map[string]string{"password": TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(202)
+
+			g.Assert(purge()).Equal(1)
+
+			for _, url := range urls {
+				g.Assert(fetch(url)).Equal(404)
+			}
+
+			for _, id := range []string{attachedId, unattachedId} {
+				_, err := s.Media.FindByID(context.Background(), oid(id))
+				g.Assert(err).Equal(store.ErrNotFound)
+			}
+
+			// nothing is left in the store, the thumbnails included
+			files := 0
+			filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					files++
+				}
+				return nil
+			})
+			g.Assert(files).Equal(0)
+		})
+	})
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminHandlerInterface interface {
+	ListUsers(c *fiber.Ctx) interface{}
+	SuspendUser(c *fiber.Ctx) interface{}
+	UnsuspendUser(c *fiber.Ctx) interface{}
+	UpdateRole(c *fiber.Ctx) interface{}
+	UnlockUser(c *fiber.Ctx) interface{}
+	DeletePost(c *fiber.Ctx) interface{}
+	DeleteComment(c *fiber.Ctx) interface{}
+}
+
+type AdminHandler struct {
+	Users    store.UserStore
+	Posts    store.PostStore
+	Comments store.CommentStore
+	Tokens   store.RefreshTokenStore
+	Sessions store.SessionStore
+	Attempts store.LoginAttemptStore
+	Media    store.MediaStore
+	Blobs    store.BlobStore
+}
+
+// userRole is the role put in the access token, accounts created before roles existed are plain users.
+func userRole(user *models.User) string {
+	if user.Role == "" {
+		return models.RoleUser
+	}
+	return user.Role
+}
+
+// signupRole gives the admin role to the emails listed in ADMIN_EMAILS (comma separated),
+// that's how the first admins are created. It only applies to verified emails.
+func signupRole(email string) string {
+	for _, admin := range strings.Split(utils.GoDotEnvVariable("ADMIN_EMAILS"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && strings.EqualFold(admin, email) {
+			return models.RoleAdmin
+		}
+	}
+	return models.RoleUser
+}
+
+/**
+ * @Route /admin/users?page=&limit=
+ * @Mothod GET
+ * @Protected ✔️ admin
+ */
+func (a AdminHandler) ListUsers(c *fiber.Ctx) {
+	users, count, err := a.Users.List(c.Fasthttp, pageQuery(c))
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	type Data struct {
+		Count int32         `json:"count"`
+		Users []models.User `json:"users"`
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(Data{Count: count, Users: users}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /admin/users/:id/suspend
+ * @Mothod POST
+ * @Protected ✔️ admin
+ */
+func (a AdminHandler) SuspendUser(c *fiber.Ctx) {
+	a.setSuspended(c, true)
+}
+
+/**
+ * @Route /admin/users/:id/unsuspend
+ * @Mothod POST
+ * @Protected ✔️ admin
+ */
+func (a AdminHandler) UnsuspendUser(c *fiber.Ctx) {
+	a.setSuspended(c, false)
+}
+
+func (a AdminHandler) setSuspended(c *fiber.Ctx, suspended bool) {
+	admin := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if userId.Hex() == admin.ID {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "You can't suspend yourself"})
+		return
+	}
+
+	user, err := a.Users.Update(c.Fasthttp, userId, store.UserUpdate{Suspended: &suspended})
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "User not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// kick the user out right away, WithUser rejects suspended users anyway
+	// but their refresh tokens must not outlive the suspension
+	if suspended {
+		if err := a.Users.IncrementTokenVersion(c.Fasthttp, userId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err := a.Tokens.RevokeUser(c.Fasthttp, userId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err := a.Sessions.RevokeUser(c.Fasthttp, userId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(user); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /admin/users/:id/role
+ * @Body {role: "user" | "moderator" | "admin"}
+ * @Mothod PUT
+ * @Protected ✔️ admin
+ */
+func (a AdminHandler) UpdateRole(c *fiber.Ctx) {
+	inputs := new(models.RoleInputs)
+
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	user, err := a.Users.Update(c.Fasthttp, userId, store.UserUpdate{Role: &inputs.Role})
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "User not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// the role is carried by the access tokens, the user has to log in again to get the new one
+	if err := a.Users.IncrementTokenVersion(c.Fasthttp, userId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(user); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /admin/users/:id/unlock
+ * @Mothod POST
+ * @Protected ✔️ admin
+ */
+func (a AdminHandler) UnlockUser(c *fiber.Ctx) {
+	userId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	user, err := a.Users.FindByID(c.Fasthttp, userId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "User not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// clears the lockout and the backoff of the account, not the ones of the IP addresses
+	if err := a.Attempts.Reset(c.Fasthttp, accountAttemptsKey(user.Email)); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Account unlocked"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /admin/post/:id
+ * @Mothod DELETE
+ * @Protected ✔️ moderator, admin
+ */
+func (a AdminHandler) DeletePost(c *fiber.Ctx) {
+	postId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	post, err := a.Posts.FindByID(c.Fasthttp, postId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Post not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// NilObjectID deletes the post whoever the author is
+	if err := a.Posts.Delete(c.Fasthttp, postId, primitive.NilObjectID); err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if authorId, err := primitive.ObjectIDFromHex(post.Author.ID); err == nil {
+		if err := a.Users.RemovePost(c.Fasthttp, authorId, postId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+	}
+
+	if err := a.Comments.DeleteByPost(c.Fasthttp, postId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := deleteReposts(c.Fasthttp, a.Posts, a.Users, postId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := deletePostMedia(c.Fasthttp, a.Media, a.Blobs, postId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	c.Status(fiber.StatusOK).Send("Post deleted successfully")
+}
+
+/**
+ * @Route /admin/comment/:id
+ * @Mothod DELETE
+ * @Protected ✔️ moderator, admin
+ */
+func (a AdminHandler) DeleteComment(c *fiber.Ctx) {
+	commentId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// NilObjectID deletes the comment whoever wrote it
+	_, err = a.Comments.Delete(c.Fasthttp, commentId, primitive.NilObjectID)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Comment not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := deleteReplies(c.Fasthttp, a.Comments, a.Posts, commentId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	c.Status(fiber.StatusOK).Send("Comment deleted successfully")
+}
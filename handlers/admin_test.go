@@ -0,0 +1,189 @@
+package handlers_test
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestAdminRoutes(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var mails *bytes.Buffer
+
+	adminInputs := TSignInputs{
+		Email:    "admin@gotter.local",
+		UserName: "admin",
+		Password: "password",
+	}
+
+	// verify confirms the email of the last signup, the ADMIN_EMAILS accounts need it to become admins
+	verify := func() {
+		matches := regexp.MustCompile(`Verification token: (\S+)`).FindAllStringSubmatch(mails.String(), -1)
+		g.Assert(len(matches) > 0).IsTrue()
+
+		resp := TSend(app, "GET", "/api/v1/verify-email?token="+matches[len(matches)-1][1], "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+	}
+
+	signupAndLogin := func(inputs TSignInputs) (string, string) {
+		resp, _, user := TSignup(app, inputs)
+		g.Assert(resp.StatusCode).Equal(201)
+		verify()
+
+		resp, login := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+		g.Assert(resp.StatusCode).Equal(200)
+
+		return user.ID, login.Data.Token
+	}
+
+	g.Describe("Admin Routes Test", func() {
+		var adminToken, userId, userToken string
+
+		g.BeforeEach(func() {
+			os.Setenv("ADMIN_EMAILS", adminInputs.Email)
+
+			mails = new(bytes.Buffer)
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(mails, "test@gotter.local"),
+			})
+
+			_, adminToken = signupAndLogin(adminInputs)
+			userId, userToken = signupAndLogin(TSignupInputsVal)
+		})
+
+		g.AfterEach(func() {
+			os.Unsetenv("ADMIN_EMAILS")
+		})
+
+		g.It("returns 403 to non admins", func() {
+			resp := TSend(app, "GET", "/api/v1/admin/users", userToken, nil)
+			g.Assert(resp.StatusCode).Equal(403)
+
+			resp = TSend(app, "GET", "/api/v1/admin/users", "", nil)
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+
+		g.It("makes the ADMIN_EMAILS accounts admins once their email is verified", func() {
+			inputs := TSignInputs{Email: "other.admin@gotter.local", UserName: "otheradmin", Password: "password"}
+			os.Setenv("ADMIN_EMAILS", adminInputs.Email+","+inputs.Email)
+
+			resp, _, _ := TSignup(app, inputs)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, login := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "GET", "/api/v1/user", login.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var user struct {
+				Role string `json:"role"`
+			}
+			TDecode(resp, &user)
+			g.Assert(user.Role).Equal("user")
+
+			resp = TSend(app, "GET", "/api/v1/admin/users", login.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(403)
+
+			// the role comes with a new token
+			verify()
+
+			resp = TSend(app, "GET", "/api/v1/admin/users", login.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp, login = TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "GET", "/api/v1/admin/users", login.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("lists the users", func() {
+			resp := TSend(app, "GET", "/api/v1/admin/users?limit=1", adminToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var data struct {
+				Count int32 `json:"count"`
+				Users []struct {
+					UserName string `json:"username"`
+					Role     string `json:"role"`
+				} `json:"users"`
+			}
+			TDecode(resp, &data)
+
+			g.Assert(data.Count).Equal(int32(2))
+			g.Assert(len(data.Users)).Equal(1)
+			g.Assert(data.Users[0].UserName).Equal(adminInputs.UserName)
+			g.Assert(data.Users[0].Role).Equal("admin")
+		})
+
+		g.It("suspends and unsuspends a user", func() {
+			resp := TSend(app, "POST", "/api/v1/admin/users/"+userId+"/suspend", adminToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			// the current token stops working and the user can't log in
+			resp = TSend(app, "GET", "/api/v1/user", userToken, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp, _ = TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(403)
+
+			resp = TSend(app, "POST", "/api/v1/admin/users/"+userId+"/unsuspend", adminToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp, _ = TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("lets moderators delete any post and comment", func() {
+			resp, _, post := TCreatePost(app, adminToken)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp = TSend(app, "POST", "/api/v1/comment", adminToken, Map{"postId": post.ID, "message": "Test Comment"})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			var comment struct {
+				ID string `json:"id"`
+			}
+			TDecode(resp, &comment)
+
+			// plain users can't moderate
+			resp = TSend(app, "DELETE", "/api/v1/admin/comment/"+comment.ID, userToken, nil)
+			g.Assert(resp.StatusCode).Equal(403)
+
+			resp = TSend(app, "PUT", "/api/v1/admin/users/"+userId+"/role", adminToken, Map{"role": "moderator"})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			// the role comes with a new token
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			userToken = login.Data.Token
+
+			resp = TSend(app, "DELETE", "/api/v1/admin/comment/"+comment.ID, userToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "DELETE", "/api/v1/admin/post/"+post.ID, userToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "DELETE", "/api/v1/admin/post/"+post.ID, userToken, nil)
+			g.Assert(resp.StatusCode).Equal(404)
+
+			// but can't manage users
+			resp = TSend(app, "GET", "/api/v1/admin/users", userToken, nil)
+			g.Assert(resp.StatusCode).Equal(403)
+		})
+	})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyHandlerInterface interface {
+	CreateAPIKey(c *fiber.Ctx) interface{}
+	ListAPIKeys(c *fiber.Ctx) interface{}
+	RevokeAPIKey(c *fiber.Ctx) interface{}
+}
+
+type APIKeyHandler struct {
+	Keys store.APIKeyStore
+}
+
+/**
+ * @Route /api-keys
+ * @Body {name: string, scopes: string[]}
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (a APIKeyHandler) CreateAPIKey(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+	inputs := new(models.APIKeyInputs)
+
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	token, err := utils.RandomToken()
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// the key is only shown in this response, we keep its hash
+	secret := models.APIKeyPrefix + token
+	key := models.APIKey{
+		Name:      inputs.Name,
+		Hash:      utils.HashToken(secret),
+		User:      userId,
+		Hint:      secret[len(secret)-4:],
+		Scopes:    inputs.Scopes,
+		CreatedAt: time.Now(),
+	}
+
+	if err := a.Keys.Create(c.Fasthttp, &key); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "API key created, copy it now as it won't be shown again",
+		"data": fiber.Map{
+			"key":    secret,
+			"apiKey": key,
+		},
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /api-keys
+ * @Mothod GET
+ * @Protected ✔️
+ */
+func (a APIKeyHandler) ListAPIKeys(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	keys, err := a.Keys.ListByUser(c.Fasthttp, userId)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"apiKeys": keys}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /api-keys/:id
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (a APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	keyId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	err = a.Keys.Revoke(c.Fasthttp, keyId, userId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "API key not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "API key revoked"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestAPIKeyRoutes(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token string
+
+	type apiKeyOutput struct {
+		Data struct {
+			Key    string `json:"key"`
+			APIKey struct {
+				ID     string   `json:"id"`
+				Hint   string   `json:"hint"`
+				Scopes []string `json:"scopes"`
+			} `json:"apiKey"`
+		} `json:"data"`
+	}
+
+	createKey := func(scopes ...string) apiKeyOutput {
+		resp := TSend(app, "POST", "/api/v1/api-keys", token, map[string]interface{}{
+			"name":   "ci",
+			"scopes": scopes,
+		})
+		g.Assert(resp.StatusCode).Equal(201)
+
+		var output apiKeyOutput
+		TDecode(resp, &output)
+		return output
+	}
+
+	g.Describe("API Key Routes Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, inputs, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, login := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.It("returns 400 on unknown scopes", func() {
+			resp := TSend(app, "POST", "/api/v1/api-keys", token, map[string]interface{}{
+				"name":   "ci",
+				"scopes": []string{"everything"},
+			})
+			g.Assert(resp.StatusCode).Equal(400)
+		})
+
+		g.It("creates and lists keys without exposing them", func() {
+			key := createKey("posts:write")
+			g.Assert(key.Data.Key[len(key.Data.Key)-4:]).Equal(key.Data.APIKey.Hint)
+
+			resp := TSend(app, "GET", "/api/v1/api-keys", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var list struct {
+				APIKeys []map[string]interface{} `json:"apiKeys"`
+			}
+			TDecode(resp, &list)
+
+			g.Assert(len(list.APIKeys)).Equal(1)
+			g.Assert(list.APIKeys[0]["hint"]).Equal(key.Data.APIKey.Hint)
+			_, hasHash := list.APIKeys[0]["hash"]
+			g.Assert(hasHash).IsFalse()
+		})
+
+		g.It("accepts keys on routes allowed by their scopes", func() {
+			key := createKey("posts:write")
+
+			resp, _, post := TCreatePost(app, key.Data.Key)
+			g.Assert(resp.StatusCode).Equal(201)
+			g.Assert(post.Author.Username).Equal(TSignupInputsVal.UserName)
+
+			// the X-API-Key header works too
+			req := MakeRequest(Req{
+				Method:  "POST",
+				Target:  "/api/v1/post/" + post.ID,
+				Options: Opt{Header: Map{"X-API-Key": key.Data.Key}},
+			})
+			resp, _ = app.Test(req, -1)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			// no user:read scope
+			resp = TSend(app, "GET", "/api/v1/user", key.Data.Key, nil)
+			g.Assert(resp.StatusCode).Equal(403)
+
+			// keys can't manage keys
+			resp = TSend(app, "GET", "/api/v1/api-keys", key.Data.Key, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+
+		g.It("can't change the username or the password", func() {
+			key := createKey("user:write")
+
+			resp := TSend(app, "PUT", "/api/v1/user", key.Data.Key, map[string]string{"password": "taken_over"})
+			g.Assert(resp.StatusCode).Equal(403)
+
+			resp = TSend(app, "PUT", "/api/v1/user", key.Data.Key, map[string]string{"username": "taken_over"})
+			g.Assert(resp.StatusCode).Equal(403)
+
+			// the profile fields are fine
+			resp = TSend(app, "PUT", "/api/v1/user", key.Data.Key, map[string]string{"bio": "updated by a key"})
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("rejects revoked keys", func() {
+			key := createKey("posts:write", "user:read")
+
+			resp := TSend(app, "GET", "/api/v1/user", key.Data.Key, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "DELETE", "/api/v1/api-keys/"+key.Data.APIKey.ID, token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "GET", "/api/v1/user", key.Data.Key, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp = TSend(app, "GET", "/api/v1/user", "gtr_unknown", nil)
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+	})
+}
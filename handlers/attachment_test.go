@@ -0,0 +1,183 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+func TestPostAttachments(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var dir, token, userId string
+
+	upload := func(token, alt string) models.Media {
+		resp := TUpload(app, "/api/v1/media", token, "photo.jpg", testPhoto(), Map{"alt": alt})
+		g.Assert(resp.StatusCode).Equal(201)
+
+		var m models.Media
+		TDecode(resp, &m)
+		return m
+	}
+
+	createPost := func(media []models.AttachmentInput) (int, models.Post) {
+		resp := TSend(app, "POST", "/api/v1/post", token, map[string]interface{}{
+			"title":       "Holidays",
+			"description": "Some pictures of the trip",
+			"media":       media,
+		})
+
+		var post models.Post
+		if resp.StatusCode == 201 {
+			TDecode(resp, &post)
+		}
+		return resp.StatusCode, post
+	}
+
+	timeline := func(target string) []models.PostWithComment {
+		resp := TSend(app, "GET", target, "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Posts []models.PostWithComment `json:"posts"`
+		}
+		TDecode(resp, &data)
+		return data.Posts
+	}
+
+	fetch := func(url string) int {
+		return TSend(app, "GET", strings.TrimPrefix(url, utils.AppURL()), "", nil).StatusCode
+	}
+
+	g.Describe("Post Attachments Test", func() {
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "gotter-blobs")
+			g.Assert(err == nil).IsTrue()
+
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+				Blobs:  store.NewLocalBlobs(dir),
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("returns the media inline in the timelines", func() {
+			first := upload(token, "A beach at sunset")
+			second := upload(token, "")
+
+			// the placeholder of a 4×3 components blurhash
+			g.Assert(len(first.Blurhash)).Equal(28)
+			g.Assert(strings.HasPrefix(first.Blurhash, "L")).IsTrue()
+
+			code, post := createPost([]models.AttachmentInput{{ID: first.ID}, {ID: second.ID, Alt: "A boat"}})
+			g.Assert(code).Equal(201)
+			g.Assert(len(post.Media)).Equal(2)
+
+			for _, posts := range [][]models.PostWithComment{
+				timeline("/api/v1/post/timeline/user/" + userId),
+				timeline("/api/v1/post/timeline/home"),
+			} {
+				g.Assert(len(posts)).Equal(1)
+				media := posts[0].Media
+
+				g.Assert(len(media)).Equal(2)
+				g.Assert(media[0].ID).Equal(first.ID)
+				g.Assert(media[0].Alt).Equal("A beach at sunset")
+				g.Assert(media[0].Width).Equal(20)
+				g.Assert(media[0].Height).Equal(40)
+				g.Assert(media[0].Blurhash).Equal(first.Blurhash)
+				g.Assert(media[1].Alt).Equal("A boat")
+				g.Assert(media[1].URL).Equal(second.URL)
+			}
+		})
+
+		g.It("only attaches unused uploads of the author", func() {
+			m := upload(token, "")
+
+			code, _ := createPost([]models.AttachmentInput{{ID: m.ID}, {ID: m.ID}})
+			g.Assert(code).Equal(400)
+
+			code, _ = createPost([]models.AttachmentInput{{ID: m.ID}})
+			g.Assert(code).Equal(201)
+
+			// already attached
+			code, _ = createPost([]models.AttachmentInput{{ID: m.ID}})
+			g.Assert(code).Equal(400)
+
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, _ := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, otherLogin := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			code, _ = createPost([]models.AttachmentInput{{ID: upload(otherLogin.Data.Token, "").ID}})
+			g.Assert(code).Equal(400)
+
+			code, _ = createPost([]models.AttachmentInput{{ID: "not-an-id"}})
+			g.Assert(code).Equal(400)
+		})
+
+		g.It("limits the number of media and the alt text", func() {
+			inputs := []models.AttachmentInput{}
+			for i := 0; i < models.MaxAttachments+1; i++ {
+				inputs = append(inputs, models.AttachmentInput{ID: upload(token, "").ID})
+			}
+
+			code, _ := createPost(inputs)
+			g.Assert(code).Equal(400)
+
+			code, _ = createPost([]models.AttachmentInput{{ID: inputs[0].ID, Alt: strings.Repeat("a", models.MaxAltLength+1)}})
+			g.Assert(code).Equal(400)
+
+			resp := TUpload(app, "/api/v1/media", token, "photo.jpg", testPhoto(), Map{"alt": strings.Repeat("a", models.MaxAltLength+1)})
+			g.Assert(resp.StatusCode).Equal(400)
+
+			code, post := createPost(inputs[:models.MaxAttachments])
+			g.Assert(code).Equal(201)
+			g.Assert(len(post.Media)).Equal(models.MaxAttachments)
+		})
+
+		g.It("deletes the files with the post", func() {
+			m := upload(token, "")
+
+			code, post := createPost([]models.AttachmentInput{{ID: m.ID}})
+			g.Assert(code).Equal(201)
+
+			g.Assert(fetch(m.URL)).Equal(200)
+			g.Assert(fetch(m.ThumbnailURL)).Equal(200)
+
+			resp := TSend(app, "DELETE", "/api/v1/post/"+post.ID, token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			g.Assert(fetch(m.URL)).Equal(404)
+			g.Assert(fetch(m.ThumbnailURL)).Equal(404)
+		})
+	})
+}
@@ -1,25 +1,38 @@
 package handlers
 
 import (
-	"log"
-
 	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/mailer"
 	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/oidc"
+	"github.com/kiranbhalerao123/gotter/store"
 	"github.com/kiranbhalerao123/gotter/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // These interfaces doesn't necessary,
 // but i kept it to keep track of methods that the handlers has.
 type AuthHandlerInterface interface {
 	Login(ctx *fiber.Ctx) interface{}
+	LoginMFA(ctx *fiber.Ctx) interface{}
 	Signup(ctx *fiber.Ctx) interface{}
+	RefreshToken(ctx *fiber.Ctx) interface{}
+	Logout(ctx *fiber.Ctx) interface{}
+	LogoutAll(ctx *fiber.Ctx) interface{}
+	OAuthLogin(ctx *fiber.Ctx) interface{}
+	OAuthCallback(ctx *fiber.Ctx) interface{}
 }
 
 type AuthHandler struct {
-	UsersColl *mongo.Collection
+	Users         store.UserStore
+	Tokens        store.RefreshTokenStore
+	Revocations   store.RevocationStore
+	OneTimeTokens store.OneTimeTokenStore
+	Mailer        mailer.Mailer
+	Attempts      store.LoginAttemptStore
+	Sessions      store.SessionStore
+	// Providers are the OpenID Connect providers users can log in with, by name
+	Providers map[string]*oidc.Provider
 }
 
 func (a AuthHandler) Login(c *fiber.Ctx) {
@@ -30,14 +43,15 @@ func (a AuthHandler) Login(c *fiber.Ctx) {
 		return
 	}
 
-	// get the user by email
-	user := new(models.User)
+	if !checkLoginAttempts(c, a.Attempts, u.Email) {
+		return
+	}
 
-	filter := bson.M{"email": u.Email}
-	err := a.UsersColl.FindOne(c.Fasthttp, filter).Decode(user)
+	// get the user by email
+	user, err := a.Users.FindByEmail(c.Fasthttp, u.Email)
 
-	if err != nil {
-		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid Credentials"})
+	if err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
 
@@ -51,27 +65,69 @@ func (a AuthHandler) Login(c *fiber.Ctx) {
 	// 	}
 	// }
 
-	isMatch := utils.Password{Password: u.Password}.Compare(user.Password)
+	// unknown emails count as failures too, so locking doesn't tell which accounts exist
+	if user == nil || !(utils.Password{Password: u.Password}).Compare(user.Password) {
+		if err := failLogin(c, a.Attempts, u.Email); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
 
-	if !isMatch {
 		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid Credentials"})
 		return
 	}
 
-	// create access token
-	accessToken, err := utils.CreateJWTToken(map[string]interface{}{
-		"username": user.UserName,
-		"email":    user.Email,
-		"id":       user.ID,
-	})
+	a.completeLogin(c, user)
+}
+
+// completeLogin logs in a user who proved who they are (password, social login),
+// it issues the tokens or asks for the TOTP code first when 2FA is on.
+func (a AuthHandler) completeLogin(c *fiber.Ctx, user *models.User) {
+	if user.Suspended {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "Account suspended"})
+		return
+	}
+
+	// with 2FA on, the first factor alone only gets a token to finish the login at /login/mfa
+	// and the failure counter keeps going until the code is right
+	if user.MFAEnabled {
+		data, err := issueMFAToken(user)
+
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "MFA Required",
+			"data":    data,
+		}); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+		}
+		return
+	}
+
+	if err := succeedLogin(c, a.Attempts, user.Email); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	session, err := startSession(c, a.Sessions, user)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// create access and refresh tokens
+	data, err := issueTokens(c.Fasthttp, a.Tokens, user, session)
 
 	if err != nil {
-		log.Fatal(err)
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
 	}
 
 	err = c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Login Successfully",
-		"data":    fiber.Map{"token": accessToken},
+		"data":    data,
 	})
 
 	if err != nil {
@@ -80,39 +136,139 @@ func (a AuthHandler) Login(c *fiber.Ctx) {
 	}
 }
 
-func (a AuthHandler) Signup(c *fiber.Ctx) {
-	inputs := new(models.SignupInputs)
+/**
+ * @Route /login/mfa
+ * @Body {mfaToken: string, code?: string, recoveryCode?: string}
+ * @Mothod POST
+ */
+func (a AuthHandler) LoginMFA(c *fiber.Ctx) {
+	inputs := new(models.LoginMFAInputs)
 
-	// parse body inputs
 	if err := c.BodyParser(inputs); err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	// validate inputs
 	if err := inputs.Validate(); err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	query := bson.D{{Key: "email", Value: inputs.Email}}
+	mapClaims, err := utils.ParseJWTToken(inputs.MFAToken)
+	if err != nil || mapClaims["typ"] != models.MFAPendingToken {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid or expired MFA token"})
+		return
+	}
 
-	existingUser := new(models.User)
-	err := a.UsersColl.FindOne(c.Fasthttp, query).Decode(existingUser)
+	id, _ := mapClaims["id"].(string)
+	version, _ := mapClaims["tv"].(float64)
 
+	userId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		// hack 🙃, dont know how to handle this one
-		if err.Error() != "mongo: no documents in result" {
-			log.Fatal(err)
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid or expired MFA token"})
+		return
+	}
+
+	user, err := a.Users.FindByID(c.Fasthttp, userId)
+
+	// a password reset or logout/all since the first step invalidates the token too
+	if err != nil || !user.MFAEnabled || user.TokenVersion != int(version) {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid or expired MFA token"})
+		return
+	}
+
+	if user.Suspended {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "Account suspended"})
+		return
+	}
+
+	// codes are only 6 digits, guessing them is throttled like passwords
+	if !checkLoginAttempts(c, a.Attempts, user.Email) {
+		return
+	}
+
+	ok, err := checkSecondFactor(c.Fasthttp, a.Users, user, inputs.Code, inputs.RecoveryCode)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if !ok {
+		if err := failLogin(c, a.Attempts, user.Email); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
 			return
 		}
+
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid code"})
+		return
+	}
+
+	if err := succeedLogin(c, a.Attempts, user.Email); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
 	}
 
-	if existingUser.ID != "" {
+	session, err := startSession(c, a.Sessions, user)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	data, err := issueTokens(c.Fasthttp, a.Tokens, user, session)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Login Successfully",
+		"data":    data,
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+func (a AuthHandler) Signup(c *fiber.Ctx) {
+	inputs := new(models.SignupInputs)
+
+	// parse body inputs
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// validate inputs
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	_, err := a.Users.FindByEmail(c.Fasthttp, inputs.Email)
+
+	if err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err == nil {
 		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "User already exists"})
 		return
 	}
 
+	// the usernames are unique, the profiles and the @mentions find the users by it
+	_, err = a.Users.FindByUserName(c.Fasthttp, inputs.UserName)
+
+	if err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err == nil {
+		c.Status(fiber.StatusConflict).Send(fiber.Map{"message": "Username already taken"})
+		return
+	}
+
 	p := utils.Password{Password: inputs.Password}
 	hashPassword := p.Hash()
 
@@ -120,29 +276,32 @@ func (a AuthHandler) Signup(c *fiber.Ctx) {
 		Email:     inputs.Email,
 		Password:  hashPassword,
 		UserName:  inputs.UserName,
+		Role:      models.RoleUser,
 		Posts:     []primitive.ObjectID{},
 		Following: []primitive.ObjectID{},
 		Followers: []primitive.ObjectID{},
 	}
 
-	// force MongoDB to always set its own generated ObjectIDs
-	user.ID = ""
-	insertionResult, err := a.UsersColl.InsertOne(c.Fasthttp, user)
+	err = a.Users.Create(c.Fasthttp, &user)
 
-	if err != nil {
-		log.Fatal(err)
+	if err == store.ErrDuplicate {
+		c.Status(fiber.StatusConflict).Send(fiber.Map{"message": "Username already taken"})
+		return
 	}
 
-	// get the user doc
-	createdUser := new(models.User)
-	filter := bson.D{{Key: "_id", Value: insertionResult.InsertedID}}
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
 
-	if err := a.UsersColl.FindOne(c.Fasthttp, filter).Decode(createdUser); err != nil {
+	// the account works right away, the verification link only unlocks posting
+	// when the verified email policy is on
+	if err := sendVerificationEmail(c.Fasthttp, a.OneTimeTokens, a.Mailer, &user); err != nil {
 		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
 
-	if err := c.Status(fiber.StatusCreated).JSON(createdUser); err != nil {
+	if err := c.Status(fiber.StatusCreated).JSON(user); err != nil {
 		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
@@ -1,14 +1,19 @@
 package handlers_test
 
 import (
-	"context"
+	"io/ioutil"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/dgrijalva/jwt-go"
 	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
 	. "github.com/kiranbhalerao123/gotter/app"
-	. "github.com/kiranbhalerao123/gotter/config"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
 	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
 
 	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
 	"github.com/stretchr/testify/assert"
@@ -17,17 +22,16 @@ import (
 func TestAuthRoutes(t *testing.T) {
 	g := Goblin(t)
 
-	app := SetupApp()
-	SetupDB()
-	SetupRouter(app)
+	var app *fiber.App
 
 	g.Describe("Auth Routes Tests", func() {
 		g.BeforeEach(func() {
-			err := Mongo.DB.Drop(context.Background())
-
-			if err != nil {
-				panic(err)
-			}
+			// every case runs against a fresh app with an empty in-memory store
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
 		})
 
 		g.Describe("Signup Route Suits", func() {
@@ -52,6 +56,17 @@ func TestAuthRoutes(t *testing.T) {
 				g.Assert(data.Email).Equal(inputs.Email)
 				g.Assert(data.UserName).Equal(inputs.UserName)
 			})
+
+			g.It("refuses malformed or taken usernames", func() {
+				resp := TSend(app, "POST", "/api/v1/signup", "", TSignInputs{Email: "other@gotter.local", UserName: "not valid!", Password: "password"})
+				g.Assert(resp.StatusCode).Equal(400)
+
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp = TSend(app, "POST", "/api/v1/signup", "", TSignInputs{Email: "other@gotter.local", UserName: inputs.UserName, Password: "password"})
+				g.Assert(resp.StatusCode).Equal(409)
+			})
 		})
 
 		g.Describe("Login Route Suits", func() {
@@ -93,6 +108,252 @@ func TestAuthRoutes(t *testing.T) {
 
 				g.Assert(resp.StatusCode).Equal(200)
 				assert.NotNil(t, data.Data.Token)
+				assert.NotEmpty(t, data.Data.RefreshToken)
+			})
+		})
+
+		g.Describe("Refresh Token Route Suits", func() {
+			g.It("returns 400 on invalid request", func() {
+				req := httptest.NewRequest(
+					"POST",
+					"/api/v1/token/refresh",
+					nil,
+				)
+
+				resp, _ := app.Test(req, -1)
+				g.Assert(resp.StatusCode).Equal(400)
+			})
+
+			g.It("returns 401 on unknown refresh token", func() {
+				resp, _ := TRefresh(app, "not-a-refresh-token")
+				g.Assert(resp.StatusCode).Equal(401)
+			})
+
+			g.It("rotates the refresh token", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, login := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp, refreshed := TRefresh(app, login.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(200)
+				assert.NotEmpty(t, refreshed.Data.Token)
+				assert.NotEqual(t, login.Data.RefreshToken, refreshed.Data.RefreshToken)
+
+				// the new access token is accepted
+				req := MakeRequest(Req{
+					Method: "GET",
+					Target: "/api/v1/user",
+					Options: Opt{
+						Header: Map{
+							"Authorization": "Bearer " + refreshed.Data.Token,
+						},
+					},
+				})
+
+				resp, _ = app.Test(req, -1)
+				g.Assert(resp.StatusCode).Equal(200)
+
+				// and the new refresh token can be rotated again
+				resp, _ = TRefresh(app, refreshed.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(200)
+			})
+
+			g.It("revokes the token family when an old refresh token is replayed", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, login := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp, refreshed := TRefresh(app, login.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(200)
+
+				// replay the already rotated token
+				resp, _ = TRefresh(app, login.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(401)
+
+				// the token issued by the rotation is revoked as well
+				resp, _ = TRefresh(app, refreshed.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(401)
+
+				// other sessions are not affected
+				resp, other := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp, _ = TRefresh(app, other.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(200)
+			})
+		})
+
+		g.Describe("Logout Route Suits", func() {
+			getUser := func(token string) int {
+				req := MakeRequest(Req{
+					Method: "GET",
+					Target: "/api/v1/user",
+					Options: Opt{
+						Header: Map{
+							"Authorization": "Bearer " + token,
+						},
+					},
+				})
+
+				resp, _ := app.Test(req, -1)
+				return resp.StatusCode
+			}
+
+			logout := func(target, token string) int {
+				req := MakeRequest(Req{
+					Method: "POST",
+					Target: target,
+					Options: Opt{
+						Header: Map{
+							"Authorization": "Bearer " + token,
+						},
+					},
+				})
+
+				resp, _ := app.Test(req, -1)
+				return resp.StatusCode
+			}
+
+			g.It("returns 401 without auth token", func() {
+				g.Assert(logout("/api/v1/logout", "")).Equal(401)
+			})
+
+			g.It("revokes the current session only", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, first := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp, second := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				g.Assert(logout("/api/v1/logout", first.Data.Token)).Equal(200)
+
+				// the access and refresh tokens of the session are rejected
+				g.Assert(getUser(first.Data.Token)).Equal(401)
+				resp, _ = TRefresh(app, first.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(401)
+
+				// the other session still works
+				g.Assert(getUser(second.Data.Token)).Equal(200)
+				resp, _ = TRefresh(app, second.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(200)
+			})
+
+			g.It("revokes every session on logout all", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, first := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp, second := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				g.Assert(logout("/api/v1/logout/all", first.Data.Token)).Equal(200)
+
+				g.Assert(getUser(first.Data.Token)).Equal(401)
+				g.Assert(getUser(second.Data.Token)).Equal(401)
+				resp, _ = TRefresh(app, second.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(401)
+
+				// logging in again issues a working token
+				resp, third := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+				g.Assert(getUser(third.Data.Token)).Equal(200)
+			})
+		})
+
+		g.Describe("Access Token Suits", func() {
+			// resign returns the token of the login with its claims changed, a nil value removes the claim
+			resign := func(token string, changes map[string]interface{}) string {
+				claims := jwt.MapClaims{}
+				_, _, err := new(jwt.Parser).ParseUnverified(token, claims)
+				g.Assert(err == nil).IsTrue()
+
+				for key, val := range changes {
+					if val == nil {
+						delete(claims, key)
+					} else {
+						claims[key] = val
+					}
+				}
+
+				forged, err := utils.CreateJWTToken(claims)
+				g.Assert(err == nil).IsTrue()
+				return forged
+			}
+
+			login := func() string {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, data := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+				g.Assert(resp.StatusCode).Equal(200)
+				return data.Data.Token
+			}
+
+			g.It("rejects the tokens that never expire", func() {
+				token := resign(login(), map[string]interface{}{"exp": nil})
+
+				resp := TSend(app, "GET", "/api/v1/user", token, nil)
+				g.Assert(resp.StatusCode).Equal(401)
+			})
+
+			g.It("only accepts access tokens for this API", func() {
+				token := login()
+
+				for _, changes := range []map[string]interface{}{
+					{"typ": nil},
+					{"typ": models.MFAPendingToken},
+					{"aud": nil},
+					{"aud": "https://elsewhere.example"},
+				} {
+					resp := TSend(app, "GET", "/api/v1/user", resign(token, changes), nil)
+					g.Assert(resp.StatusCode).Equal(401)
+				}
+
+				resp := TSend(app, "GET", "/api/v1/user", token, nil)
+				g.Assert(resp.StatusCode).Equal(200)
+			})
+
+			g.It("rejects the tokens that can't be logged out", func() {
+				token := resign(login(), map[string]interface{}{"jti": nil})
+
+				resp := TSend(app, "GET", "/api/v1/user", token, nil)
+				g.Assert(resp.StatusCode).Equal(401)
+
+				resp = TSend(app, "POST", "/api/v1/logout", token, nil)
+				g.Assert(resp.StatusCode).Equal(401)
 			})
 		})
 	})
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/**
+ * @Route /user/:id/block
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (u UserHandler) BlockUser(c *fiber.Ctx) {
+	u.updateRelation(c, u.Users.Block, "Blocked the user", "isBlocked", true)
+}
+
+/**
+ * @Route /user/:id/block
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (u UserHandler) UnblockUser(c *fiber.Ctx) {
+	u.updateRelation(c, u.Users.Unblock, "Unblocked the user", "isBlocked", false)
+}
+
+/**
+ * @Route /user/:id/mute
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (u UserHandler) MuteUser(c *fiber.Ctx) {
+	u.updateRelation(c, u.Users.Mute, "Muted the user", "isMuted", true)
+}
+
+/**
+ * @Route /user/:id/mute
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (u UserHandler) UnmuteUser(c *fiber.Ctx) {
+	u.updateRelation(c, u.Users.Unmute, "Unmuted the user", "isMuted", false)
+}
+
+// updateRelation applies a block or mute change of the current user towards the user of the :id param.
+func (u UserHandler) updateRelation(c *fiber.Ctx, apply func(ctx context.Context, userID, targetID primitive.ObjectID) error, message, flag string, value bool) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	targetId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if targetId == userId {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "You can't do this to yourself"})
+		return
+	}
+
+	_, err = u.Users.FindByID(c.Fasthttp, targetId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "User not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := apply(c.Fasthttp, userId, targetId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": message, flag: value}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+// isBlockedBetween tells if one of the two users blocked the other.
+func isBlockedBetween(ctx context.Context, users store.UserStore, userId, otherId primitive.ObjectID) (bool, error) {
+	blocked, err := users.IsBlocked(ctx, otherId, userId)
+	if err != nil || blocked {
+		return blocked, err
+	}
+	return users.IsBlocked(ctx, userId, otherId)
+}
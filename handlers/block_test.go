@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBlockAndMute(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token, userId, otherToken, otherId string
+
+	me := func(token string) models.User {
+		resp := TSend(app, "GET", "/api/v1/user", token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var user models.User
+		TDecode(resp, &user)
+		return user
+	}
+
+	home := func() int {
+		resp := TSend(app, "GET", "/api/v1/post/timeline/home/"+userId, "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Count int `json:"count"`
+		}
+		TDecode(resp, &data)
+		return data.Count
+	}
+
+	g.Describe("Block and Mute Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, otherUser := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+			otherId = otherUser.ID
+
+			resp, otherLogin := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			otherToken = otherLogin.Data.Token
+		})
+
+		g.It("removes the follow edges and keeps both users from following again", func() {
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherId, token, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+userId, otherToken, nil).StatusCode).Equal(200)
+
+			resp := TSend(app, "POST", "/api/v1/user/"+otherId+"/block", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			user := me(token)
+			g.Assert(len(user.Following)).Equal(0)
+			g.Assert(len(user.Followers)).Equal(0)
+			g.Assert(user.Blocked[0].Hex()).Equal(otherId)
+
+			other := me(otherToken)
+			g.Assert(len(other.Following)).Equal(0)
+			g.Assert(len(other.Followers)).Equal(0)
+
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+userId, otherToken, nil).StatusCode).Equal(403)
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherId, token, nil).StatusCode).Equal(403)
+
+			resp = TSend(app, "DELETE", "/api/v1/user/"+otherId+"/block", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+userId, otherToken, nil).StatusCode).Equal(200)
+		})
+
+		g.It("keeps blocked users from commenting on and liking the posts", func() {
+			resp, _, post := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			// a like from before the block
+			g.Assert(TSend(app, "POST", "/api/v1/post/"+post.ID, otherToken, nil).StatusCode).Equal(200)
+
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherId+"/block", token, nil).StatusCode).Equal(200)
+
+			resp = TSend(app, "POST", "/api/v1/comment", otherToken, map[string]string{"postId": post.ID, "message": "nice post"})
+			g.Assert(resp.StatusCode).Equal(403)
+
+			// it can be taken back but not given again
+			g.Assert(TSend(app, "POST", "/api/v1/post/"+post.ID, otherToken, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "POST", "/api/v1/post/"+post.ID, otherToken, nil).StatusCode).Equal(403)
+
+			// other users still can
+			resp = TSend(app, "POST", "/api/v1/comment", token, map[string]string{"postId": post.ID, "message": "thanks"})
+			g.Assert(resp.StatusCode).Equal(201)
+		})
+
+		g.It("hides the posts of muted users from the home timeline", func() {
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherId, token, nil).StatusCode).Equal(200)
+
+			resp, _, _ := TCreatePost(app, otherToken)
+			g.Assert(resp.StatusCode).Equal(201)
+			g.Assert(home()).Equal(1)
+
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherId+"/mute", token, nil).StatusCode).Equal(200)
+			g.Assert(home()).Equal(0)
+
+			// muting doesn't unfollow
+			g.Assert(len(me(token).Following)).Equal(1)
+
+			g.Assert(TSend(app, "DELETE", "/api/v1/user/"+otherId+"/mute", token, nil).StatusCode).Equal(200)
+			g.Assert(home()).Equal(1)
+		})
+
+		g.It("rejects yourself and unknown users", func() {
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+userId+"/block", token, nil).StatusCode).Equal(400)
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+userId+"/mute", token, nil).StatusCode).Equal(400)
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+primitive.NewObjectID().Hex()+"/block", token, nil).StatusCode).Equal(404)
+		})
+	})
+}
@@ -1,20 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber"
-	conf "github.com/kiranbhalerao123/gotter/config"
 	"github.com/kiranbhalerao123/gotter/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/kiranbhalerao123/gotter/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CommentHandler struct {
-	CommentColl *mongo.Collection
-	PostColl    *mongo.Collection
+	Comments store.CommentStore
+	Posts    store.PostStore
+	Users    store.UserStore
 }
 
 type CommentHandlerInterface interface {
@@ -23,11 +23,22 @@ type CommentHandlerInterface interface {
 	DeleteComment(c *fiber.Ctx) interface{}
 	LikeDislikeComment(c *fiber.Ctx) interface{}
 	GetComment(c *fiber.Ctx) interface{}
+	GetThread(c *fiber.Ctx) interface{}
+}
+
+// deleteReplies removes the replies to a deleted comment, and the replies to them,
+// and pulls the whole conversation out of the post's comments[].
+func deleteReplies(ctx context.Context, comments store.CommentStore, posts store.PostStore, commentId primitive.ObjectID) error {
+	replies, err := comments.DeleteReplies(ctx, []primitive.ObjectID{commentId})
+	if err != nil {
+		return err
+	}
+	return posts.RemoveComments(ctx, append(replies, commentId))
 }
 
 /**
  * @Route /comment
- * @Body {postId: string, message: string}
+ * @Body {postId: string, message: string, parent?: string}
  * @Mothod POST
  * @Protected ✔️
  */
@@ -37,6 +48,8 @@ func (CH CommentHandler) CommentPost(c *fiber.Ctx) {
 	body := new(struct {
 		PostId  string
 		Message string
+		// Parent is the comment replied to, blank for a comment on the post
+		Parent string
 	})
 
 	if e := c.BodyParser(body); e != nil {
@@ -51,34 +64,104 @@ func (CH CommentHandler) CommentPost(c *fiber.Ctx) {
 	}
 
 	// check if post is available
-	var post models.Post
-	e := CH.PostColl.FindOne(c.Fasthttp, bson.M{"_id": postId}).Decode(&post)
+	post, e := CH.Posts.FindByID(c.Fasthttp, postId)
 	if e != nil {
 		c.Status(fiber.StatusBadRequest).Send(e)
 		return
 	}
 
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	authorId, err := primitive.ObjectIDFromHex(post.Author.ID)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// a reply answers a comment of the same post
+	var parentId *primitive.ObjectID
+
+	if body.Parent != "" {
+		id, err := primitive.ObjectIDFromHex(body.Parent)
+		if err != nil {
+			c.Status(fiber.StatusBadRequest).Send(err)
+			return
+		}
+
+		parent, err := CH.Comments.FindByID(c.Fasthttp, id)
+
+		if err == store.ErrNotFound || (err == nil && parent.Post != postId) {
+			c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "The parent comment isn't on this post"})
+			return
+		}
+
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		parentAuthorId, err := primitive.ObjectIDFromHex(parent.User.ID)
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		blocked, err := isBlockedBetween(c.Fasthttp, CH.Users, userId, parentAuthorId)
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if blocked {
+			c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "You can't reply to this comment"})
+			return
+		}
+
+		parentId = &id
+	}
+
+	// neither the author nor the commenter may have blocked the other
+	blocked, err := isBlockedBetween(c.Fasthttp, CH.Users, userId, authorId)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if blocked {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "You can't comment on this post"})
+		return
+	}
+
+	mentions, err := resolveMentions(c.Fasthttp, CH.Users, user, models.ParseMentions("message", body.Message))
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
 	comment := models.Comment{
 		Message:   body.Message,
 		CreatedAt: time.Now(),
 		Post:      postId,
 		Likes:     []primitive.ObjectID{},
 		User:      models.Author{ID: user.ID, UserName: user.UserName},
+		Parent:    parentId,
+		Mentions:  mentions,
 	}
 
 	// create comment
-	insertedResult, err := CH.CommentColl.InsertOne(c.Fasthttp, comment)
+	err = CH.Comments.Create(c.Fasthttp, &comment)
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	comment.ID = insertedResult.InsertedID.(primitive.ObjectID).Hex()
-
 	// add comment to post
-	filter := bson.M{"_id": postId}
-	update := bson.M{"$push": bson.M{"comments": insertedResult.InsertedID}}
-	_, err = CH.PostColl.UpdateOne(c.Fasthttp, filter, update)
+	commentId, _ := primitive.ObjectIDFromHex(comment.ID)
+	err = CH.Posts.AddComment(c.Fasthttp, postId, commentId)
 
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
@@ -100,17 +183,25 @@ func (CH CommentHandler) UpdateComment(c *fiber.Ctx) {
 		return
 	}
 
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
 	commentId, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	var comment models.Comment
+	mentions, err := resolveMentions(c.Fasthttp, CH.Users, user, models.ParseMentions("message", body.Comment))
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
 
-	filter := bson.M{"_id": commentId, "user._id": user.ID}
-	update := bson.M{"$set": bson.M{"message": body.Comment}}
-	err = CH.CommentColl.FindOneAndUpdate(c.Fasthttp, filter, update, &conf.MongoOps.New).Decode(&comment)
+	comment, err := CH.Comments.Update(c.Fasthttp, commentId, userId, body.Comment, mentions)
 
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
@@ -126,6 +217,12 @@ func (CH CommentHandler) UpdateComment(c *fiber.Ctx) {
 func (CH CommentHandler) DeleteComment(c *fiber.Ctx) {
 	user := c.Locals("user").(models.User)
 
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
 	commentId, err := primitive.ObjectIDFromHex(c.Params("id"))
 
 	if err != nil {
@@ -133,20 +230,15 @@ func (CH CommentHandler) DeleteComment(c *fiber.Ctx) {
 		return
 	}
 
-	var comment models.Comment
-
-	e := CH.CommentColl.FindOneAndDelete(c.Fasthttp, bson.M{"_id": commentId, "user._id": user.ID}).Decode(&comment)
+	_, e := CH.Comments.Delete(c.Fasthttp, commentId, userId)
 
 	if e != nil {
 		c.Status(fiber.StatusInternalServerError).Send(e)
 		return
 	}
 
-	// pull out commentId from post collection's Comment[]
-	filter := bson.M{"_id": comment.Post}
-	update := bson.M{"$pull": bson.M{"comments": commentId}}
-
-	_, err = CH.PostColl.UpdateOne(c.Fasthttp, filter, update)
+	// pull out commentId and the replies to it from post collection's Comment[]
+	err = deleteReplies(c.Fasthttp, CH.Comments, CH.Posts, commentId)
 
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
@@ -170,38 +262,27 @@ func (CH CommentHandler) LikeDislikeComment(c *fiber.Ctx) {
 		return
 	}
 
-	var comment models.Comment
 	// check whether the comment exists or not
-	err = CH.CommentColl.FindOne(c.Fasthttp, bson.M{"_id": commentId}).Decode(&comment)
+	_, err = CH.Comments.FindByID(c.Fasthttp, commentId)
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
 	// check whether the user already liked the comment
-	err = CH.CommentColl.FindOne(c.Fasthttp, bson.M{"_id": commentId, "likes": bson.M{"$in": bson.A{userId}}}).Decode(&models.Comment{})
-
-	var alreadyLiked bool
-
-	if err == nil {
-		alreadyLiked = true
-	} else {
-		if err.Error() != "mongo: no documents in result" {
-			c.Status(fiber.StatusBadRequest).Send(err)
-			return
-		}
+	alreadyLiked, err := CH.Comments.IsLiked(c.Fasthttp, commentId, userId)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
 	}
 
 	// update the comment doc
-	filter := bson.M{"_id": commentId}
-	update := bson.M{"$push": bson.M{"likes": userId}}
-
 	if alreadyLiked {
-		update = bson.M{"$pull": bson.M{"likes": userId}}
+		err = CH.Comments.Unlike(c.Fasthttp, commentId, userId)
+	} else {
+		err = CH.Comments.Like(c.Fasthttp, commentId, userId)
 	}
 
-	_, err = CH.CommentColl.UpdateOne(c.Fasthttp, filter, update)
-
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
@@ -239,31 +320,7 @@ func (CH CommentHandler) GetComment(c *fiber.Ctx) {
 
 	skip := (page - 1) * limit
 
-	cur, err := CH.CommentColl.Aggregate(c.Fasthttp, []bson.M{
-		{"$project": bson.M{
-			"_id":       1,
-			"message":   1,
-			"post":      1,
-			"user":      1,
-			"createdAt": 1,
-			"likes":     1,
-			"count":     bson.M{"$size": "$likes"},
-		}},
-		{"$sort": bson.M{"count": -1}},
-		{"$skip": skip},
-		{"$facet": bson.M{
-			"count":    bson.A{bson.M{"$count": "count"}},
-			"comments": bson.A{bson.M{"$limit": limit}},
-		}},
-		{"$project": bson.M{
-			"count":    bson.M{"$arrayElemAt": bson.A{"$count", 0}},
-			"comments": 1,
-		}},
-		{"$project": bson.M{
-			"count":    "$count.count",
-			"comments": 1,
-		}},
-	})
+	comments, count, err := CH.Comments.List(c.Fasthttp, store.Page{Skip: skip, Limit: limit})
 
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
@@ -275,31 +332,9 @@ func (CH CommentHandler) GetComment(c *fiber.Ctx) {
 		Comments []models.Comment `json:"comments"`
 	}
 
-	var data []Data
-
-	for cur.Next(c.Fasthttp) {
-		var d Data
-		err = cur.Decode(&d)
-
-		if err != nil {
-			c.Status(fiber.StatusBadRequest).Send(err)
-			return
-		}
-		data = append(data, d)
-	}
-
-	if err := cur.Err(); err != nil {
-		if err != nil {
-			c.Status(fiber.StatusBadRequest).Send(err)
-			return
-		}
-	}
-
-	// Close the cursor once finished
-	cur.Close(c.Fasthttp)
 	err = c.Status(fiber.StatusOK).JSON(Data{
-		Count:    data[0].Count + int32(skip),
-		Comments: data[0].Comments,
+		Count:    count,
+		Comments: comments,
 	})
 
 	if err != nil {
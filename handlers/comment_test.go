@@ -2,31 +2,32 @@ package handlers_test
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
+	"io/ioutil"
 	"testing"
 
 	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
 	. "github.com/kiranbhalerao123/gotter/app"
-	. "github.com/kiranbhalerao123/gotter/config"
 	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
 	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
 )
 
 func TestCommentsRoute(t *testing.T) {
 	g := Goblin(t)
 
-	app := SetupApp()
-	SetupDB()
-	SetupRouter(app)
+	var app *fiber.App
 
 	g.Describe("Comment Routes Test", func() {
 		g.BeforeEach(func() {
-			err := Mongo.DB.Drop(context.Background())
-
-			if err != nil {
-				panic(err)
-			}
+			// every case runs against a fresh app with an empty in-memory store
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
 		})
 
 		g.Describe("Create Comment Route Suits", func() {
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExportHandlerInterface interface {
+	RequestExport(c *fiber.Ctx) interface{}
+	GetExport(c *fiber.Ctx) interface{}
+	DownloadExport(c *fiber.Ctx) interface{}
+}
+
+// ExportHandler lets users download everything we hold about them,
+// the archives are built by jobs.Exporter.
+type ExportHandler struct {
+	Exports store.ExportStore
+}
+
+// exportStatus is the export with the link its archive is downloaded from once it's ready.
+func exportStatus(export *models.Export) (fiber.Map, error) {
+	status := fiber.Map{"export": export}
+
+	if export.Status == models.ExportReady {
+		link, err := jobs.DownloadURL(*export)
+		if err != nil {
+			return nil, err
+		}
+		status["downloadUrl"] = link
+	}
+	return status, nil
+}
+
+/**
+ * @Route /user/export
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (e ExportHandler) RequestExport(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// asking again while the archive is being built doesn't queue another one
+	export, err := e.Exports.FindLatest(c.Fasthttp, userId)
+
+	if err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err == store.ErrNotFound || export.Status != models.ExportPending {
+		export = &models.Export{
+			User:      userId,
+			Status:    models.ExportPending,
+			CreatedAt: time.Now(),
+		}
+
+		if err := e.Exports.Create(c.Fasthttp, export); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+	}
+
+	if err := c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Your archive is being prepared, we'll email you the download link",
+		"export":  export,
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /user/export
+ * @Mothod GET
+ * @Protected ✔️
+ */
+func (e ExportHandler) GetExport(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	export, err := e.Exports.FindLatest(c.Fasthttp, userId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "No export requested"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	status, err := exportStatus(export)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(status); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /user/export/download?token=
+ * @Mothod GET
+ */
+func (e ExportHandler) DownloadExport(c *fiber.Ctx) {
+	// the link is opened from the email, the signed token stands for the login
+	claims, err := utils.ParseJWTToken(c.Query("token"))
+	if err != nil || claims["typ"] != models.ExportDownloadToken {
+		c.Status(fiber.StatusGone).Send(fiber.Map{"message": "The download link is invalid or expired"})
+		return
+	}
+
+	id, _ := claims["id"].(string)
+	exportId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.Status(fiber.StatusGone).Send(fiber.Map{"message": "The download link is invalid or expired"})
+		return
+	}
+
+	export, err := e.Exports.FindByID(c.Fasthttp, exportId)
+
+	if err == store.ErrNotFound || (err == nil && (export.Status != models.ExportReady || !export.ExpiresAt.After(time.Now()))) {
+		c.Status(fiber.StatusGone).Send(fiber.Map{"message": "The download link is invalid or expired"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	if err := c.Download(export.File, "gotter-export-"+export.ReadyAt.UTC().Format("2006-01-02")+".zip"); err != nil {
+		c.Status(fiber.StatusGone).Send(fiber.Map{"message": "The download link is invalid or expired"})
+		return
+	}
+}
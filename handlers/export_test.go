@@ -0,0 +1,218 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDataExport(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var s store.Store
+	var mails *bytes.Buffer
+	var exporter jobs.Exporter
+	var token, userId string
+
+	type exportStatus struct {
+		Export      models.Export `json:"export"`
+		DownloadURL string        `json:"downloadUrl"`
+	}
+
+	status := func() exportStatus {
+		resp := TSend(app, "GET", "/api/v1/user/export", token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var out exportStatus
+		TDecode(resp, &out)
+		return out
+	}
+
+	run := func() {
+		g.Assert(exporter.Run(context.Background()) == nil).IsTrue()
+	}
+
+	// download follows the link without logging in and returns the files of the archive
+	download := func(link string) (int, map[string][]byte) {
+		resp := TSend(app, "GET", strings.TrimPrefix(link, utils.AppURL()), "", nil)
+		if resp.StatusCode != 200 {
+			return resp.StatusCode, nil
+		}
+		g.Assert(strings.Contains(resp.Header.Get("Content-Disposition"), "attachment")).IsTrue()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		g.Assert(err == nil).IsTrue()
+
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		g.Assert(err == nil).IsTrue()
+
+		files := map[string][]byte{}
+		for _, f := range archive.File {
+			r, err := f.Open()
+			g.Assert(err == nil).IsTrue()
+			files[f.Name], err = ioutil.ReadAll(r)
+			g.Assert(err == nil).IsTrue()
+			r.Close()
+		}
+		return resp.StatusCode, files
+	}
+
+	g.Describe("Data Export Test", func() {
+		g.BeforeEach(func() {
+			s = store.NewMemory()
+			mails = new(bytes.Buffer)
+
+			dir, err := ioutil.TempDir("", "gotter-exports")
+			g.Assert(err == nil).IsTrue()
+
+			m := mailer.NewLogMailer(mails, "test@gotter.local")
+			exporter = jobs.Exporter{Store: s, Mailer: m, Dir: dir}
+
+			app = SetupApp()
+			SetupRouter(app, Options{Store: s, Mailer: m})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(exporter.Dir)
+		})
+
+		g.It("builds the archive in the background and emails the link", func() {
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, otherUser := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, otherLogin := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp, _, post := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, _, otherPost := TCreatePost(app, otherLogin.Data.Token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			g.Assert(TSend(app, "POST", "/api/v1/comment", token, map[string]string{"postId": otherPost.ID, "message": "nice post"}).StatusCode).Equal(201)
+			g.Assert(TSend(app, "POST", "/api/v1/post/"+otherPost.ID, token, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherUser.ID, token, nil).StatusCode).Equal(200)
+
+			resp = TSend(app, "POST", "/api/v1/user/export", token, nil)
+			g.Assert(resp.StatusCode).Equal(202)
+
+			var requested exportStatus
+			TDecode(resp, &requested)
+			g.Assert(requested.Export.Status).Equal(models.ExportPending)
+
+			// asking again doesn't queue another export
+			resp = TSend(app, "POST", "/api/v1/user/export", token, nil)
+			g.Assert(resp.StatusCode).Equal(202)
+
+			var again exportStatus
+			TDecode(resp, &again)
+			g.Assert(again.Export.ID).Equal(requested.Export.ID)
+			g.Assert(status().DownloadURL).Equal("")
+
+			run()
+
+			ready := status()
+			g.Assert(ready.Export.Status).Equal(models.ExportReady)
+			g.Assert(ready.Export.Size > 0).IsTrue()
+			g.Assert(ready.DownloadURL != "").IsTrue()
+
+			emailed := regexp.MustCompile(`\S+/api/v1/user/export/download\?token=\S+`).FindString(mails.String())
+			g.Assert(emailed != "").IsTrue()
+
+			code, files := download(emailed)
+			g.Assert(code).Equal(200)
+
+			for _, name := range []string{"profile.json", "posts.json", "comments.json", "likes.json", "followers.json", "following.json", "index.html"} {
+				g.Assert(files[name] != nil).IsTrue()
+			}
+
+			var profile models.User
+			g.Assert(json.Unmarshal(files["profile.json"], &profile) == nil).IsTrue()
+			g.Assert(profile.ID).Equal(userId)
+
+			var posts []models.Post
+			g.Assert(json.Unmarshal(files["posts.json"], &posts) == nil).IsTrue()
+			g.Assert(len(posts)).Equal(1)
+			g.Assert(posts[0].ID).Equal(post.ID)
+
+			var likes struct {
+				Posts []models.Post `json:"posts"`
+			}
+			g.Assert(json.Unmarshal(files["likes.json"], &likes) == nil).IsTrue()
+			g.Assert(len(likes.Posts)).Equal(1)
+			g.Assert(likes.Posts[0].ID).Equal(otherPost.ID)
+
+			var following []models.Author
+			g.Assert(json.Unmarshal(files["following.json"], &following) == nil).IsTrue()
+			g.Assert(following).Equal([]models.Author{{ID: otherUser.ID, UserName: other.UserName}})
+
+			// others' emails stay out of the archive
+			g.Assert(strings.Contains(string(files["following.json"]), other.Email)).IsFalse()
+			g.Assert(strings.Contains(string(files["index.html"]), "nice post")).IsTrue()
+		})
+
+		g.It("stops serving the archive once it expired", func() {
+			g.Assert(TSend(app, "POST", "/api/v1/user/export", token, nil).StatusCode).Equal(202)
+			run()
+
+			ready := status()
+			code, _ := download(ready.DownloadURL)
+			g.Assert(code).Equal(200)
+
+			id, err := primitive.ObjectIDFromHex(userId)
+			g.Assert(err == nil).IsTrue()
+			g.Assert(s.Exports.ExpireByUser(context.Background(), id, time.Now().Add(-time.Second)) == nil).IsTrue()
+
+			code, _ = download(ready.DownloadURL)
+			g.Assert(code).Equal(410)
+
+			// the next run removes the archive
+			run()
+
+			files, err := ioutil.ReadDir(exporter.Dir)
+			g.Assert(err == nil).IsTrue()
+			g.Assert(len(files)).Equal(0)
+
+			resp := TSend(app, "GET", "/api/v1/user/export", token, nil)
+			g.Assert(resp.StatusCode).Equal(404)
+		})
+
+		g.It("rejects forged links", func() {
+			code, _ := download("/api/v1/user/export/download?token=forged")
+			g.Assert(code).Equal(410)
+
+			// an access token isn't a download link
+			code, _ = download("/api/v1/user/export/download?token=" + token)
+			g.Assert(code).Equal(410)
+		})
+	})
+}
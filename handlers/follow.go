@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/**
+ * @Route /users/:id/follow
+ * @Mothod PUT
+ * @Protected ✔️
+ */
+func (u UserHandler) Follow(c *fiber.Ctx) {
+	userId, target, ok := u.followTarget(c)
+	if !ok {
+		return
+	}
+
+	targetId, _ := primitive.ObjectIDFromHex(target.ID)
+
+	following, err := u.Users.IsFollowing(c.Fasthttp, userId, targetId)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// following twice changes nothing
+	if following {
+		u.sendFollowState(c, fiber.StatusOK, "Following the user", true, false)
+		return
+	}
+
+	requested, err := u.Users.IsFollowRequested(c.Fasthttp, userId, targetId)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if requested {
+		u.sendFollowState(c, fiber.StatusAccepted, "Follow request pending", false, true)
+		return
+	}
+
+	blocked, err := isBlockedBetween(c.Fasthttp, u.Users, userId, targetId)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if blocked {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "You can't follow this user"})
+		return
+	}
+
+	if target.Private {
+		if err := u.Users.RequestFollow(c.Fasthttp, userId, targetId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+		u.sendFollowState(c, fiber.StatusAccepted, "Follow request sent", false, true)
+		return
+	}
+
+	if err := u.Users.Follow(c.Fasthttp, userId, targetId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+	u.sendFollowState(c, fiber.StatusOK, "Followed the user", true, false)
+}
+
+/**
+ * @Route /users/:id/follow
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (u UserHandler) Unfollow(c *fiber.Ctx) {
+	userId, target, ok := u.followTarget(c)
+	if !ok {
+		return
+	}
+
+	targetId, _ := primitive.ObjectIDFromHex(target.ID)
+
+	// both are no-ops when there is nothing to undo, a pending request is withdrawn too
+	if err := u.Users.Unfollow(c.Fasthttp, userId, targetId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := u.Users.RemoveFollowRequest(c.Fasthttp, userId, targetId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	u.sendFollowState(c, fiber.StatusOK, "UnFollowed the user", false, false)
+}
+
+// followTarget reads the current user and the user of the :id param,
+// it answers the request itself and returns false when the target can't be followed.
+func (u UserHandler) followTarget(c *fiber.Ctx) (primitive.ObjectID, *models.User, bool) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return userId, nil, false
+	}
+
+	targetId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return userId, nil, false
+	}
+
+	if targetId == userId {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "You can't follow yourself"})
+		return userId, nil, false
+	}
+
+	target, err := u.Users.FindByID(c.Fasthttp, targetId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "User not found"})
+		return userId, nil, false
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return userId, nil, false
+	}
+	return userId, target, true
+}
+
+func (u UserHandler) sendFollowState(c *fiber.Ctx, status int, message string, following, requested bool) {
+	if err := c.Status(status).JSON(fiber.Map{
+		"message":     message,
+		"isFollowing": following,
+		"isRequested": requested,
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /users/:id/followers?page=&limit=
+ * @Mothod GET
+ */
+func (u UserHandler) ListFollowers(c *fiber.Ctx) {
+	u.listNetwork(c, func(account models.User) []primitive.ObjectID { return account.Followers })
+}
+
+/**
+ * @Route /users/:id/following?page=&limit=
+ * @Mothod GET
+ */
+func (u UserHandler) ListFollowing(c *fiber.Ctx) {
+	u.listNetwork(c, func(account models.User) []primitive.ObjectID { return account.Following })
+}
+
+// listNetwork sends a page of the followers or followings of the user of the :id param, the latest first.
+func (u UserHandler) listNetwork(c *fiber.Ctx, network func(account models.User) []primitive.ObjectID) {
+	accountId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	account, err := u.Users.FindByID(c.Fasthttp, accountId)
+
+	if err == store.ErrNotFound || (err == nil && account.Suspended) {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "User not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	viewer := loggedInUser(c)
+
+	if !canView(viewer, *account) {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "This account is private"})
+		return
+	}
+
+	// the IDs are kept in the order they were added
+	ids := network(*account)
+	latest := make([]primitive.ObjectID, len(ids))
+	for i, id := range ids {
+		latest[len(ids)-1-i] = id
+	}
+
+	page := pageQuery(c)
+	start, end := pageBounds(len(latest), page)
+
+	users, err := u.Users.FindByIDs(c.Fasthttp, latest[start:end])
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	byID := map[string]models.User{}
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	// the viewer's followers are the users following them
+	followsViewer := map[primitive.ObjectID]bool{}
+	if viewer != nil {
+		for _, id := range viewer.Followers {
+			followsViewer[id] = true
+		}
+	}
+
+	summaries := []models.UserSummary{}
+	for _, id := range latest[start:end] {
+		user, ok := byID[id.Hex()]
+		if !ok || user.Suspended {
+			continue
+		}
+
+		summaries = append(summaries, models.UserSummary{
+			ID:          user.ID,
+			UserName:    user.UserName,
+			DisplayName: user.DisplayName,
+			Avatar:      user.Avatar,
+			Private:     user.Private,
+			FollowsYou:  followsViewer[id],
+		})
+	}
+
+	type Data struct {
+		Count int32                `json:"count"`
+		Users []models.UserSummary `json:"users"`
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(Data{Count: int32(len(latest)), Users: summaries}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+// loggedInUser returns the user of the routes where logging in is optional, nil for anonymous requests.
+func loggedInUser(c *fiber.Ctx) *models.User {
+	if user, ok := c.Locals("user").(models.User); ok {
+		return &user
+	}
+	return nil
+}
+
+// canView tells if the viewer, nil when logged out, can see the posts and network of the account,
+// private accounts only show them to themselves and their followers.
+func canView(viewer *models.User, account models.User) bool {
+	if !account.Private {
+		return true
+	}
+	if viewer == nil {
+		return false
+	}
+	if viewer.ID == account.ID {
+		return true
+	}
+
+	for _, id := range account.Followers {
+		if id.Hex() == viewer.ID {
+			return true
+		}
+	}
+	return false
+}
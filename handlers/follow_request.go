@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/**
+ * @Route /user/follow-requests
+ * @Mothod GET
+ * @Protected ✔️
+ */
+func (u UserHandler) ListFollowRequests(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	users, err := u.Users.FindByIDs(c.Fasthttp, user.FollowRequests)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	requests := []models.Author{}
+	for _, requester := range users {
+		requests = append(requests, models.Author{ID: requester.ID, UserName: requester.UserName})
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"requests": requests}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /user/follow-requests/:id/approve
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (u UserHandler) ApproveFollowRequest(c *fiber.Ctx) {
+	u.answerFollowRequest(c, true)
+}
+
+/**
+ * @Route /user/follow-requests/:id/reject
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (u UserHandler) RejectFollowRequest(c *fiber.Ctx) {
+	u.answerFollowRequest(c, false)
+}
+
+// answerFollowRequest approves or rejects the pending request of the user of the :id param.
+func (u UserHandler) answerFollowRequest(c *fiber.Ctx, approve bool) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	requesterId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	requested, err := u.Users.IsFollowRequested(c.Fasthttp, requesterId, userId)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if !requested {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Follow request not found"})
+		return
+	}
+
+	message := "Follow request rejected"
+	if approve {
+		err = u.approveFollowRequest(c.Fasthttp, requesterId, userId)
+		message = "Follow request approved"
+	} else {
+		err = u.Users.RemoveFollowRequest(c.Fasthttp, requesterId, userId)
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": message}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+// approveFollowRequest turns the pending request of requesterId into a follow of userId.
+func (u UserHandler) approveFollowRequest(ctx context.Context, requesterId, userId primitive.ObjectID) error {
+	if err := u.Users.RemoveFollowRequest(ctx, requesterId, userId); err != nil {
+		return err
+	}
+	return u.Users.Follow(ctx, requesterId, userId)
+}
@@ -0,0 +1,209 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestFollowRequests(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token, userId, otherToken, otherId string
+
+	setPrivate := func(private bool) {
+		resp := TSend(app, "PUT", "/api/v1/user", token, map[string]bool{"private": private})
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var user models.User
+		TDecode(resp, &user)
+		g.Assert(user.Private).Equal(private)
+	}
+
+	follow := func() (int, map[string]interface{}) {
+		resp := TSend(app, "POST", "/api/v1/user/"+userId, otherToken, nil)
+
+		var data map[string]interface{}
+		TDecode(resp, &data)
+		return resp.StatusCode, data
+	}
+
+	requests := func() []models.Author {
+		resp := TSend(app, "GET", "/api/v1/user/follow-requests", token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Requests []models.Author `json:"requests"`
+		}
+		TDecode(resp, &data)
+		return data.Requests
+	}
+
+	followers := func() int {
+		resp := TSend(app, "GET", "/api/v1/users/"+TSignupInputsVal.UserName, "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var profile models.Profile
+		TDecode(resp, &profile)
+		return profile.FollowersCount
+	}
+
+	g.Describe("Follow Requests Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, otherUser := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+			otherId = otherUser.ID
+
+			resp, otherLogin := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			otherToken = otherLogin.Data.Token
+
+			setPrivate(true)
+		})
+
+		g.It("follows once the request is approved", func() {
+			code, data := follow()
+			g.Assert(code).Equal(202)
+			g.Assert(data["isRequested"]).IsTrue()
+			g.Assert(data["isFollowing"]).IsFalse()
+			g.Assert(followers()).Equal(0)
+
+			pending := requests()
+			g.Assert(len(pending)).Equal(1)
+			g.Assert(pending[0].ID).Equal(otherId)
+			g.Assert(pending[0].UserName).Equal("other")
+
+			resp := TSend(app, "POST", "/api/v1/user/follow-requests/"+otherId+"/approve", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			g.Assert(followers()).Equal(1)
+			g.Assert(len(requests())).Equal(0)
+
+			// it's a plain follow from now on
+			code, data = follow()
+			g.Assert(code).Equal(200)
+			g.Assert(data["message"]).Equal("UnFollowed the user")
+		})
+
+		g.It("rejects or cancels the request", func() {
+			code, _ := follow()
+			g.Assert(code).Equal(202)
+
+			resp := TSend(app, "POST", "/api/v1/user/follow-requests/"+otherId+"/reject", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+			g.Assert(len(requests())).Equal(0)
+			g.Assert(followers()).Equal(0)
+
+			resp = TSend(app, "POST", "/api/v1/user/follow-requests/"+otherId+"/approve", token, nil)
+			g.Assert(resp.StatusCode).Equal(404)
+
+			// following again sends a new request, and again cancels it
+			code, _ = follow()
+			g.Assert(code).Equal(202)
+
+			code, data := follow()
+			g.Assert(code).Equal(200)
+			g.Assert(data["isRequested"]).IsFalse()
+			g.Assert(len(requests())).Equal(0)
+		})
+
+		g.It("approves the pending requests when the account goes public", func() {
+			code, _ := follow()
+			g.Assert(code).Equal(202)
+
+			setPrivate(false)
+			g.Assert(followers()).Equal(1)
+			g.Assert(len(requests())).Equal(0)
+		})
+
+		g.It("hides the posts from the non-followers", func() {
+			resp, _, _ := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			target := "/api/v1/post/timeline/user/" + userId
+
+			g.Assert(TSend(app, "GET", target, "", nil).StatusCode).Equal(403)
+			g.Assert(TSend(app, "GET", target, otherToken, nil).StatusCode).Equal(403)
+			g.Assert(TSend(app, "GET", target, token, nil).StatusCode).Equal(200)
+
+			// a bad token isn't taken for an anonymous request
+			g.Assert(TSend(app, "GET", target, "not-a-token", nil).StatusCode).Equal(401)
+
+			// nor are they in the latest posts of the system
+			resp = TSend(app, "GET", "/api/v1/post/timeline/home", "", nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var home struct {
+				Count int `json:"count"`
+			}
+			TDecode(resp, &home)
+			g.Assert(home.Count).Equal(0)
+
+			follow()
+			g.Assert(TSend(app, "POST", "/api/v1/user/follow-requests/"+otherId+"/approve", token, nil).StatusCode).Equal(200)
+
+			resp = TSend(app, "GET", target, otherToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var timeline struct {
+				Count int `json:"count"`
+			}
+			TDecode(resp, &timeline)
+			g.Assert(timeline.Count).Equal(1)
+		})
+
+		g.It("hides the posts from the home timeline of a follower to the others", func() {
+			resp, _, _ := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			follow()
+			g.Assert(TSend(app, "POST", "/api/v1/user/follow-requests/"+otherId+"/approve", token, nil).StatusCode).Equal(200)
+
+			resp, _, _ = TSignup(app, TSignInputs{Email: "third@gotter.local", UserName: "third", Password: "password"})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, third := TLogin(app, TLoginInputs{Email: "third@gotter.local", Password: "password"})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			home := func(token string) int {
+				resp := TSend(app, "GET", "/api/v1/post/timeline/home/"+otherId, token, nil)
+				g.Assert(resp.StatusCode).Equal(200)
+
+				var data struct {
+					Count int `json:"count"`
+				}
+				TDecode(resp, &data)
+				return data.Count
+			}
+
+			g.Assert(home(otherToken)).Equal(1)
+			g.Assert(home(token)).Equal(1)
+			g.Assert(home("")).Equal(0)
+			g.Assert(home(third.Data.Token)).Equal(0)
+		})
+	})
+}
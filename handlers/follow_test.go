@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFollowEndpoints(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token, userId string
+
+	type account struct{ id, token string }
+
+	signup := func(name string) account {
+		inputs := TSignInputs{Email: name + "@gotter.local", UserName: name, Password: "password"}
+		resp, _, user := TSignup(app, inputs)
+		g.Assert(resp.StatusCode).Equal(201)
+
+		resp, login := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+		g.Assert(resp.StatusCode).Equal(200)
+		return account{id: user.ID, token: login.Data.Token}
+	}
+
+	list := func(target, token string) (int32, []models.UserSummary) {
+		resp := TSend(app, "GET", target, token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Count int32                `json:"count"`
+			Users []models.UserSummary `json:"users"`
+		}
+		TDecode(resp, &data)
+		return data.Count, data.Users
+	}
+
+	g.Describe("Follow Endpoints Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.It("follows and unfollows idempotently", func() {
+			other := signup("other")
+			target := "/api/v1/users/" + other.id + "/follow"
+
+			for i := 0; i < 2; i++ {
+				g.Assert(TSend(app, "PUT", target, token, nil).StatusCode).Equal(200)
+			}
+
+			count, _ := list("/api/v1/users/"+other.id+"/followers", "")
+			g.Assert(count).Equal(int32(1))
+
+			for i := 0; i < 2; i++ {
+				g.Assert(TSend(app, "DELETE", target, token, nil).StatusCode).Equal(200)
+			}
+
+			count, _ = list("/api/v1/users/"+other.id+"/followers", "")
+			g.Assert(count).Equal(int32(0))
+
+			g.Assert(TSend(app, "PUT", "/api/v1/users/"+userId+"/follow", token, nil).StatusCode).Equal(400)
+			g.Assert(TSend(app, "PUT", "/api/v1/users/"+primitive.NewObjectID().Hex()+"/follow", token, nil).StatusCode).Equal(404)
+		})
+
+		g.It("sends a single follow request to private accounts", func() {
+			other := signup("other")
+			g.Assert(TSend(app, "PUT", "/api/v1/user", other.token, map[string]bool{"private": true}).StatusCode).Equal(200)
+
+			target := "/api/v1/users/" + other.id + "/follow"
+			g.Assert(TSend(app, "PUT", target, token, nil).StatusCode).Equal(202)
+			g.Assert(TSend(app, "PUT", target, token, nil).StatusCode).Equal(202)
+
+			resp := TSend(app, "GET", "/api/v1/user/follow-requests", other.token, nil)
+			var data struct {
+				Requests []models.Author `json:"requests"`
+			}
+			TDecode(resp, &data)
+			g.Assert(len(data.Requests)).Equal(1)
+
+			// the lists of private accounts are for their followers
+			g.Assert(TSend(app, "GET", "/api/v1/users/"+other.id+"/followers", token, nil).StatusCode).Equal(403)
+
+			// unfollowing withdraws the request
+			g.Assert(TSend(app, "DELETE", target, token, nil).StatusCode).Equal(200)
+
+			resp = TSend(app, "GET", "/api/v1/user/follow-requests", other.token, nil)
+			TDecode(resp, &data)
+			g.Assert(len(data.Requests)).Equal(0)
+		})
+
+		g.It("lists the network page by page with the follows you flag", func() {
+			var accounts []account
+			for i := 0; i < 5; i++ {
+				a := signup(fmt.Sprintf("follower%d", i))
+				g.Assert(TSend(app, "PUT", "/api/v1/users/"+userId+"/follow", a.token, nil).StatusCode).Equal(200)
+				accounts = append(accounts, a)
+			}
+
+			// the user follows back the first one only
+			g.Assert(TSend(app, "PUT", "/api/v1/users/"+accounts[0].id+"/follow", token, nil).StatusCode).Equal(200)
+
+			count, page := list("/api/v1/users/"+userId+"/followers?limit=2", "")
+			g.Assert(count).Equal(int32(5))
+			g.Assert(len(page)).Equal(2)
+			g.Assert(page[0].UserName).Equal("follower4")
+			g.Assert(page[1].UserName).Equal("follower3")
+
+			_, page = list("/api/v1/users/"+userId+"/followers?limit=2&page=3", "")
+			g.Assert(len(page)).Equal(1)
+			g.Assert(page[0].UserName).Equal("follower0")
+
+			// huge pages are empty, the limit is capped
+			_, page = list("/api/v1/users/"+userId+"/followers?page=2&limit=9223372036854775807", "")
+			g.Assert(len(page)).Equal(0)
+
+			_, page = list("/api/v1/users/"+userId+"/followers?page=922337203685477580&limit=20", "")
+			g.Assert(len(page)).Equal(0)
+
+			count, page = list("/api/v1/users/"+userId+"/followers?limit=9223372036854775807", "")
+			g.Assert(count).Equal(int32(5))
+			g.Assert(len(page)).Equal(5)
+
+			// relative to the caller
+			_, page = list("/api/v1/users/"+accounts[1].id+"/following", accounts[0].token)
+			g.Assert(len(page)).Equal(1)
+			g.Assert(page[0].ID).Equal(userId)
+			g.Assert(page[0].FollowsYou).IsTrue()
+
+			_, page = list("/api/v1/users/"+accounts[1].id+"/following", accounts[2].token)
+			g.Assert(page[0].FollowsYou).IsFalse()
+
+			_, page = list("/api/v1/users/"+accounts[1].id+"/following", "")
+			g.Assert(page[0].FollowsYou).IsFalse()
+		})
+	})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+type WellKnownHandler struct{}
+
+/**
+ * @Route /.well-known/jwks.json
+ * @Mothod GET
+ */
+func (w WellKnownHandler) JWKS(c *fiber.Ctx) {
+	keys, err := utils.JWTKeys()
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// other services cache it, so publish a new key (JWT_VERIFY_KEY_FILES) a few minutes before signing with it
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	if err := c.Status(fiber.StatusOK).JSON(keys.JWKS()); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
@@ -0,0 +1,204 @@
+package handlers_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+func TestJWTKeys(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+
+	type jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+
+	fetchJWKS := func() jwks {
+		resp := TSend(app, "GET", "/.well-known/jwks.json", "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var set jwks
+		TDecode(resp, &set)
+		return set
+	}
+
+	login := func() string {
+		resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+		g.Assert(resp.StatusCode).Equal(200)
+		return login.Data.Token
+	}
+
+	header := func(token string) map[string]interface{} {
+		parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+		g.Assert(err == nil).IsTrue()
+		return parsed.Header
+	}
+
+	mustKey := func(key interface{}) *utils.JWTKey {
+		k, err := utils.NewJWTKey(key, "")
+		g.Assert(err == nil).IsTrue()
+		return k
+	}
+
+	mustSet := func(signing *utils.JWTKey, previous ...*utils.JWTKey) *utils.JWTKeySet {
+		set, err := utils.NewJWTKeySet(signing, previous...)
+		g.Assert(err == nil).IsTrue()
+		return set
+	}
+
+	g.Describe("JWT Keys Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+		})
+
+		g.AfterEach(func() {
+			utils.SetJWTKeys(nil)
+		})
+
+		g.It("never publishes HMAC secrets", func() {
+			token := login()
+
+			g.Assert(header(token)["alg"]).Equal("HS256")
+			g.Assert(header(token)["kid"] != nil).IsTrue()
+			g.Assert(len(fetchJWKS().Keys)).Equal(0)
+		})
+
+		g.It("rotates from RS256 to EdDSA", func() {
+			rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			g.Assert(err == nil).IsTrue()
+
+			oldKey := mustKey(rsaKey)
+			utils.SetJWTKeys(mustSet(oldKey))
+
+			oldToken := login()
+			g.Assert(header(oldToken)["alg"]).Equal("RS256")
+			g.Assert(header(oldToken)["kid"]).Equal(oldKey.ID)
+
+			// the new key signs, the old one still verifies
+			publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+			g.Assert(err == nil).IsTrue()
+
+			newKey := mustKey(privateKey)
+			utils.SetJWTKeys(mustSet(newKey, mustKey(&rsaKey.PublicKey)))
+
+			resp := TSend(app, "GET", "/api/v1/user", oldToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			newToken := login()
+			g.Assert(header(newToken)["alg"]).Equal("EdDSA")
+			g.Assert(header(newToken)["kid"]).Equal(newKey.ID)
+
+			set := fetchJWKS()
+			g.Assert(len(set.Keys)).Equal(2)
+			g.Assert(set.Keys[0]["kid"]).Equal(newKey.ID)
+			g.Assert(set.Keys[0]["kty"]).Equal("OKP")
+			g.Assert(set.Keys[0]["x"]).Equal(base64.RawURLEncoding.EncodeToString(publicKey))
+			g.Assert(set.Keys[1]["kid"]).Equal(oldKey.ID)
+			g.Assert(set.Keys[1]["kty"]).Equal("RSA")
+
+			// a third party can verify the token with the published key
+			parts := strings.Split(newToken, ".")
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			g.Assert(err == nil).IsTrue()
+			g.Assert(ed25519.Verify(publicKey, []byte(parts[0]+"."+parts[1]), signature)).IsTrue()
+
+			// once the old key is dropped its tokens stop working
+			utils.SetJWTKeys(mustSet(newKey))
+
+			resp = TSend(app, "GET", "/api/v1/user", oldToken, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp = TSend(app, "GET", "/api/v1/user", newToken, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("loads an ES256 key from the env", func() {
+			ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			g.Assert(err == nil).IsTrue()
+
+			der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+			g.Assert(err == nil).IsTrue()
+
+			dir, err := ioutil.TempDir("", "gotter-keys")
+			g.Assert(err == nil).IsTrue()
+			defer os.RemoveAll(dir)
+
+			file := filepath.Join(dir, "jwt.pem")
+			err = ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+			g.Assert(err == nil).IsTrue()
+
+			os.Setenv("JWT_ALG", "ES256")
+			os.Setenv("JWT_PRIVATE_KEY_FILE", file)
+			os.Setenv("JWT_KID", "2020-07")
+			defer func() {
+				os.Unsetenv("JWT_ALG")
+				os.Unsetenv("JWT_PRIVATE_KEY_FILE")
+				os.Unsetenv("JWT_KID")
+			}()
+
+			set, err := utils.LoadJWTKeys()
+			g.Assert(err == nil).IsTrue()
+			utils.SetJWTKeys(set)
+
+			token := login()
+			g.Assert(header(token)["alg"]).Equal("ES256")
+			g.Assert(header(token)["kid"]).Equal("2020-07")
+
+			resp := TSend(app, "GET", "/api/v1/user", token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			keys := fetchJWKS().Keys
+			g.Assert(len(keys)).Equal(1)
+			g.Assert(keys[0]["crv"]).Equal("P-256")
+
+			// a wrong algorithm is refused
+			os.Setenv("JWT_ALG", "RS256")
+			_, err = utils.LoadJWTKeys()
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.It("refuses the tokens without a kid", func() {
+			secret := []byte("a-secret-for-the-tests")
+			utils.SetJWTKeys(mustSet(mustKey(secret)))
+
+			claims := jwt.MapClaims{}
+			_, _, err := new(jwt.Parser).ParseUnverified(login(), claims)
+			g.Assert(err == nil).IsTrue()
+
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+			g.Assert(err == nil).IsTrue()
+
+			resp := TSend(app, "GET", "/api/v1/user", token, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+	})
+}
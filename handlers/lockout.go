@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+// freeLoginFailures is how many failures are allowed before the backoff kicks in,
+// then every failure doubles the wait starting at one second.
+const freeLoginFailures = 3
+
+func accountAttemptsKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptsKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginBackoff is how long to wait after the given number of consecutive failures.
+func loginBackoff(failures int, max time.Duration) time.Duration {
+	if failures < freeLoginFailures {
+		return 0
+	}
+
+	delay := time.Duration(math.Pow(2, float64(failures-freeLoginFailures))) * time.Second
+	if delay > max || delay <= 0 {
+		return max
+	}
+	return delay
+}
+
+// loginWait tells how long the counter has to wait before the next attempt and if it's locked out.
+func loginWait(attempts *models.LoginAttempts, maxFailures int, now time.Time) (time.Duration, bool) {
+	lockout := utils.LoginLockout()
+
+	if attempts == nil || now.Sub(attempts.LastFailure) > lockout {
+		return 0, false
+	}
+
+	if attempts.Failures >= maxFailures {
+		return attempts.LastFailure.Add(lockout).Sub(now), true
+	}
+
+	return attempts.LastFailure.Add(loginBackoff(attempts.Failures, lockout)).Sub(now), false
+}
+
+// checkLoginAttempts answers 423 when the account is locked and 429 when the account
+// or the IP address has to slow down, it returns false when the login can't go on.
+func checkLoginAttempts(c *fiber.Ctx, attempts store.LoginAttemptStore, email string) bool {
+	account, err := getLoginAttempts(c.Fasthttp, attempts, accountAttemptsKey(email))
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return false
+	}
+
+	ip, err := getLoginAttempts(c.Fasthttp, attempts, ipAttemptsKey(c.IP()))
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return false
+	}
+
+	now := time.Now()
+	accountWait, locked := loginWait(account, utils.LoginMaxFailures(), now)
+	ipWait, _ := loginWait(ip, utils.LoginMaxIPFailures(), now)
+
+	if locked {
+		retryAfter(c, accountWait)
+		c.Status(fiber.StatusLocked).Send(fiber.Map{"message": "Account temporarily locked, too many failed logins"})
+		return false
+	}
+
+	wait := accountWait
+	if ipWait > wait {
+		wait = ipWait
+	}
+
+	if wait > 0 {
+		retryAfter(c, wait)
+		c.Status(fiber.StatusTooManyRequests).Send(fiber.Map{"message": "Too many failed logins, try again later"})
+		return false
+	}
+
+	return true
+}
+
+// failLogin counts a failed attempt against the account and the IP address.
+func failLogin(c *fiber.Ctx, attempts store.LoginAttemptStore, email string) error {
+	lockout := utils.LoginLockout()
+
+	if _, err := attempts.Fail(c.Fasthttp, accountAttemptsKey(email), lockout); err != nil {
+		return err
+	}
+
+	_, err := attempts.Fail(c.Fasthttp, ipAttemptsKey(c.IP()), lockout)
+	return err
+}
+
+// succeedLogin clears the account counter, the IP one keeps counting
+// so owning one account doesn't help guessing the others.
+func succeedLogin(c *fiber.Ctx, attempts store.LoginAttemptStore, email string) error {
+	return attempts.Reset(c.Fasthttp, accountAttemptsKey(email))
+}
+
+func getLoginAttempts(ctx context.Context, attempts store.LoginAttemptStore, key string) (*models.LoginAttempts, error) {
+	a, err := attempts.Get(ctx, key)
+
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	return a, err
+}
+
+func retryAfter(c *fiber.Ctx, wait time.Duration) {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestLoginLockout(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var mails *bytes.Buffer
+	var userId string
+
+	login := func(password string) (int, string) {
+		resp, _ := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: password})
+		return resp.StatusCode, resp.Header.Get("Retry-After")
+	}
+
+	g.Describe("Login Lockout Test", func() {
+		g.BeforeEach(func() {
+			mails = new(bytes.Buffer)
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(mails, "test@gotter.local"),
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+		})
+
+		g.AfterEach(func() {
+			os.Unsetenv("LOGIN_MAX_FAILURES")
+			os.Unsetenv("ADMIN_EMAILS")
+		})
+
+		g.It("slows down after a few failures", func() {
+			for i := 0; i < 3; i++ {
+				status, _ := login("wrong-password")
+				g.Assert(status).Equal(401)
+			}
+
+			// even the right password has to wait
+			status, wait := login(TSignupInputsVal.Password)
+			g.Assert(status).Equal(429)
+			g.Assert(wait).Equal("1")
+		})
+
+		g.It("locks the account until an admin unlocks it", func() {
+			os.Setenv("LOGIN_MAX_FAILURES", "2")
+			os.Setenv("ADMIN_EMAILS", "admin@gotter.local")
+
+			admin := TSignInputs{Email: "admin@gotter.local", UserName: "admin", Password: "password"}
+			resp, _, _ := TSignup(app, admin)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			// the admin role comes with the verified email
+			matches := regexp.MustCompile(`Verification token: (\S+)`).FindAllStringSubmatch(mails.String(), -1)
+			resp = TSend(app, "GET", "/api/v1/verify-email?token="+matches[len(matches)-1][1], "", nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp, adminLogin := TLogin(app, TLoginInputs{Email: admin.Email, Password: admin.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			for i := 0; i < 2; i++ {
+				status, _ := login("wrong-password")
+				g.Assert(status).Equal(401)
+			}
+
+			status, wait := login(TSignupInputsVal.Password)
+			g.Assert(status).Equal(423)
+
+			seconds, err := strconv.Atoi(wait)
+			g.Assert(err == nil).IsTrue()
+			g.Assert(seconds > 60).IsTrue()
+
+			resp = TSend(app, "POST", "/api/v1/admin/users/"+userId+"/unlock", adminLogin.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			status, _ = login(TSignupInputsVal.Password)
+			g.Assert(status).Equal(200)
+		})
+	})
+}
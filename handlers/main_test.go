@@ -0,0 +1,15 @@
+package handlers_test
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// the guard refuses to start without a secret, .env.test provides one when running through make
+	if os.Getenv("JWT_SECRET") == "" {
+		os.Setenv("JWT_SECRET", "test_secret")
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/media"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MediaHandlerInterface interface {
+	UploadAvatar(c *fiber.Ctx) interface{}
+	UploadBanner(c *fiber.Ctx) interface{}
+	UploadMedia(c *fiber.Ctx) interface{}
+	GetMedia(c *fiber.Ctx) interface{}
+}
+
+type MediaHandler struct {
+	Users store.UserStore
+	Media store.MediaStore
+	Blobs store.BlobStore
+}
+
+var (
+	avatarOptions = media.Options{MaxSide: 400, Square: true, Still: true, ThumbSide: 96}
+	bannerOptions = media.Options{MaxSide: 1500, Still: true, ThumbSide: 500}
+	mediaOptions  = media.Options{MaxSide: 2048, ThumbSide: 320}
+)
+
+// readUpload reads the image of the multipart "file" field and processes it,
+// it answers the request itself and returns nil when the upload is refused.
+func readUpload(c *fiber.Ctx, opts media.Options) *media.Image {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "The image goes in the file field of a multipart form"})
+		return nil
+	}
+
+	limit := utils.MediaMaxBytes()
+	if header.Size > int64(limit) {
+		c.Status(fiber.StatusRequestEntityTooLarge).Send(fiber.Map{"message": "The file is too large", "maxBytes": limit})
+		return nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return nil
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(file, int64(limit)+1))
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return nil
+	}
+
+	if len(data) > limit {
+		c.Status(fiber.StatusRequestEntityTooLarge).Send(fiber.Map{"message": "The file is too large", "maxBytes": limit})
+		return nil
+	}
+
+	img, err := media.Process(data, opts)
+
+	if err == media.ErrUnsupported {
+		c.Status(fiber.StatusUnsupportedMediaType).Send(fiber.Map{"message": "Only JPEG, PNG and GIF images can be uploaded"})
+		return nil
+	}
+
+	if err == media.ErrTooLarge {
+		c.Status(fiber.StatusRequestEntityTooLarge).Send(fiber.Map{"message": "The image dimensions are too large"})
+		return nil
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return nil
+	}
+	return img
+}
+
+// putImage stores the image and its thumbnail under a new random key in the folder and returns their keys.
+func putImage(ctx context.Context, blobs store.BlobStore, folder string, img *media.Image) (string, string, error) {
+	name, err := utils.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	key := folder + "/" + name + img.Ext()
+	thumbnailKey := folder + "/" + name + "_thumb" + img.ThumbnailExt()
+
+	if err := blobs.Put(ctx, key, img.ContentType, img.Data); err != nil {
+		return "", "", err
+	}
+
+	if err := blobs.Put(ctx, thumbnailKey, img.ThumbnailType, img.Thumbnail); err != nil {
+		blobs.Delete(ctx, key)
+		return "", "", err
+	}
+	return key, thumbnailKey, nil
+}
+
+/**
+ * @Route /user/avatar
+ * @Body multipart/form-data {file}
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (m MediaHandler) UploadAvatar(c *fiber.Ctx) {
+	m.uploadProfileImage(c, "avatars", avatarOptions)
+}
+
+/**
+ * @Route /user/banner
+ * @Body multipart/form-data {file}
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (m MediaHandler) UploadBanner(c *fiber.Ctx) {
+	m.uploadProfileImage(c, "banners", bannerOptions)
+}
+
+func (m MediaHandler) uploadProfileImage(c *fiber.Ctx, kind string, opts media.Options) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	img := readUpload(c, opts)
+	if img == nil {
+		return
+	}
+
+	folder := kind + "/" + user.ID
+	key, thumbnailKey, err := putImage(c.Fasthttp, m.Blobs, folder, img)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	url := utils.MediaURL(key)
+	previous := user.Avatar
+
+	update := store.UserUpdate{Avatar: &url}
+	if kind == "banners" {
+		update = store.UserUpdate{Banner: &url}
+		previous = user.Banner
+	}
+
+	updatedUser, err := m.Users.Update(c.Fasthttp, userId, update)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// the replaced image isn't referenced anymore
+	if err := store.DeleteProfileImage(c.Fasthttp, m.Blobs, previous, folder); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"user":         updatedUser,
+		"url":          url,
+		"thumbnailUrl": utils.MediaURL(thumbnailKey),
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+// deletePostMedia removes the media attached to the post with their files.
+func deletePostMedia(ctx context.Context, mediaStore store.MediaStore, blobs store.BlobStore, postId primitive.ObjectID) error {
+	deleted, err := mediaStore.DeleteByPost(ctx, postId)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range deleted {
+		if err := blobs.Delete(ctx, m.Key); err != nil {
+			return err
+		}
+		if err := blobs.Delete(ctx, m.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * @Route /media
+ * @Body multipart/form-data {file, alt?}
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (m MediaHandler) UploadMedia(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	alt := strings.TrimSpace(c.FormValue("alt"))
+	if !models.ValidAlt(alt) {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "The alt text is too long"})
+		return
+	}
+
+	img := readUpload(c, mediaOptions)
+	if img == nil {
+		return
+	}
+
+	key, thumbnailKey, err := putImage(c.Fasthttp, m.Blobs, "media/"+user.ID, img)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	upload := &models.Media{
+		Owner:        userId,
+		ContentType:  img.ContentType,
+		URL:          utils.MediaURL(key),
+		ThumbnailURL: utils.MediaURL(thumbnailKey),
+		Width:        img.Width,
+		Height:       img.Height,
+		Size:         len(img.Data),
+		Alt:          alt,
+		Blurhash:     img.Blurhash,
+		CreatedAt:    time.Now(),
+		Key:          key,
+		ThumbnailKey: thumbnailKey,
+	}
+
+	if err := m.Media.Create(c.Fasthttp, upload); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusCreated).JSON(upload); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /media/*
+ * @Mothod GET
+ */
+func (m MediaHandler) GetMedia(c *fiber.Ctx) {
+	body, contentType, err := m.Blobs.Get(c.Fasthttp, c.Params("*"))
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Media not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// keys are random and never reused, the content never changes
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Status(fiber.StatusOK).SendBytes(data)
+}
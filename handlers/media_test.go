@@ -0,0 +1,312 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/media"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/store/s3test"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+// exifSecret is in the EXIF metadata of the test photo and must not make it to the stored files
+const exifSecret = "GPS 45.7640N 4.8357E"
+
+// testPhoto returns a 40×20 JPEG, red on the left and blue on the right,
+// with EXIF metadata telling viewers to rotate it 90° clockwise.
+func testPhoto() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			if x < 20 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		panic(err)
+	}
+
+	// a big endian TIFF with a single IFD holding the Orientation (6)
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, 6, 0, 0, 0, 0, 0, 0}
+	segment := append(append([]byte("Exif\x00\x00"), tiff...), exifSecret...)
+
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(app1, segment...)...), data[2:]...)
+}
+
+// testAnimation returns a GIF of the given number of side×side frames.
+func testAnimation(frames, side int) []byte {
+	anim := &gif.GIF{}
+	palette := color.Palette{color.Black, color.White}
+
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, side, side), palette)
+		frame.SetColorIndex(0, 0, uint8(i%2))
+
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMediaUpload(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var s store.Store
+	var token string
+
+	s3 := s3test.NewServer()
+	defer s3.Close()
+
+	setup := func(blobs store.BlobStore) {
+		s = store.NewMemory()
+		app = SetupApp()
+		SetupRouter(app, Options{
+			Store:  s,
+			Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			Blobs:  blobs,
+		})
+
+		resp, _, _ := TSignup(app)
+		g.Assert(resp.StatusCode).Equal(201)
+
+		resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+		g.Assert(resp.StatusCode).Equal(200)
+		token = login.Data.Token
+	}
+
+	// fetch downloads an uploaded file from its URL
+	fetch := func(url string) (int, string, []byte) {
+		resp := TSend(app, "GET", strings.TrimPrefix(url, utils.AppURL()), "", nil)
+		body, err := ioutil.ReadAll(resp.Body)
+		g.Assert(err == nil).IsTrue()
+		return resp.StatusCode, resp.Header.Get("Content-Type"), body
+	}
+
+	uploadsAPhoto := func() {
+		resp := TUpload(app, "/api/v1/media", token, "holidays.jpg", testPhoto(), nil)
+		g.Assert(resp.StatusCode).Equal(201)
+
+		var m models.Media
+		TDecode(resp, &m)
+		g.Assert(m.ContentType).Equal("image/jpeg")
+
+		// turned upright
+		g.Assert(m.Width).Equal(20)
+		g.Assert(m.Height).Equal(40)
+
+		code, contentType, data := fetch(m.URL)
+		g.Assert(code).Equal(200)
+		g.Assert(contentType).Equal("image/jpeg")
+		g.Assert(bytes.Contains(data, []byte(exifSecret))).IsFalse()
+		g.Assert(bytes.Contains(data, []byte("Exif"))).IsFalse()
+
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		g.Assert(err == nil).IsTrue()
+
+		// the left of the photo is now on top
+		r, _, b, _ := img.At(10, 5).RGBA()
+		g.Assert(r > b).IsTrue()
+		r, _, b, _ = img.At(10, 35).RGBA()
+		g.Assert(b > r).IsTrue()
+
+		code, _, data = fetch(m.ThumbnailURL)
+		g.Assert(code).Equal(200)
+		g.Assert(bytes.Contains(data, []byte(exifSecret))).IsFalse()
+	}
+
+	g.Describe("Media Upload Test", func() {
+		g.Describe("with local blobs", func() {
+			var dir string
+
+			g.BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir("", "gotter-blobs")
+				g.Assert(err == nil).IsTrue()
+
+				setup(store.NewLocalBlobs(dir))
+			})
+
+			g.AfterEach(func() {
+				os.RemoveAll(dir)
+			})
+
+			g.It("strips the metadata and turns the photo upright", uploadsAPhoto)
+
+			g.It("replaces the avatar", func() {
+				resp := TUpload(app, "/api/v1/user/avatar", token, "me.jpg", testPhoto(), nil)
+				g.Assert(resp.StatusCode).Equal(200)
+
+				var first struct {
+					URL          string `json:"url"`
+					ThumbnailURL string `json:"thumbnailUrl"`
+				}
+				TDecode(resp, &first)
+
+				code, _, data := fetch(first.URL)
+				g.Assert(code).Equal(200)
+
+				// avatars are square
+				img, err := jpeg.Decode(bytes.NewReader(data))
+				g.Assert(err == nil).IsTrue()
+				g.Assert(img.Bounds().Dx()).Equal(img.Bounds().Dy())
+
+				resp = TSend(app, "GET", "/api/v1/users/"+TSignupInputsVal.UserName, "", nil)
+				var p models.Profile
+				TDecode(resp, &p)
+				g.Assert(p.Avatar).Equal(first.URL)
+
+				resp = TUpload(app, "/api/v1/user/avatar", token, "me.jpg", testPhoto(), nil)
+				g.Assert(resp.StatusCode).Equal(200)
+
+				// the previous one is gone with its thumbnail
+				code, _, _ = fetch(first.URL)
+				g.Assert(code).Equal(404)
+				code, _, _ = fetch(first.ThumbnailURL)
+				g.Assert(code).Equal(404)
+			})
+
+			g.It("refuses what isn't an image", func() {
+				resp := TUpload(app, "/api/v1/media", token, "photo.jpg", []byte("<html><script>alert(1)</script></html>"), nil)
+				g.Assert(resp.StatusCode).Equal(415)
+
+				// the name doesn't matter, the content does
+				resp = TUpload(app, "/api/v1/media", token, "notes.txt", testPhoto(), nil)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp = TSend(app, "POST", "/api/v1/media", token, map[string]string{"file": "nope"})
+				g.Assert(resp.StatusCode).Equal(400)
+			})
+
+			g.It("limits the size of the uploads", func() {
+				os.Setenv("MEDIA_MAX_BYTES", "100")
+				defer os.Unsetenv("MEDIA_MAX_BYTES")
+
+				resp := TUpload(app, "/api/v1/media", token, "big.jpg", testPhoto(), nil)
+				g.Assert(resp.StatusCode).Equal(413)
+			})
+
+			g.It("counts the frames of the animations before decoding them", func() {
+				resp := TUpload(app, "/api/v1/media", token, "loop.gif", testAnimation(3, 8), nil)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				// a few bytes per frame, decoded in full each
+				resp = TUpload(app, "/api/v1/media", token, "bomb.gif", testAnimation(media.MaxFrames+1, 1), nil)
+				g.Assert(resp.StatusCode).Equal(413)
+			})
+
+			g.It("doesn't serve files outside of the store", func() {
+				code, _, _ := fetch("/api/v1/media/../../etc/passwd")
+				g.Assert(code).Equal(404)
+
+				code, _, _ = fetch("/api/v1/media/%2e%2e/secret")
+				g.Assert(code).Equal(404)
+			})
+
+			g.It("needs a login to upload", func() {
+				resp := TUpload(app, "/api/v1/media", "", "photo.jpg", testPhoto(), nil)
+				g.Assert(resp.StatusCode).Equal(401)
+			})
+
+			g.It("purges the uploads never attached to a post", func() {
+				upload := func() models.Media {
+					resp := TUpload(app, "/api/v1/media", token, "photo.jpg", testPhoto(), nil)
+					g.Assert(resp.StatusCode).Equal(201)
+
+					var m models.Media
+					TDecode(resp, &m)
+					return m
+				}
+
+				attached, unattached := upload(), upload()
+
+				resp := TSend(app, "POST", "/api/v1/post", token, map[string]interface{}{
+					"title":       "Holidays",
+					"description": "with a photo",
+					"media":       []map[string]string{{"id": attached.ID}},
+				})
+				g.Assert(resp.StatusCode).Equal(201)
+
+				purge := func(before time.Time) int {
+					n, err := jobs.PurgeUnattachedMedia(context.Background(), s, store.NewLocalBlobs(dir), before)
+					g.Assert(err == nil).IsTrue()
+					return n
+				}
+
+				// the recent uploads may still be attached
+				g.Assert(purge(time.Now().Add(-utils.MediaUnattachedTTL()))).Equal(0)
+				g.Assert(purge(time.Now().Add(time.Minute))).Equal(1)
+
+				code, _, _ := fetch(unattached.URL)
+				g.Assert(code).Equal(404)
+				code, _, _ = fetch(unattached.ThumbnailURL)
+				g.Assert(code).Equal(404)
+
+				code, _, _ = fetch(attached.URL)
+				g.Assert(code).Equal(200)
+				code, _, _ = fetch(attached.ThumbnailURL)
+				g.Assert(code).Equal(200)
+			})
+		})
+
+		g.Describe("with S3 blobs", func() {
+			g.BeforeEach(func() {
+				setup(s3.Blobs())
+			})
+
+			g.It("strips the metadata and turns the photo upright", uploadsAPhoto)
+
+			g.It("keeps the image and its thumbnail in the bucket", func() {
+				before := len(s3.Keys())
+
+				resp := TUpload(app, "/api/v1/media", token, "holidays.jpg", testPhoto(), nil)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				keys := s3.Keys()
+				g.Assert(len(keys)).Equal(before + 2)
+			})
+
+			g.It("fails with the wrong credentials", func() {
+				blobs := s3.Blobs()
+				blobs.SecretKey = "wrong"
+				setup(blobs)
+
+				resp := TUpload(app, "/api/v1/media", token, "holidays.jpg", testPhoto(), nil)
+				g.Assert(resp.StatusCode).Equal(500)
+			})
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// postMentions parses the mentions of the title and the description of a post.
+func postMentions(title, description string) []models.Mention {
+	return append(models.ParseMentions("title", title), models.ParseMentions("description", description)...)
+}
+
+// resolveMentions links the parsed mentions to their users, the mentions of users that don't exist
+// or that blocked the author, or were blocked by them, stay plain text and are left out.
+func resolveMentions(ctx context.Context, users store.UserStore, author models.User, mentions []models.Mention) ([]models.Mention, error) {
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	usernames := []string{}
+	for _, mention := range mentions {
+		usernames = append(usernames, mention.UserName)
+	}
+
+	found, err := users.FindByUserNames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	authorId, err := primitive.ObjectIDFromHex(author.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := map[primitive.ObjectID]bool{}
+	for _, id := range author.Blocked {
+		blocked[id] = true
+	}
+
+	mentioned := map[string]primitive.ObjectID{}
+
+	for _, user := range found {
+		userId, err := primitive.ObjectIDFromHex(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range user.Blocked {
+			if id == authorId {
+				blocked[userId] = true
+			}
+		}
+
+		if !blocked[userId] {
+			mentioned[user.UserName] = userId
+		}
+	}
+
+	var resolved []models.Mention
+	for _, mention := range mentions {
+		if userId, ok := mentioned[mention.UserName]; ok {
+			mention.User = userId
+			resolved = append(resolved, mention)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestMentions(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token, otherId, otherToken string
+
+	create := func(title, description string) models.Post {
+		resp := TSend(app, "POST", "/api/v1/post", token, map[string]string{"title": title, "description": description})
+		g.Assert(resp.StatusCode).Equal(201)
+
+		var post models.Post
+		TDecode(resp, &post)
+		return post
+	}
+
+	g.Describe("Mentions Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, user := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+			otherId = user.ID
+
+			resp, login = TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			otherToken = login.Data.Token
+		})
+
+		g.It("parses the mentions with their offsets", func() {
+			mentions := models.ParseMentions("message", "hé @other, me@mail.com @@x @")
+			g.Assert(len(mentions)).Equal(1)
+			g.Assert(mentions[0].UserName).Equal("other")
+			g.Assert(mentions[0].Start).Equal(3)
+			g.Assert(mentions[0].End).Equal(9)
+		})
+
+		g.It("links the mentions of existing users in posts", func() {
+			post := create("hey @other", "@nobody ask @other")
+			g.Assert(len(post.Mentions)).Equal(2)
+
+			g.Assert(post.Mentions[0].User.Hex()).Equal(otherId)
+			g.Assert(post.Mentions[0].UserName).Equal("other")
+			g.Assert(post.Mentions[0].Field).Equal("title")
+			g.Assert(post.Mentions[0].Start).Equal(4)
+			g.Assert(post.Mentions[0].End).Equal(10)
+
+			g.Assert(post.Mentions[1].Field).Equal("description")
+			g.Assert(post.Mentions[1].Start).Equal(12)
+
+			// the timelines return them
+			resp := TSend(app, "GET", "/api/v1/post/timeline/home", "", nil)
+			var data struct {
+				Posts []models.PostWithComment `json:"posts"`
+			}
+			TDecode(resp, &data)
+			g.Assert(len(data.Posts[0].Mentions)).Equal(2)
+
+			// and they follow the updates
+			resp = TSend(app, "PUT", "/api/v1/post/"+post.ID, token, map[string]string{"title": "hey you"})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var updated models.Post
+			TDecode(resp, &updated)
+			g.Assert(len(updated.Mentions)).Equal(1)
+			g.Assert(updated.Mentions[0].Field).Equal("description")
+		})
+
+		g.It("links the mentions in comments", func() {
+			post := create("a post", "to comment on")
+
+			resp := TSend(app, "POST", "/api/v1/comment", token, map[string]string{"postId": post.ID, "message": "cc @other"})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			var comment models.Comment
+			TDecode(resp, &comment)
+			g.Assert(len(comment.Mentions)).Equal(1)
+			g.Assert(comment.Mentions[0].User.Hex()).Equal(otherId)
+			g.Assert(comment.Mentions[0].Start).Equal(3)
+
+			resp = TSend(app, "PUT", "/api/v1/comment/"+comment.ID, token, map[string]string{"comment": "never mind"})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var updated models.Comment
+			TDecode(resp, &updated)
+			g.Assert(updated.Message).Equal("never mind")
+			g.Assert(len(updated.Mentions)).Equal(0)
+		})
+
+		g.It("leaves the blocked users as plain text", func() {
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherId+"/block", token, nil).StatusCode).Equal(200)
+
+			post := create("hey @other", "are you there")
+			g.Assert(len(post.Mentions)).Equal(0)
+
+			g.Assert(TSend(app, "DELETE", "/api/v1/user/"+otherId+"/block", token, nil).StatusCode).Equal(200)
+
+			// whoever blocked the other
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+post.Author.ID+"/block", otherToken, nil).StatusCode).Equal(200)
+
+			post = create("hey @other", "are you there")
+			g.Assert(len(post.Mentions)).Equal(0)
+		})
+	})
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// totpIssuer is the account name authenticator apps display
+const totpIssuer = "gotter"
+
+// recoveryCodesCount is how many recovery codes are generated when 2FA is turned on
+const recoveryCodesCount = 10
+
+type MFAHandlerInterface interface {
+	EnrollTOTP(c *fiber.Ctx) interface{}
+	ConfirmTOTP(c *fiber.Ctx) interface{}
+	DisableTOTP(c *fiber.Ctx) interface{}
+}
+
+type MFAHandler struct {
+	Users store.UserStore
+}
+
+// issueMFAToken creates the short lived token returned by /login when the user still has to enter a TOTP code.
+func issueMFAToken(user *models.User) (fiber.Map, error) {
+	ttl := utils.MFATokenTTL()
+
+	token, err := utils.CreateAccessToken(map[string]interface{}{
+		"id":  user.ID,
+		"typ": models.MFAPendingToken,
+		"tv":  user.TokenVersion,
+	}, ttl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"mfaRequired": true,
+		"mfaToken":    token,
+		"expiresIn":   int64(ttl.Seconds()),
+	}, nil
+}
+
+// checkTOTP validates the code and uses up its time step, so it can't be replayed while it's still valid.
+func checkTOTP(ctx context.Context, users store.UserStore, userId primitive.ObjectID, secret, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	err := users.UseTOTPStep(ctx, userId, step)
+	if err == store.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkSecondFactor validates a TOTP code or consumes one of the user's recovery codes.
+func checkSecondFactor(ctx context.Context, users store.UserStore, user *models.User, code, recoveryCode string) (bool, error) {
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if code != "" {
+		return checkTOTP(ctx, users, userId, user.TOTPSecret, code)
+	}
+
+	if recoveryCode == "" {
+		return false, nil
+	}
+
+	for _, hash := range user.RecoveryCodes {
+		if !(utils.Password{Password: recoveryCode}).Compare(hash) {
+			continue
+		}
+
+		// the code might have been used by a concurrent request
+		err := users.RemoveRecoveryCode(ctx, userId, hash)
+		if err == store.ErrNotFound {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, nil
+}
+
+/**
+ * @Route /mfa/totp/enroll
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (m MFAHandler) EnrollTOTP(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	if user.MFAEnabled {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// the secret stays pending until the user confirms a code, enrolling again replaces it
+	if _, err := m.Users.Update(c.Fasthttp, userId, store.UserUpdate{TOTPSecret: &secret}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Scan the URI with your authenticator app and confirm a code",
+		"data": fiber.Map{
+			"secret": secret,
+			"uri":    utils.TOTPURI(totpIssuer, user.Email, secret),
+		},
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /mfa/totp/confirm
+ * @Body {code: string}
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (m MFAHandler) ConfirmTOTP(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+	inputs := new(models.MFACodeInputs)
+
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if user.MFAEnabled {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Two-factor authentication enrollment not started"})
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	ok, err := checkTOTP(c.Fasthttp, m.Users, userId, user.TOTPSecret, inputs.Code)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if !ok {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid code"})
+		return
+	}
+
+	codes, err := utils.RecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// recovery codes are as good as a password, only their hashes are stored
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.Password{Password: code}.Hash()
+	}
+
+	enabled := true
+	if _, err := m.Users.Update(c.Fasthttp, userId, store.UserUpdate{MFAEnabled: &enabled, RecoveryCodes: hashes}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Two-factor authentication enabled",
+		"data":    fiber.Map{"recoveryCodes": codes},
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /mfa/totp
+ * @Body {password: string}
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (m MFAHandler) DisableTOTP(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+	inputs := new(models.DisableMFAInputs)
+
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if !(utils.Password{Password: inputs.Password}).Compare(user.Password) {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid Credentials"})
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	enabled, secret := false, ""
+	if _, err := m.Users.Update(c.Fasthttp, userId, store.UserUpdate{
+		MFAEnabled:    &enabled,
+		TOTPSecret:    &secret,
+		RecoveryCodes: []string{},
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Two-factor authentication disabled"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
@@ -0,0 +1,189 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+func TestMFARoutes(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+
+	codeAt := func(secret string, t time.Time) string {
+		c, err := utils.TOTPCode(secret, t)
+		if err != nil {
+			panic(err)
+		}
+		return c
+	}
+
+	code := func(secret string) string {
+		return codeAt(secret, time.Now())
+	}
+
+	// enable signs up, logs in and turns 2FA on, it returns the TOTP secret and the recovery codes
+	enable := func() (secret string, recoveryCodes []string) {
+		resp, inputs, _ := TSignup(app)
+		g.Assert(resp.StatusCode).Equal(201)
+
+		resp, login := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+		g.Assert(resp.StatusCode).Equal(200)
+
+		resp = TSend(app, "POST", "/api/v1/mfa/totp/enroll", login.Data.Token, struct{}{})
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var enrollment struct {
+			Data struct {
+				Secret string `json:"secret"`
+				URI    string `json:"uri"`
+			} `json:"data"`
+		}
+		TDecode(resp, &enrollment)
+
+		uri, err := url.Parse(enrollment.Data.URI)
+		g.Assert(err == nil).IsTrue()
+		g.Assert(uri.Scheme).Equal("otpauth")
+		g.Assert(uri.Host).Equal("totp")
+		g.Assert(uri.Query().Get("secret")).Equal(enrollment.Data.Secret)
+
+		// a wrong code doesn't turn it on
+		resp = TSend(app, "POST", "/api/v1/mfa/totp/confirm", login.Data.Token, Map{"code": "000000"})
+		if code(enrollment.Data.Secret) != "000000" {
+			g.Assert(resp.StatusCode).Equal(400)
+		}
+
+		// the code of the previous period is still accepted, it leaves the current one for the login
+		resp = TSend(app, "POST", "/api/v1/mfa/totp/confirm", login.Data.Token, Map{"code": codeAt(enrollment.Data.Secret, time.Now().Add(-30*time.Second))})
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var confirmation struct {
+			Data struct {
+				RecoveryCodes []string `json:"recoveryCodes"`
+			} `json:"data"`
+		}
+		TDecode(resp, &confirmation)
+
+		return enrollment.Data.Secret, confirmation.Data.RecoveryCodes
+	}
+
+	login := func() TLoginOutput {
+		resp, data := TLogin(app, TLoginInputs{
+			Email:    TSignupInputsVal.Email,
+			Password: TSignupInputsVal.Password,
+		})
+		g.Assert(resp.StatusCode).Equal(200)
+
+		return data
+	}
+
+	g.Describe("MFA Routes Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+		})
+
+		g.It("returns an mfa token instead of an access token once enabled", func() {
+			secret, codes := enable()
+			g.Assert(len(codes)).Equal(10)
+
+			data := login()
+			g.Assert(data.Data.Token).Equal("")
+			g.Assert(data.Data.MFAToken != "").IsTrue()
+
+			// the pending token doesn't open protected routes
+			req := MakeRequest(Req{
+				Method:  "GET",
+				Target:  "/api/v1/user",
+				Options: Opt{Header: Map{"Authorization": "Bearer " + data.Data.MFAToken}},
+			})
+			resp, _ := app.Test(req, -1)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp = TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": data.Data.MFAToken, "code": code(secret)})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var tokens TLoginOutput
+			TDecode(resp, &tokens)
+			g.Assert(tokens.Data.Token != "").IsTrue()
+			g.Assert(tokens.Data.RefreshToken != "").IsTrue()
+		})
+
+		g.It("returns 401 on a wrong code or token", func() {
+			secret, _ := enable()
+			data := login()
+
+			wrong := "123456"
+			if code(secret) == wrong {
+				wrong = "654321"
+			}
+
+			resp := TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": data.Data.MFAToken, "code": wrong})
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp = TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": "not-a-token", "code": code(secret)})
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+
+		g.It("accepts each code once", func() {
+			secret, _ := enable()
+			current := code(secret)
+
+			resp := TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": login().Data.MFAToken, "code": current})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			// the code is still within its period but it was already used
+			resp = TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": login().Data.MFAToken, "code": current})
+			g.Assert(resp.StatusCode).Equal(401)
+
+			// and so are the older ones
+			previous := codeAt(secret, time.Now().Add(-30*time.Second))
+			resp = TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": login().Data.MFAToken, "code": previous})
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+
+		g.It("accepts each recovery code once", func() {
+			_, codes := enable()
+
+			resp := TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": login().Data.MFAToken, "recoveryCode": codes[0]})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": login().Data.MFAToken, "recoveryCode": codes[0]})
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+
+		g.It("disables 2FA with the password", func() {
+			secret, _ := enable()
+
+			resp := TSend(app, "POST", "/api/v1/login/mfa", "", Map{"mfaToken": login().Data.MFAToken, "code": code(secret)})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var tokens TLoginOutput
+			TDecode(resp, &tokens)
+
+			resp = TSend(app, "DELETE", "/api/v1/mfa/totp", tokens.Data.Token, Map{"password": "wrong-password"})
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp = TSend(app, "DELETE", "/api/v1/mfa/totp", tokens.Data.Token, Map{"password": TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			data := login()
+			g.Assert(data.Data.Token != "").IsTrue()
+			g.Assert(data.Data.MFAToken).Equal("")
+		})
+	})
+}
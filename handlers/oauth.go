@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/oidc"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// oauthStateCookie carries the signed state of a social login from /oauth/:provider/login to the callback
+const oauthStateCookie = "oauth_state"
+
+/**
+ * @Route /oauth/:provider/login
+ * @Mothod GET
+ */
+func (a AuthHandler) OAuthLogin(c *fiber.Ctx) {
+	provider, ok := a.Providers[c.Params("provider")]
+	if !ok {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Unknown provider"})
+		return
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		token, err := utils.RandomToken()
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+		secrets[i] = token
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := provider.AuthCodeURL(c.Fasthttp, state, nonce, verifier)
+	if err != nil {
+		c.Status(fiber.StatusBadGateway).Send(fiber.Map{"message": "Provider unavailable"})
+		return
+	}
+
+	// nothing is stored server side, the cookie binds the login to this browser
+	// and the signature keeps the verifier and nonce from being tampered with
+	ttl := utils.OAuthStateTTL()
+	cookie, err := utils.CreateAccessToken(map[string]interface{}{
+		"typ":      models.OAuthStateToken,
+		"provider": provider.Name,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+	}, ttl)
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	c.Cookie(oauthCookie(provider, cookie, time.Now().Add(ttl)))
+	c.Redirect(authURL, fiber.StatusFound)
+}
+
+/**
+ * @Route /oauth/:provider/callback?code=&state=
+ * @Mothod GET
+ */
+func (a AuthHandler) OAuthCallback(c *fiber.Ctx) {
+	provider, ok := a.Providers[c.Params("provider")]
+	if !ok {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Unknown provider"})
+		return
+	}
+
+	mapClaims, err := utils.ParseJWTToken(c.Cookies(oauthStateCookie))
+	if err != nil || mapClaims["typ"] != models.OAuthStateToken || mapClaims["provider"] != provider.Name {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid or expired login, try again"})
+		return
+	}
+
+	state, _ := mapClaims["state"].(string)
+	nonce, _ := mapClaims["nonce"].(string)
+	verifier, _ := mapClaims["verifier"].(string)
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid or expired login, try again"})
+		return
+	}
+
+	// the state is single use
+	c.Cookie(oauthCookie(provider, "", time.Unix(0, 0)))
+
+	if reason := c.Query("error"); reason != "" {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Login refused by the provider", "error": reason})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Fasthttp, c.Query("code"), verifier, nonce)
+	if err != nil {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Couldn't verify the login with the provider"})
+		return
+	}
+
+	user, err := a.oauthUser(c.Fasthttp, provider, claims)
+
+	if err == errEmailTaken {
+		c.Status(fiber.StatusConflict).Send(fiber.Map{"message": "An account already uses this email, log in with your password to link it"})
+		return
+	}
+
+	if err == errNoEmail {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "The provider didn't share an email address"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	a.completeLogin(c, user)
+}
+
+var (
+	errEmailTaken = errors.New("email already used by another account")
+	errNoEmail    = errors.New("no email address")
+)
+
+// oauthUser returns the user the social login is linked to, links it to the account with the same
+// verified email or creates a new account on the first login.
+func (a AuthHandler) oauthUser(ctx context.Context, provider *oidc.Provider, claims *oidc.Claims) (*models.User, error) {
+	identity := models.Identity{Provider: provider.Name, Subject: claims.Subject}
+
+	user, err := a.Users.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != store.ErrNotFound {
+		return user, err
+	}
+
+	if claims.Email == "" {
+		return nil, errNoEmail
+	}
+
+	user, err = a.Users.FindByEmail(ctx, claims.Email)
+
+	if err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+
+	if err == nil {
+		// an unverified email could be anybody's, linking it would hand the account over. The same goes
+		// for a local account that never verified its email, its password may belong to someone else.
+		if !claims.EmailVerified || !user.Verified {
+			return nil, errEmailTaken
+		}
+
+		userId, err := primitive.ObjectIDFromHex(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := a.Users.AddIdentity(ctx, userId, identity); err != nil {
+			return nil, err
+		}
+
+		// the provider vouches for the email
+		return markVerified(ctx, a.Users, userId)
+	}
+
+	username, err := provisionUserName(ctx, a.Users, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// ADMIN_EMAILS only applies to emails the provider verified
+	role := models.RoleUser
+	if claims.EmailVerified {
+		role = signupRole(claims.Email)
+	}
+
+	// social accounts have no password, Login can't match an empty hash
+	user = &models.User{
+		Email:      claims.Email,
+		Verified:   claims.EmailVerified,
+		UserName:   username,
+		Role:       role,
+		Posts:      []primitive.ObjectID{},
+		Following:  []primitive.ObjectID{},
+		Followers:  []primitive.ObjectID{},
+		Identities: []models.Identity{identity},
+	}
+
+	if err := a.Users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+var userNameInvalidChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// provisionUserName derives a free username from the provider's preferred username, name or email,
+// a random number is appended when it's taken.
+func provisionUserName(ctx context.Context, users store.UserStore, claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = claims.Name
+	}
+	if base == "" {
+		base = strings.Split(claims.Email, "@")[0]
+	}
+
+	base = strings.Trim(userNameInvalidChars.ReplaceAllString(strings.ToLower(base), "_"), "_")
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	if len(base) < 3 {
+		base = "user" + base
+	}
+
+	username := base
+	for i := 0; i < 10; i++ {
+		_, err := users.FindByUserName(ctx, username)
+
+		if err == store.ErrNotFound {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(100000))
+		if err != nil {
+			return "", err
+		}
+		username = fmt.Sprintf("%s%05d", base, n.Int64())
+	}
+	return "", fmt.Errorf("couldn't find a free username for %q", base)
+}
+
+func oauthCookie(provider *oidc.Provider, value string, expires time.Time) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     oauthStateCookie,
+		Value:    value,
+		Path:     "/api/v1/oauth/" + provider.Name,
+		Expires:  expires,
+		Secure:   strings.HasPrefix(utils.AppURL(), "https://"),
+		HTTPOnly: true,
+		// Lax still sends it on the top level redirect back from the provider
+		SameSite: "Lax",
+	}
+}
@@ -0,0 +1,192 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/oidc"
+	"github.com/kiranbhalerao123/gotter/oidc/oidctest"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestOAuthLogin(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	provider := oidctest.NewServer()
+	defer provider.Close()
+
+	const redirectURL = "http://localhost:3000/api/v1/oauth/test/callback"
+
+	// start goes to /oauth/test/login and through the provider, it returns the callback path and the state cookie
+	start := func() (string, *http.Cookie) {
+		resp := TSend(app, "GET", "/api/v1/oauth/test/login", "", nil)
+		g.Assert(resp.StatusCode).Equal(302)
+
+		var cookie *http.Cookie
+		for _, c := range resp.Cookies() {
+			if c.Name == "oauth_state" {
+				cookie = c
+			}
+		}
+		g.Assert(cookie != nil).IsTrue()
+		g.Assert(cookie.HttpOnly).IsTrue()
+
+		authURL := resp.Header.Get("Location")
+		g.Assert(strings.HasPrefix(authURL, provider.URL+"/authorize?")).IsTrue()
+		g.Assert(strings.Contains(authURL, "code_challenge_method=S256")).IsTrue()
+
+		back, err := provider.Authorize(authURL)
+		g.Assert(err == nil).IsTrue()
+		g.Assert(strings.HasPrefix(back.String(), redirectURL)).IsTrue()
+
+		return back.RequestURI(), cookie
+	}
+
+	callback := func(target string, cookie *http.Cookie) *http.Response {
+		req := MakeRequest(Req{Method: "GET", Target: target})
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+
+		resp, err := app.Test(req, -1)
+		g.Assert(err == nil).IsTrue()
+		return resp
+	}
+
+	login := func() *TLoginOutput {
+		resp := callback(start())
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var out TLoginOutput
+		TDecode(resp, &out)
+		g.Assert(out.Data.Token != "").IsTrue()
+		return &out
+	}
+
+	me := func(token string) models.User {
+		resp := TSend(app, "GET", "/api/v1/user", token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var user models.User
+		TDecode(resp, &user)
+		return user
+	}
+
+	var mails *bytes.Buffer
+
+	verify := func() {
+		matches := regexp.MustCompile(`Verification token: (\S+)`).FindAllStringSubmatch(mails.String(), -1)
+		resp := TSend(app, "GET", "/api/v1/verify-email?token="+matches[len(matches)-1][1], "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+	}
+
+	g.Describe("OAuth Login Test", func() {
+		g.BeforeEach(func() {
+			mails = &bytes.Buffer{}
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(mails, "test@gotter.local"),
+				Providers: map[string]*oidc.Provider{
+					"test": provider.Provider("test", redirectURL),
+				},
+			})
+		})
+
+		g.It("creates the account on the first login", func() {
+			provider.SetUser(oidc.Claims{Subject: "1001", Email: "jane@example.com", EmailVerified: true, PreferredUsername: "Jane.Doe"})
+
+			first := me(login().Data.Token)
+			g.Assert(first.UserName).Equal("jane_doe")
+			g.Assert(first.Email).Equal("jane@example.com")
+			g.Assert(first.Verified).IsTrue()
+
+			// the next login finds the linked identity
+			g.Assert(me(login().Data.Token).ID).Equal(first.ID)
+
+			// another user with the same preferred username gets a free one
+			provider.SetUser(oidc.Claims{Subject: "1002", Email: "other@example.com", EmailVerified: true, PreferredUsername: "jane.doe"})
+
+			second := me(login().Data.Token)
+			g.Assert(second.ID != first.ID).IsTrue()
+			g.Assert(strings.HasPrefix(second.UserName, "jane_doe")).IsTrue()
+			g.Assert(second.UserName != "jane_doe").IsTrue()
+		})
+
+		g.It("links a verified email to the existing account", func() {
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			verify()
+
+			provider.SetUser(oidc.Claims{Subject: "2001", Email: TSignupInputsVal.Email, EmailVerified: false})
+
+			resp = callback(start())
+			g.Assert(resp.StatusCode).Equal(409)
+
+			provider.SetUser(oidc.Claims{Subject: "2001", Email: TSignupInputsVal.Email, EmailVerified: true})
+
+			linked := me(login().Data.Token)
+			g.Assert(linked.ID).Equal(user.ID)
+			g.Assert(linked.Verified).IsTrue()
+			g.Assert(len(linked.Identities)).Equal(1)
+			g.Assert(linked.Identities[0].Provider).Equal("test")
+
+			// the password still works
+			resp, _ = TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("doesn't link an account that never verified its email", func() {
+			// anybody can sign up with someone else's email
+			resp, _, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			provider.SetUser(oidc.Claims{Subject: "2002", Email: TSignupInputsVal.Email, EmailVerified: true})
+
+			resp = callback(start())
+			g.Assert(resp.StatusCode).Equal(409)
+
+			// the identity wasn't linked, the next login is refused too
+			resp = callback(start())
+			g.Assert(resp.StatusCode).Equal(409)
+		})
+
+		g.It("rejects a forged or replayed state", func() {
+			provider.SetUser(oidc.Claims{Subject: "3001", Email: "sam@example.com", EmailVerified: true})
+
+			target, cookie := start()
+
+			// without the cookie of the browser that started the login
+			resp := callback(target, nil)
+			g.Assert(resp.StatusCode).Equal(400)
+
+			// with another state
+			_, otherCookie := start()
+			resp = callback(target, otherCookie)
+			g.Assert(resp.StatusCode).Equal(400)
+
+			resp = callback(target, cookie)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			// the provider refuses to exchange the same code twice
+			resp = callback(target, cookie)
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+
+		g.It("rejects unknown providers", func() {
+			resp := TSend(app, "GET", "/api/v1/oauth/nope/login", "", nil)
+			g.Assert(resp.StatusCode).Equal(404)
+		})
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+const (
+	// maxPageLimit is the largest page a listing returns
+	maxPageLimit = 100
+	// maxPage keeps the offset of the page far from overflowing
+	maxPage = 1 << 20
+)
+
+// pageQuery reads the ?page=&limit= query of a listing, page starts at 1 and limit defaults to 10.
+func pageQuery(c *fiber.Ctx) store.Page {
+	limit := int64(10)
+	page := int64(1)
+
+	if lim, err := strconv.Atoi(c.Query("limit")); err == nil && lim > 0 {
+		limit = int64(lim)
+	}
+
+	if pag, err := strconv.Atoi(c.Query("page")); err == nil && pag > 0 {
+		page = int64(pag)
+	}
+
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if page > maxPage {
+		page = maxPage
+	}
+
+	return store.Page{Skip: (page - 1) * limit, Limit: limit}
+}
+
+// pageBounds returns the page of a slice of the given length as [start, end) bounds.
+func pageBounds(length int, page store.Page) (int, int) {
+	start := length
+	if page.Skip < int64(length) {
+		start = int(page.Skip)
+	}
+
+	end := length
+	if page.Limit < int64(end-start) {
+		end = start + int(page.Limit)
+	}
+	return start, end
+}
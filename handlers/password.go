@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PasswordHandlerInterface interface {
+	ForgotPassword(c *fiber.Ctx) interface{}
+	ResetPassword(c *fiber.Ctx) interface{}
+}
+
+type PasswordHandler struct {
+	Users         store.UserStore
+	Tokens        store.OneTimeTokenStore
+	RefreshTokens store.RefreshTokenStore
+	Sessions      store.SessionStore
+	Mailer        mailer.Mailer
+}
+
+/**
+ * @Route /password/forgot
+ * @Body {email: string}
+ * @Mothod POST
+ */
+func (p PasswordHandler) ForgotPassword(c *fiber.Ctx) {
+	inputs := new(models.ForgotPasswordInputs)
+
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// the response is the same whether the account exists or not,
+	// so this route can't be used to find out who is registered
+	response := fiber.Map{"message": "If the account exists, a password reset link has been sent"}
+
+	user, err := p.Users.FindByEmail(c.Fasthttp, inputs.Email)
+
+	if err == store.ErrNotFound {
+		if err := c.Status(fiber.StatusOK).JSON(response); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+		}
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	ttl := utils.PasswordResetTTL()
+	token, err := issueOneTimeToken(c.Fasthttp, p.Tokens, models.PasswordResetToken, userId, ttl)
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	err = p.Mailer.Send(c.Fasthttp, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your gotter password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password, it expires in %s.\n\n%s/reset-password?token=%s\n\nReset token: %s\n\nIf you didn't ask for a password reset, you can ignore this email.\n",
+			user.UserName, ttl, utils.AppURL(), token, token,
+		),
+	})
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(response); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /password/reset
+ * @Body {token: string, password: string}
+ * @Mothod POST
+ */
+func (p PasswordHandler) ResetPassword(c *fiber.Ctx) {
+	inputs := new(models.ResetPasswordInputs)
+
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	token, err := p.Tokens.Consume(c.Fasthttp, models.PasswordResetToken, utils.HashToken(inputs.Token))
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid or expired token"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	hashPassword := utils.Password{Password: inputs.Password}.Hash()
+
+	if _, err := p.Users.Update(c.Fasthttp, token.User, store.UserUpdate{Password: &hashPassword}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// whoever knew the old password shouldn't stay logged in
+	if err := p.Users.IncrementTokenVersion(c.Fasthttp, token.User); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := p.RefreshTokens.RevokeUser(c.Fasthttp, token.User); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := p.Sessions.RevokeUser(c.Fasthttp, token.User); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Password has been reset"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
@@ -0,0 +1,146 @@
+package handlers_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestPasswordRoutes(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var mails *bytes.Buffer
+
+	forgot := func(email string) int {
+		req := MakeRequest(Req{
+			Method: "POST",
+			Target: "/api/v1/password/forgot",
+			Body:   JSONBody(map[string]string{"email": email}),
+			Options: Opt{
+				Header: Map{"Content-Type": "application/json"},
+			},
+		})
+
+		resp, _ := app.Test(req, -1)
+		return resp.StatusCode
+	}
+
+	reset := func(token, password string) int {
+		req := MakeRequest(Req{
+			Method: "POST",
+			Target: "/api/v1/password/reset",
+			Body:   JSONBody(map[string]string{"token": token, "password": password}),
+			Options: Opt{
+				Header: Map{"Content-Type": "application/json"},
+			},
+		})
+
+		resp, _ := app.Test(req, -1)
+		return resp.StatusCode
+	}
+
+	// resetToken returns the token of the last reset email
+	resetToken := func() string {
+		matches := regexp.MustCompile(`Reset token: (\S+)`).FindAllStringSubmatch(mails.String(), -1)
+
+		if len(matches) == 0 {
+			return ""
+		}
+		return matches[len(matches)-1][1]
+	}
+
+	g.Describe("Password Routes Test", func() {
+		g.BeforeEach(func() {
+			// every case runs against a fresh app with an empty in-memory store
+			mails = new(bytes.Buffer)
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(mails, "test@gotter.local"),
+			})
+		})
+
+		g.Describe("Forgot Password Route Suits", func() {
+			g.It("returns 400 on invalid email", func() {
+				g.Assert(forgot("not-an-email")).Equal(400)
+			})
+
+			g.It("returns 200 without sending anything for unknown email", func() {
+				g.Assert(forgot("unknown@user.com")).Equal(200)
+				g.Assert(mails.Len()).Equal(0)
+			})
+
+			g.It("emails a reset token to the user", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				g.Assert(forgot(inputs.Email)).Equal(200)
+				g.Assert(resetToken() != "").IsTrue()
+			})
+		})
+
+		g.Describe("Reset Password Route Suits", func() {
+			g.It("returns 400 on unknown token", func() {
+				g.Assert(reset("not-a-token", "new_password")).Equal(400)
+			})
+
+			g.It("resets the password once", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, login := TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				g.Assert(forgot(inputs.Email)).Equal(200)
+				token := resetToken()
+
+				g.Assert(reset(token, "new_password")).Equal(200)
+
+				// the token is single use
+				g.Assert(reset(token, "other_password")).Equal(400)
+
+				// the old password and sessions are gone
+				resp, _ = TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: inputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(401)
+
+				resp, _ = TRefresh(app, login.Data.RefreshToken)
+				g.Assert(resp.StatusCode).Equal(401)
+
+				resp, _ = TLogin(app, TLoginInputs{
+					Email:    inputs.Email,
+					Password: "new_password",
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+			})
+
+			g.It("only accepts the latest token", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				g.Assert(forgot(inputs.Email)).Equal(200)
+				first := resetToken()
+
+				g.Assert(forgot(inputs.Email)).Equal(200)
+				second := resetToken()
+
+				g.Assert(reset(first, "new_password")).Equal(400)
+				g.Assert(reset(second, "new_password")).Equal(200)
+			})
+		})
+	})
+}
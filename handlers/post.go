@@ -2,14 +2,13 @@ package handlers
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber"
 	"github.com/kiranbhalerao123/gotter/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/kiranbhalerao123/gotter/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type PostHandlerIntreface interface {
@@ -17,18 +16,72 @@ type PostHandlerIntreface interface {
 	UpdatePost(c *fiber.Ctx) interface{}
 	DeletePost(c *fiber.Ctx) interface{}
 	LikeDislikePost(c *fiber.Ctx) interface{}
+	Repost(c *fiber.Ctx) interface{}
+	Unrepost(c *fiber.Ctx) interface{}
 	HomeTimeline(c *fiber.Ctx) interface{}
 	UserTimeline(c *fiber.Ctx) interface{}
+	TagTimeline(c *fiber.Ctx) interface{}
 }
 
 type PostHandler struct {
-	PostColl    *mongo.Collection
-	UserColl    *mongo.Collection
-	CommentColl *mongo.Collection
+	Posts    store.PostStore
+	Users    store.UserStore
+	Comments store.CommentStore
+	Media    store.MediaStore
+	Blobs    store.BlobStore
+}
+
+// attachments checks the media picked for a new post are uploads of the user that aren't used yet,
+// it answers the request itself and returns false when they aren't.
+func (p PostHandler) attachments(c *fiber.Ctx, userId primitive.ObjectID, inputs []models.AttachmentInput) ([]models.Attachment, []primitive.ObjectID, bool) {
+	if len(inputs) > models.MaxAttachments {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "A post can't have more than " + strconv.Itoa(models.MaxAttachments) + " media"})
+		return nil, nil, false
+	}
+
+	attachments := []models.Attachment{}
+	ids := []primitive.ObjectID{}
+
+	for _, input := range inputs {
+		alt := strings.TrimSpace(input.Alt)
+		if !models.ValidAlt(alt) {
+			c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "The alt text is too long"})
+			return nil, nil, false
+		}
+
+		id, err := primitive.ObjectIDFromHex(input.ID)
+		if err != nil {
+			c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid media " + input.ID})
+			return nil, nil, false
+		}
+
+		for _, other := range ids {
+			if other == id {
+				c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "The media " + input.ID + " is attached twice"})
+				return nil, nil, false
+			}
+		}
+
+		m, err := p.Media.FindByID(c.Fasthttp, id)
+
+		if err == store.ErrNotFound || (err == nil && (m.Owner != userId || m.Post != nil)) {
+			c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid media " + input.ID})
+			return nil, nil, false
+		}
+
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return nil, nil, false
+		}
+
+		attachments = append(attachments, models.NewAttachment(*m, alt))
+		ids = append(ids, id)
+	}
+	return attachments, ids, true
 }
 
 /**
- * @Body {title: string, description: string}
+ * @Body {title: string, description: string, media?: [{id: string, alt?: string}], quote?: string}
  * @Mothod POST
  * @Protected ✔️
  */
@@ -57,6 +110,37 @@ func (p PostHandler) CreatePost(c *fiber.Ctx) {
 		return
 	}
 
+	attachments, mediaIds, ok := p.attachments(c, userId, inputs.Media)
+	if !ok {
+		return
+	}
+
+	// a quote embeds the post quoted
+	var quoteOf *primitive.ObjectID
+
+	if inputs.Quote != "" {
+		quoteId, err := primitive.ObjectIDFromHex(inputs.Quote)
+		if err != nil {
+			c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid quote " + inputs.Quote})
+			return
+		}
+
+		quoted, ok := p.findShared(c, quoteId)
+		if !ok || !p.canShare(c, userId, quoted) {
+			return
+		}
+
+		quoteId, _ = primitive.ObjectIDFromHex(quoted.ID)
+		quoteOf = &quoteId
+	}
+
+	// the @usernames link to the users
+	mentions, err := resolveMentions(c.Fasthttp, p.Users, user, postMentions(inputs.Title, inputs.Description))
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
 	post := models.Post{
 		Title:       inputs.Title,
 		Description: inputs.Description,
@@ -68,30 +152,37 @@ func (p PostHandler) CreatePost(c *fiber.Ctx) {
 			ID:       user.ID,
 			UserName: user.UserName,
 		},
+		QuoteOf:  quoteOf,
+		Tags:     models.ParseTags(inputs.Title, inputs.Description),
+		Mentions: mentions,
+	}
+
+	if len(attachments) > 0 {
+		post.Media = attachments
 	}
 
-	insertionResult, err := p.PostColl.InsertOne(c.Fasthttp, post)
+	err = p.Posts.Create(c.Fasthttp, &post)
 
 	if err != nil {
 		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
 
-	// update the users collection, put post id inside posts[]
-	filter := bson.M{"_id": userId}
-	update := bson.M{"$push": bson.M{"posts": insertionResult.InsertedID}}
-
-	_, err = p.UserColl.UpdateOne(c.Fasthttp, filter, update)
+	postId, _ := primitive.ObjectIDFromHex(post.ID)
 
-	if err != nil {
-		// rollback the post insertion
-		c.Status(fiber.StatusInternalServerError).Send(err)
-		return
+	// the media can't be attached to another post anymore
+	if len(mediaIds) > 0 {
+		if err := p.Media.Attach(c.Fasthttp, mediaIds, postId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
 	}
 
-	err = p.PostColl.FindOne(c.Fasthttp, bson.M{"_id": insertionResult.InsertedID}).Decode(&post)
+	// update the users collection, put post id inside posts[]
+	err = p.Users.AddPost(c.Fasthttp, userId, postId)
 
 	if err != nil {
+		// rollback the post insertion
 		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
@@ -110,6 +201,12 @@ func (p PostHandler) CreatePost(c *fiber.Ctx) {
 func (p PostHandler) UpdatePost(c *fiber.Ctx) {
 	user := c.Locals("user").(models.User)
 
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
 	postId, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
@@ -123,29 +220,55 @@ func (p PostHandler) UpdatePost(c *fiber.Ctx) {
 		return
 	}
 
-	filter := bson.M{"_id": postId, "author._id": user.ID}
-	update := bson.M{"$set": bson.M{"title": inputs.Title, "description": inputs.Description}}
+	var update store.PostUpdate
 
 	// update check for empty title
-	if inputs.Title == "" {
-		update = bson.M{"$set": bson.M{"description": inputs.Description}}
+	if inputs.Title != "" {
+		update.Title = &inputs.Title
 	}
 
 	// update check for empty description
-	if inputs.Description == "" {
-		update = bson.M{"$set": bson.M{"title": inputs.Title}}
+	if inputs.Description != "" {
+		update.Description = &inputs.Description
 	}
 
-	upsert := true
-	after := options.After
-	opt := options.FindOneAndUpdateOptions{
-		ReturnDocument: &after,
-		Upsert:         &upsert,
+	// the tags and the mentions follow the new text
+	if update.Title != nil || update.Description != nil {
+		post, err := p.Posts.FindByID(c.Fasthttp, postId)
+
+		if err != nil && err != store.ErrNotFound {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err == nil {
+			title, description := post.Title, post.Description
+			if update.Title != nil {
+				title = *update.Title
+			}
+			if update.Description != nil {
+				description = *update.Description
+			}
+
+			tags := models.ParseTags(title, description)
+			update.Tags = &tags
+
+			mentions, err := resolveMentions(c.Fasthttp, p.Users, user, postMentions(title, description))
+			if err != nil {
+				c.Status(fiber.StatusInternalServerError).Send(err)
+				return
+			}
+			update.Mentions = &mentions
+		}
 	}
 
-	var post models.Post
+	post, err := p.Posts.Update(c.Fasthttp, postId, userId, update)
 
-	err = p.PostColl.FindOneAndUpdate(c.Fasthttp, filter, update, &opt).Decode(&post)
+	// the post is missing or someone else's
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Post not found"})
+		return
+	}
 
 	if err != nil {
 		c.Status(fiber.StatusInternalServerError).Send(err)
@@ -166,6 +289,12 @@ func (p PostHandler) UpdatePost(c *fiber.Ctx) {
 func (p PostHandler) DeletePost(c *fiber.Ctx) {
 	user := c.Locals("user").(models.User)
 
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
 	postId, err := primitive.ObjectIDFromHex(c.Params("id"))
 
 	if err != nil {
@@ -173,31 +302,37 @@ func (p PostHandler) DeletePost(c *fiber.Ctx) {
 		return
 	}
 
-	filter := bson.M{"_id": postId, "author._id": user.ID}
-	deleteResult, e := p.PostColl.DeleteOne(c.Fasthttp, filter)
-
-	if e != nil || deleteResult.DeletedCount < 1 {
+	if e := p.Posts.Delete(c.Fasthttp, postId, userId); e != nil {
 		c.Status(fiber.StatusInternalServerError).Send("Unable to delete post")
 		return
 	}
 
 	// pull out postId from users collection
-	filter = bson.M{"email": user.Email}
-	update := bson.M{"$pull": bson.M{"posts": postId}}
-
-	_, err = p.UserColl.UpdateOne(c.Fasthttp, filter, update)
+	err = p.Users.RemovePost(c.Fasthttp, userId, postId)
 	if err != nil {
 		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
 
 	// delete all comments associated with this post
-	_, err = p.CommentColl.DeleteMany(c.Fasthttp, bson.M{"post": postId})
+	err = p.Comments.DeleteByPost(c.Fasthttp, postId)
 	if err != nil {
 		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
 
+	// the reposts of it go too, the quotes keep their own content
+	if err := deleteReposts(c.Fasthttp, p.Posts, p.Users, postId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// and its media with their files
+	if err := deletePostMedia(c.Fasthttp, p.Media, p.Blobs, postId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
 	c.Status(fiber.StatusOK).Send("Post deleted successfully")
 }
 
@@ -221,41 +356,48 @@ func (P PostHandler) LikeDislikePost(c *fiber.Ctx) {
 		return
 	}
 
-	var post models.Post
 	// check whether the post exist or not
-	err = P.PostColl.FindOne(c.Fasthttp, bson.M{"_id": postId}).Decode(&post)
+	post, err := P.Posts.FindByID(c.Fasthttp, postId)
 
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	err = P.PostColl.FindOne(c.Fasthttp, bson.M{"_id": postId, "likes": bson.M{"$in": bson.A{userId}}}).Decode(&models.User{})
+	liked, err := P.Posts.IsLiked(c.Fasthttp, postId, userId)
 
-	if err != nil && err.Error() != "mongo: no documents in result" {
+	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	// inline
-	// notLikedYet := err != nil && err.Error() == "mongo: no documents in result"
-	// but i prefer more explicit way
+	notLikedYet := !liked
 
-	var notLikedYet bool
-	if err != nil && err.Error() == "mongo: no documents in result" {
-		notLikedYet = true
-	} else {
-		notLikedYet = false
-	}
+	// a like can always be taken back, a new one needs neither user to have blocked the other
+	if notLikedYet {
+		authorId, err := primitive.ObjectIDFromHex(post.Author.ID)
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
 
-	filter := bson.M{"_id": postId}
-	update := bson.M{"$pull": bson.M{"likes": userId}}
+		blocked, err := isBlockedBetween(c.Fasthttp, P.Users, userId, authorId)
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
 
-	if notLikedYet {
-		update = bson.M{"$push": bson.M{"likes": userId}}
+		if blocked {
+			c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "You can't like this post"})
+			return
+		}
 	}
 
-	_, err = P.PostColl.UpdateOne(c.Fasthttp, filter, update)
+	if notLikedYet {
+		err = P.Posts.Like(c.Fasthttp, postId, userId)
+	} else {
+		err = P.Posts.Unlike(c.Fasthttp, postId, userId)
+	}
 
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
@@ -277,66 +419,43 @@ func (P PostHandler) LikeDislikePost(c *fiber.Ctx) {
 }
 
 func (p PostHandler) UserTimeline(c *fiber.Ctx) {
-	userId := c.Params("userId")
 	limit := int64(10)
 	page := int64(1)
 
 	skip := (page - 1) * limit
 
-	// get posts of the userId
-	cur, err := p.PostColl.Aggregate(c.Fasthttp, []bson.M{
-		{"$match": bson.M{"author._id": userId}},
-		{
-			"$lookup": bson.M{
-				"from": "comments",
-				"let":  bson.M{"comments": "$comments"},
-				"pipeline": bson.A{
-					bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$_id", "$$comments"}}}},
-					bson.M{"$project": bson.M{
-						"_id":       1,
-						"message":   1,
-						"post":      1,
-						"user":      1,
-						"createdAt": 1,
-						"likes":     1,
-						"count":     bson.M{"$size": "$likes"},
-					}},
-					bson.M{"$sort": bson.M{"count": -1}},
-					bson.M{"$limit": limit},
-					bson.M{"$project": bson.M{"count": 0}},
-				},
-				"as": "comments",
-			},
-		},
-		{"$sort": bson.M{"createdAt": -1}},
-		{"$skip": skip},
-		{"$limit": limit},
-	})
-
+	userId, err := primitive.ObjectIDFromHex(c.Params("userId"))
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	var posts []models.PostWithComment
+	// the posts of private accounts are only shown to themselves and their followers
+	author, err := p.Users.FindByID(c.Fasthttp, userId)
+
+	if err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	viewer := loggedInUser(c)
 
-	for cur.Next(c.Fasthttp) {
-		// raw, err := cur.Current.Elements()
-		var post models.PostWithComment
-		err = cur.Decode(&post)
+	if err == nil && !canView(viewer, *author) {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "This account is private"})
+		return
+	}
 
-		if err != nil {
-			c.Status(fiber.StatusBadRequest).Send(err)
-			return
-		}
-		posts = append(posts, post)
+	viewerId := primitive.NilObjectID
+	if viewer != nil {
+		viewerId, _ = primitive.ObjectIDFromHex(viewer.ID)
 	}
 
-	if err := cur.Err(); err != nil {
-		if err != nil {
-			c.Status(fiber.StatusBadRequest).Send(err)
-			return
-		}
+	// get posts of the userId
+	posts, err := p.Posts.UserTimeline(c.Fasthttp, userId, viewerId, store.Page{Skip: skip, Limit: limit})
+
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
 	}
 
 	type Data struct {
@@ -344,8 +463,6 @@ func (p PostHandler) UserTimeline(c *fiber.Ctx) {
 		Posts []models.PostWithComment `json:"posts"`
 	}
 
-	// Close the cursor once finished
-	cur.Close(c.Fasthttp)
 	err = c.Status(fiber.StatusOK).JSON(Data{
 		Posts: posts,
 		Count: int32(len(posts)),
@@ -385,128 +502,17 @@ func (p PostHandler) HomeTimeline(c *fiber.Ctx) {
 	}
 
 	skip := (page - 1) * limit
-	var cur *mongo.Cursor
-
-	if userId != primitive.NilObjectID {
-		// if userId is provided then get this user's followings
-		// and with that followings get their posts
-
-		query := []bson.M{
-			{"$match": bson.M{"_id": userId}},
-			{"$lookup": bson.M{
-				"from": "users",
-				"let":  bson.M{"following": "$following"},
-				"pipeline": bson.A{
-					bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$_id", "$$following"}}}},
-					bson.M{"$lookup": bson.M{
-						"from": "posts",
-						"let":  bson.M{"posts": "$posts"},
-						"pipeline": bson.A{
-							bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$_id", "$$posts"}}}},
-							bson.M{"$lookup": bson.M{
-								"from": "comments",
-								"let":  bson.M{"comments": "$comments"},
-								"pipeline": bson.A{
-									bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$_id", "$$comments"}}}},
-									bson.M{"$project": bson.M{
-										"_id":       1,
-										"message":   1,
-										"post":      1,
-										"user":      1,
-										"createdAt": 1,
-										"likes":     1,
-										"count":     bson.M{"$size": "$likes"},
-									}},
-									bson.M{"$sort": bson.M{"count": -1}},
-									bson.M{"$limit": limit},
-									bson.M{"$project": bson.M{"count": 0}},
-								},
-								"as": "comments",
-							}},
-						},
-						"as": "posts",
-					}},
-					bson.M{"$unwind": bson.M{
-						"path":                       "$posts",
-						"preserveNullAndEmptyArrays": true,
-					}},
-				},
-				"as": "following",
-			}},
-			{"$unwind": bson.M{
-				"path":                       "$following",
-				"preserveNullAndEmptyArrays": true,
-			}},
-			{"$project": bson.M{"posts": "$following.posts"}},
-			{"$replaceRoot": bson.M{
-				"newRoot": bson.M{
-					"$mergeObjects": bson.A{"$posts", "$$ROOT"},
-				},
-			}},
-			{"$project": bson.M{"posts": 0}},
-			{"$sort": bson.M{"createdAt": -1}},
-			{"$skip": skip},
-			{"$facet": bson.M{
-				"count": bson.A{bson.M{"$count": "count"}},
-				"posts": bson.A{bson.M{"$limit": limit}},
-			}},
-			{"$project": bson.M{
-				"count": bson.M{"$arrayElemAt": bson.A{"$count", 0}},
-				"posts": 1,
-			}},
-			{"$project": bson.M{
-				"count": "$count.count",
-				"posts": 1,
-			}},
-		}
 
-		cur, err = p.UserColl.Aggregate(c.Fasthttp, query)
-	} else {
-		// userId is not provided
-		// get the latest posts from system
-
-		query := []bson.M{
-			{
-				"$lookup": bson.M{
-					"from": "comments",
-					"let":  bson.M{"comments": "$comments"},
-					"pipeline": bson.A{
-						bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$_id", "$$comments"}}}},
-						bson.M{"$project": bson.M{
-							"_id":       1,
-							"message":   1,
-							"post":      1,
-							"user":      1,
-							"createdAt": 1,
-							"likes":     1,
-							"count":     bson.M{"$size": "$likes"},
-						}},
-						bson.M{"$sort": bson.M{"count": -1}},
-						bson.M{"$limit": limit},
-						bson.M{"$project": bson.M{"count": 0}},
-					},
-					"as": "comments",
-				},
-			},
-			{"$sort": bson.M{"createdAt": -1}},
-			{"$skip": skip},
-			{"$facet": bson.M{
-				"count": bson.A{bson.M{"$count": "count"}},
-				"posts": bson.A{bson.M{"$limit": limit}},
-			}},
-			{"$project": bson.M{
-				"count": bson.M{"$arrayElemAt": bson.A{"$count", 0}},
-				"posts": 1,
-			}},
-			{"$project": bson.M{
-				"count": "$count.count",
-				"posts": 1,
-			}},
-		}
-
-		cur, err = p.PostColl.Aggregate(c.Fasthttp, query)
+	// if userId is provided then get this user's followings posts,
+	// otherwise get the latest posts from system
+	// the private accounts followed by userId stay hidden from the readers who don't follow them
+	viewerId := primitive.NilObjectID
+	if viewer := loggedInUser(c); viewer != nil {
+		viewerId, _ = primitive.ObjectIDFromHex(viewer.ID)
 	}
 
+	posts, count, err := p.Posts.HomeTimeline(c.Fasthttp, userId, viewerId, store.Page{Skip: skip, Limit: limit})
+
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
@@ -517,31 +523,9 @@ func (p PostHandler) HomeTimeline(c *fiber.Ctx) {
 		Posts []models.PostWithComment `json:"posts"`
 	}
 
-	var data []Data
-
-	for cur.Next(c.Fasthttp) {
-		var d Data
-		err = cur.Decode(&d)
-
-		if err != nil {
-			c.Status(fiber.StatusBadRequest).Send(err)
-			return
-		}
-		data = append(data, d)
-	}
-
-	if err := cur.Err(); err != nil {
-		if err != nil {
-			c.Status(fiber.StatusBadRequest).Send(err)
-			return
-		}
-	}
-
-	// Close the cursor once finished
-	cur.Close(c.Fasthttp)
 	err = c.Status(fiber.StatusOK).JSON(Data{
-		Count: data[0].Count + int32(skip),
-		Posts: data[0].Posts,
+		Count: count,
+		Posts: posts,
 	})
 
 	if err != nil {
@@ -2,32 +2,34 @@ package handlers_test
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
+	"io/ioutil"
 	"testing"
 
 	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
 	. "github.com/kiranbhalerao123/gotter/app"
-	. "github.com/kiranbhalerao123/gotter/config"
 	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
 	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestPostsRoute(t *testing.T) {
 	g := Goblin(t)
 
-	app := SetupApp()
-	SetupDB()
-	SetupRouter(app)
+	var app *fiber.App
 
 	g.Describe("Post Routes Test", func() {
 		g.BeforeEach(func() {
-			err := Mongo.DB.Drop(context.Background())
-
-			if err != nil {
-				panic(err)
-			}
+			// every case runs against a fresh app with an empty in-memory store
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
 		})
 
 		g.Describe("Create Post Route Suit", func() {
@@ -229,6 +231,32 @@ func TestPostsRoute(t *testing.T) {
 				assert.NotEqual(t, updates.Title, inputs.Title)
 				assert.NotEqual(t, updates.Description, inputs.Description)
 			})
+
+			g.It("returns 404 on a missing post or another user's post @UPDATE_POST", func() {
+				resp, userOneInputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, userOneLogin := TLogin(app, TLoginInputs{Email: userOneInputs.Email, Password: userOneInputs.Password})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp, _, post := TCreatePost(app, userOneLogin.Data.Token)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				userTwoInputs := TSignInputs{Email: "sec@user.com", UserName: "sec_user", Password: "password"}
+				resp, _, _ = TSignup(app, userTwoInputs)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, userTwoLogin := TLogin(app, TLoginInputs{Email: userTwoInputs.Email, Password: userTwoInputs.Password})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				updates := Map{"title": "updated title", "description": "updated description"}
+
+				resp = TSend(app, "PUT", "/api/v1/post/"+post.ID, userTwoLogin.Data.Token, updates)
+				g.Assert(resp.StatusCode).Equal(404)
+
+				resp = TSend(app, "PUT", "/api/v1/post/"+primitive.NewObjectID().Hex(), userOneLogin.Data.Token, updates)
+				g.Assert(resp.StatusCode).Equal(404)
+			})
 		})
 
 		g.Describe("Delete Post Route Suit", func() {
@@ -511,6 +539,70 @@ func TestPostsRoute(t *testing.T) {
 				g.Assert(len(homeTimelineResp.Posts)).Equal(0)
 				assert.NotNil(t, homeTimelineResp.Count)
 			})
+
+			g.It("returns the posts of followed users @TIMELINE", func() {
+				// signup and login the author, then create a post
+				resp, authorInputs, author := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, authorLogin := TLogin(app, TLoginInputs{
+					Email:    authorInputs.Email,
+					Password: authorInputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp, _, post := TCreatePost(app, authorLogin.Data.Token)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				// signup and login the reader, then follow the author
+				resp, readerInputs, reader := TSignup(app, TSignInputs{
+					Email:    "sec@user.com",
+					UserName: "sec_user",
+					Password: "password",
+				})
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, readerLogin := TLogin(app, TLoginInputs{
+					Email:    readerInputs.Email,
+					Password: readerInputs.Password,
+				})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				req := MakeRequest(Req{
+					Method: "POST",
+					Target: "/api/v1/user/" + author.ID,
+					Options: Opt{
+						Header: Map{
+							"Authorization": "Bearer " + readerLogin.Data.Token,
+						},
+					},
+				})
+				resp, _ = app.Test(req, -1)
+				g.Assert(resp.StatusCode).Equal(200)
+
+				req = MakeRequest(Req{
+					Method: "GET",
+					Target: "/api/v1/post/timeline/home/" + reader.ID,
+				})
+
+				var homeTimelineResp struct {
+					Count int32 `json:"count"`
+					Posts []struct {
+						ID string `json:"id"`
+					} `json:"posts"`
+				}
+
+				resp, _ = app.Test(req, -1)
+				g.Assert(resp.StatusCode).Equal(200)
+
+				err := json.NewDecoder(resp.Body).Decode(&homeTimelineResp)
+				if err != nil {
+					panic(err)
+				}
+
+				g.Assert(homeTimelineResp.Count).Equal(int32(1))
+				g.Assert(homeTimelineResp.Posts[0].ID).Equal(post.ID)
+			})
 		})
 
 		g.Describe("User Timeline Routes Suits", func() {
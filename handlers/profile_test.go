@@ -0,0 +1,122 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestProfiles(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token string
+
+	profile := func(username string) models.Profile {
+		resp := TSend(app, "GET", "/api/v1/users/"+username, "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var p models.Profile
+		TDecode(resp, &p)
+		return p
+	}
+
+	g.Describe("Profiles Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.It("updates and clears the profile fields", func() {
+			resp := TSend(app, "PUT", "/api/v1/user", token, map[string]string{
+				"displayName": " Jane Doe ",
+				"bio":         "Gopher, café owner ☕",
+				"location":    "Lyon",
+				"website":     "https://jane.example.com",
+				"avatar":      "https://cdn.example.com/jane.png",
+			})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var user models.User
+			TDecode(resp, &user)
+			g.Assert(user.DisplayName).Equal("Jane Doe")
+			g.Assert(user.Website).Equal("https://jane.example.com")
+
+			// the fields that aren't sent are left alone, blank ones are cleared
+			resp = TSend(app, "PUT", "/api/v1/user", token, map[string]string{"location": ""})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			p := profile(TSignupInputsVal.UserName)
+			g.Assert(p.DisplayName).Equal("Jane Doe")
+			g.Assert(p.Bio).Equal("Gopher, café owner ☕")
+			g.Assert(p.Location).Equal("")
+			g.Assert(p.Avatar).Equal("https://cdn.example.com/jane.png")
+		})
+
+		g.It("validates the profile fields", func() {
+			invalid := []map[string]string{
+				{"website": "javascript:alert(1)"},
+				{"website": "not a url"},
+				{"avatar": "ftp://example.com/a.png"},
+				{"displayName": "this display name is way longer than fifty characters"},
+				{"bio": strings.Repeat("a", 161)},
+			}
+
+			for _, body := range invalid {
+				resp := TSend(app, "PUT", "/api/v1/user", token, body)
+				g.Assert(resp.StatusCode).Equal(400)
+			}
+
+			g.Assert(profile(TSignupInputsVal.UserName).Website).Equal("")
+		})
+
+		g.It("returns the public profile with the counts", func() {
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, otherUser := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, _, _ = TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+			resp, _, _ = TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+otherUser.ID, token, nil).StatusCode).Equal(200)
+
+			p := profile(TSignupInputsVal.UserName)
+			g.Assert(p.PostsCount).Equal(2)
+			g.Assert(p.FollowingCount).Equal(1)
+			g.Assert(p.FollowersCount).Equal(0)
+
+			p = profile(other.UserName)
+			g.Assert(p.ID).Equal(otherUser.ID)
+			g.Assert(p.FollowersCount).Equal(1)
+
+			// the email stays private
+			resp = TSend(app, "GET", "/api/v1/users/"+other.UserName, "", nil)
+			body, _ := ioutil.ReadAll(resp.Body)
+			g.Assert(strings.Contains(string(body), other.Email)).IsFalse()
+
+			resp = TSend(app, "GET", "/api/v1/users/nobody", "", nil)
+			g.Assert(resp.StatusCode).Equal(404)
+		})
+	})
+}
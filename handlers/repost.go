@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deleteReposts removes the reposts of a deleted post and pulls them out of the reposters' posts[].
+func deleteReposts(ctx context.Context, posts store.PostStore, users store.UserStore, postId primitive.ObjectID) error {
+	reposts, err := posts.DeleteReposts(ctx, postId)
+	if err != nil {
+		return err
+	}
+
+	for _, repost := range reposts {
+		repostId, _ := primitive.ObjectIDFromHex(repost.ID)
+
+		if authorId, err := primitive.ObjectIDFromHex(repost.Author.ID); err == nil {
+			if err := users.RemovePost(ctx, authorId, repostId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findShared returns the post to repost or quote, a repost stands for the post it shares.
+// It answers the request itself and returns false when the post doesn't exist.
+func (p PostHandler) findShared(c *fiber.Ctx, postId primitive.ObjectID) (*models.Post, bool) {
+	post, err := p.Posts.FindByID(c.Fasthttp, postId)
+
+	if err == nil && post.RepostOf != nil {
+		post, err = p.Posts.FindByID(c.Fasthttp, *post.RepostOf)
+	}
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Post not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return nil, false
+	}
+	return post, true
+}
+
+// canShare checks the user may repost or quote the post, only private accounts share their own posts
+// and neither user may have blocked the other. It answers the request itself and returns false when they can't.
+func (p PostHandler) canShare(c *fiber.Ctx, userId primitive.ObjectID, post *models.Post) bool {
+	authorId, err := primitive.ObjectIDFromHex(post.Author.ID)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return false
+	}
+
+	if authorId == userId {
+		return true
+	}
+
+	author, err := p.Users.FindByID(c.Fasthttp, authorId)
+
+	if err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return false
+	}
+
+	if err == nil && author.Private {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "Posts of private accounts can't be shared"})
+		return false
+	}
+
+	blocked, err := isBlockedBetween(c.Fasthttp, p.Users, userId, authorId)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return false
+	}
+
+	if blocked {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "You can't share this post"})
+		return false
+	}
+	return true
+}
+
+/**
+ * @Route /post/:id/repost
+ * @Mothod PUT
+ * @Protected ✔️
+ */
+func (p PostHandler) Repost(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	postId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	post, ok := p.findShared(c, postId)
+	if !ok {
+		return
+	}
+
+	postId, _ = primitive.ObjectIDFromHex(post.ID)
+
+	// reposting twice changes nothing
+	_, err = p.Posts.FindRepost(c.Fasthttp, postId, userId)
+
+	if err == nil {
+		p.sendRepostState(c, postId, "Post reposted", true)
+		return
+	}
+
+	if err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if !p.canShare(c, userId, post) {
+		return
+	}
+
+	repost := models.Post{
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Comments:  []primitive.ObjectID{},
+		Likes:     []primitive.ObjectID{},
+		Author: models.Author{
+			ID:       user.ID,
+			UserName: user.UserName,
+		},
+		RepostOf: &postId,
+	}
+
+	err = p.Posts.Create(c.Fasthttp, &repost)
+
+	// a concurrent request reposted it first
+	if err == store.ErrDuplicate {
+		p.sendRepostState(c, postId, "Post reposted", true)
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// the repost is an entry of the user's timeline
+	repostId, _ := primitive.ObjectIDFromHex(repost.ID)
+
+	if err := p.Users.AddPost(c.Fasthttp, userId, repostId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	p.sendRepostState(c, postId, "Post reposted", true)
+}
+
+/**
+ * @Route /post/:id/repost
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (p PostHandler) Unrepost(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	postId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	post, ok := p.findShared(c, postId)
+	if !ok {
+		return
+	}
+
+	postId, _ = primitive.ObjectIDFromHex(post.ID)
+
+	repost, err := p.Posts.FindRepost(c.Fasthttp, postId, userId)
+
+	// there is nothing to undo
+	if err == store.ErrNotFound {
+		p.sendRepostState(c, postId, "Repost removed", false)
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	repostId, _ := primitive.ObjectIDFromHex(repost.ID)
+
+	if err := p.Posts.Delete(c.Fasthttp, repostId, userId); err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := p.Users.RemovePost(c.Fasthttp, userId, repostId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	p.sendRepostState(c, postId, "Repost removed", false)
+}
+
+// sendRepostState answers with the repost count the post has now, concurrent requests included.
+func (p PostHandler) sendRepostState(c *fiber.Ctx, postId primitive.ObjectID, message string, reposted bool) {
+	post, err := p.Posts.FindByID(c.Fasthttp, postId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Post not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     message,
+		"isReposted":  reposted,
+		"repostCount": post.RepostCount,
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+	}
+}
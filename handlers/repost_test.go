@@ -0,0 +1,287 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestReposts(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token, userId, postId string
+
+	type account struct{ id, token string }
+
+	signup := func(name string) account {
+		inputs := TSignInputs{Email: name + "@gotter.local", UserName: name, Password: "password"}
+		resp, _, user := TSignup(app, inputs)
+		g.Assert(resp.StatusCode).Equal(201)
+
+		resp, login := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+		g.Assert(resp.StatusCode).Equal(200)
+		return account{id: user.ID, token: login.Data.Token}
+	}
+
+	repost := func(method, id, token string) (int, int) {
+		resp := TSend(app, method, "/api/v1/post/"+id+"/repost", token, nil)
+
+		var data struct {
+			RepostCount int `json:"repostCount"`
+		}
+		if resp.StatusCode == 200 {
+			TDecode(resp, &data)
+		}
+		return resp.StatusCode, data.RepostCount
+	}
+
+	timelineAs := func(target, token string) (int32, []models.PostWithComment) {
+		resp := TSend(app, "GET", target, token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Count int32                    `json:"count"`
+			Posts []models.PostWithComment `json:"posts"`
+		}
+		TDecode(resp, &data)
+		return data.Count, data.Posts
+	}
+
+	timeline := func(target string) (int32, []models.PostWithComment) {
+		return timelineAs(target, "")
+	}
+
+	g.Describe("Reposts Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+
+			resp, _, post := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+			postId = post.ID
+		})
+
+		g.It("reposts and takes the repost back idempotently", func() {
+			other := signup("other")
+
+			for i := 0; i < 2; i++ {
+				status, count := repost("PUT", postId, other.token)
+				g.Assert(status).Equal(200)
+				g.Assert(count).Equal(1)
+			}
+
+			_, posts := timeline("/api/v1/post/timeline/user/" + other.id)
+			g.Assert(len(posts)).Equal(1)
+			g.Assert(posts[0].RepostOf.Hex()).Equal(postId)
+			g.Assert(posts[0].Original.ID).Equal(postId)
+
+			// reposting the repost shares the original
+			status, count := repost("PUT", posts[0].ID, token)
+			g.Assert(status).Equal(200)
+			g.Assert(count).Equal(2)
+
+			for i := 0; i < 2; i++ {
+				status, count := repost("DELETE", postId, other.token)
+				g.Assert(status).Equal(200)
+				g.Assert(count).Equal(1)
+			}
+
+			_, posts = timeline("/api/v1/post/timeline/user/" + other.id)
+			g.Assert(len(posts)).Equal(0)
+		})
+
+		g.It("shows a post reposted several times once in the home timeline", func() {
+			first, second, reader := signup("first"), signup("second"), signup("reader")
+
+			for _, followed := range []account{first, second} {
+				g.Assert(TSend(app, "PUT", "/api/v1/users/"+followed.id+"/follow", reader.token, nil).StatusCode).Equal(200)
+			}
+
+			g.Assert(TSend(app, "PUT", "/api/v1/post/"+postId+"/repost", first.token, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "PUT", "/api/v1/post/"+postId+"/repost", second.token, nil).StatusCode).Equal(200)
+
+			count, posts := timeline("/api/v1/post/timeline/home/" + reader.id)
+			g.Assert(count).Equal(int32(1))
+			g.Assert(posts[0].Author.ID).Equal(second.id)
+			g.Assert(posts[0].Original.ID).Equal(postId)
+			g.Assert(posts[0].Original.RepostCount).Equal(2)
+
+			// the system timeline only has the original
+			count, posts = timeline("/api/v1/post/timeline/home")
+			g.Assert(count).Equal(int32(1))
+			g.Assert(posts[0].ID).Equal(postId)
+			g.Assert(posts[0].RepostCount).Equal(2)
+
+			// the reposts of blocked authors are left out
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+userId+"/block", reader.token, nil).StatusCode).Equal(200)
+
+			count, _ = timeline("/api/v1/post/timeline/home/" + reader.id)
+			g.Assert(count).Equal(int32(0))
+		})
+
+		g.It("quotes a post", func() {
+			other := signup("other")
+
+			resp := TSend(app, "POST", "/api/v1/post", other.token, map[string]string{
+				"title":       "quote",
+				"description": "what they said",
+				"quote":       postId,
+			})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			var quote models.Post
+			TDecode(resp, &quote)
+			g.Assert(quote.QuoteOf.Hex()).Equal(postId)
+
+			count, posts := timeline("/api/v1/post/timeline/home")
+			g.Assert(count).Equal(int32(2))
+			g.Assert(posts[0].ID).Equal(quote.ID)
+			g.Assert(posts[0].Original.ID).Equal(postId)
+			g.Assert(posts[1].QuoteCount).Equal(1)
+
+			resp = TSend(app, "POST", "/api/v1/post", other.token, map[string]string{
+				"title":       "quote",
+				"description": "what they said",
+				"quote":       quote.Author.ID,
+			})
+			g.Assert(resp.StatusCode).Equal(404)
+		})
+
+		g.It("doesn't share the posts of private or blocking accounts", func() {
+			other := signup("other")
+
+			g.Assert(TSend(app, "PUT", "/api/v1/user", token, map[string]bool{"private": true}).StatusCode).Equal(200)
+
+			status, _ := repost("PUT", postId, other.token)
+			g.Assert(status).Equal(403)
+
+			resp := TSend(app, "POST", "/api/v1/post", other.token, map[string]string{
+				"title":       "quote",
+				"description": "what they said",
+				"quote":       postId,
+			})
+			g.Assert(resp.StatusCode).Equal(403)
+
+			// the author shares their own posts
+			status, _ = repost("PUT", postId, token)
+			g.Assert(status).Equal(200)
+
+			g.Assert(TSend(app, "PUT", "/api/v1/user", token, map[string]bool{"private": false}).StatusCode).Equal(200)
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+other.id+"/block", token, nil).StatusCode).Equal(200)
+
+			status, _ = repost("PUT", postId, other.token)
+			g.Assert(status).Equal(403)
+		})
+
+		g.It("deletes the reposts with the post", func() {
+			other := signup("other")
+
+			g.Assert(TSend(app, "PUT", "/api/v1/post/"+postId+"/repost", other.token, nil).StatusCode).Equal(200)
+
+			resp := TSend(app, "POST", "/api/v1/post", other.token, map[string]string{
+				"title":       "quote",
+				"description": "what they said",
+				"quote":       postId,
+			})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			g.Assert(TSend(app, "DELETE", "/api/v1/post/"+postId, token, nil).StatusCode).Equal(200)
+
+			// the quote stays, without the original
+			count, posts := timeline("/api/v1/post/timeline/home")
+			g.Assert(count).Equal(int32(1))
+			g.Assert(posts[0].Title).Equal("quote")
+			g.Assert(posts[0].Original == nil).IsTrue()
+
+			resp = TSend(app, "GET", "/api/v1/users/other", "", nil)
+			var profile models.Profile
+			TDecode(resp, &profile)
+			g.Assert(profile.PostsCount).Equal(1)
+		})
+
+		g.It("hides the originals of private accounts from the readers who don't follow them", func() {
+			other, reader := signup("other"), signup("reader")
+
+			g.Assert(TSend(app, "PUT", "/api/v1/users/"+other.id+"/follow", reader.token, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "PUT", "/api/v1/post/"+postId+"/repost", other.token, nil).StatusCode).Equal(200)
+
+			resp := TSend(app, "POST", "/api/v1/post", other.token, map[string]string{
+				"title":       "quote",
+				"description": "what they said",
+				"quote":       postId,
+			})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			// the author goes private once shared
+			g.Assert(TSend(app, "PUT", "/api/v1/user", token, map[string]bool{"private": true}).StatusCode).Equal(200)
+
+			// the repost is left out and the quote stays without the original
+			count, posts := timelineAs("/api/v1/post/timeline/home/"+reader.id, reader.token)
+			g.Assert(count).Equal(int32(1))
+			g.Assert(posts[0].Title).Equal("quote")
+			g.Assert(posts[0].Original == nil).IsTrue()
+
+			_, posts = timelineAs("/api/v1/post/timeline/user/"+other.id, reader.token)
+			g.Assert(len(posts)).Equal(2)
+			for _, post := range posts {
+				g.Assert(post.Original == nil).IsTrue()
+			}
+
+			// the author still sees their own post
+			_, posts = timelineAs("/api/v1/post/timeline/user/"+other.id, token)
+			for _, post := range posts {
+				g.Assert(post.Original.ID).Equal(postId)
+			}
+		})
+
+		g.It("hides the originals of the authors who blocked the reader", func() {
+			other, reader := signup("other"), signup("reader")
+
+			g.Assert(TSend(app, "PUT", "/api/v1/users/"+other.id+"/follow", reader.token, nil).StatusCode).Equal(200)
+			g.Assert(TSend(app, "PUT", "/api/v1/post/"+postId+"/repost", other.token, nil).StatusCode).Equal(200)
+
+			resp := TSend(app, "POST", "/api/v1/post", other.token, map[string]string{
+				"title":       "quote",
+				"description": "what they said",
+				"quote":       postId,
+			})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			count, _ := timelineAs("/api/v1/post/timeline/home/"+reader.id, reader.token)
+			g.Assert(count).Equal(int32(2))
+
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+reader.id+"/block", token, nil).StatusCode).Equal(200)
+
+			count, posts := timelineAs("/api/v1/post/timeline/home/"+reader.id, reader.token)
+			g.Assert(count).Equal(int32(1))
+			g.Assert(posts[0].Title).Equal("quote")
+			g.Assert(posts[0].Original == nil).IsTrue()
+
+			_, posts = timelineAs("/api/v1/post/timeline/user/"+other.id, reader.token)
+			g.Assert(len(posts)).Equal(2)
+			for _, post := range posts {
+				g.Assert(post.Original == nil).IsTrue()
+			}
+		})
+	})
+}
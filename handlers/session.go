@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deviceNameHeader lets apps name the device of the session, the user agent is used otherwise
+const deviceNameHeader = "X-Device-Name"
+
+type SessionHandlerInterface interface {
+	ListSessions(c *fiber.Ctx) interface{}
+	RevokeSession(c *fiber.Ctx) interface{}
+}
+
+type SessionHandler struct {
+	Sessions store.SessionStore
+	Tokens   store.RefreshTokenStore
+}
+
+// startSession records a new login of the user from this request and returns its ID,
+// which is the refresh token family of the login.
+func startSession(c *fiber.Ctx, sessions store.SessionStore, user *models.User) (string, error) {
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+
+	device := strings.TrimSpace(c.Get(deviceNameHeader))
+	if device == "" {
+		device = utils.DeviceLabel(userAgent)
+	}
+	if len(device) > 50 {
+		device = device[:50]
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		User:      userId,
+		Device:    device,
+		IP:        c.IP(),
+		UserAgent: userAgent,
+		CreatedAt: now,
+		LastSeen:  now,
+	}
+
+	if err := sessions.Create(c.Fasthttp, session); err != nil {
+		return "", err
+	}
+	return session.ID, nil
+}
+
+// revokeSession ends the session and revokes its refresh tokens, the guard rejects its access tokens right away.
+func revokeSession(ctx context.Context, sessions store.SessionStore, tokens store.RefreshTokenStore, id string, userId primitive.ObjectID) error {
+	if err := tokens.RevokeFamily(ctx, id); err != nil {
+		return err
+	}
+
+	sessionId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return store.ErrNotFound
+	}
+	return sessions.Revoke(ctx, sessionId, userId)
+}
+
+/**
+ * @Route /sessions
+ * @Mothod GET
+ * @Protected ✔️
+ */
+func (s SessionHandler) ListSessions(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+	claims := c.Locals("claims").(models.TokenClaims)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// sessions idle for longer than the refresh token lifetime can't be resumed
+	sessions, err := s.Sessions.ListByUser(c.Fasthttp, userId, time.Now().Add(-utils.RefreshTokenTTL()))
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.Session
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"sessions": sessions}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /sessions/:id
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (s SessionHandler) RevokeSession(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	sessionId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// the family only gets revoked when the session is the user's
+	session, err := s.Sessions.FindByID(c.Fasthttp, sessionId)
+
+	if err == store.ErrNotFound || (err == nil && session.User != userId) {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Session not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := revokeSession(c.Fasthttp, s.Sessions, s.Tokens, session.ID, userId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Session revoked"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
@@ -0,0 +1,138 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestSessions(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+
+	// login logs in from a device described by its headers
+	login := func(header Map) TLoginOutput {
+		header["Content-Type"] = "application/json"
+
+		resp, err := app.Test(MakeRequest(Req{
+			Method:  "POST",
+			Target:  "/api/v1/login",
+			Body:    JSONBody(TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password}),
+			Options: Opt{Header: header},
+		}), -1)
+		g.Assert(err == nil).IsTrue()
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var out TLoginOutput
+		TDecode(resp, &out)
+		return out
+	}
+
+	list := func(token string) []models.Session {
+		resp := TSend(app, "GET", "/api/v1/sessions", token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Sessions []models.Session `json:"sessions"`
+		}
+		TDecode(resp, &data)
+		return data.Sessions
+	}
+
+	g.Describe("Sessions Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+		})
+
+		g.It("lists the devices the user is logged in on", func() {
+			laptop := login(Map{"User-Agent": "Mozilla/5.0 (X11; Linux x86_64; rv:78.0) Gecko/20100101 Firefox/78.0"})
+			login(Map{"X-Device-Name": "Kitchen tablet"})
+
+			sessions := list(laptop.Data.Token)
+			g.Assert(len(sessions)).Equal(2)
+
+			devices := map[string]bool{}
+			for _, s := range sessions {
+				devices[s.Device] = s.Current
+				g.Assert(s.IP != "").IsTrue()
+				g.Assert(s.CreatedAt.IsZero()).IsFalse()
+			}
+			g.Assert(devices).Equal(map[string]bool{"Firefox on Linux": true, "Kitchen tablet": false})
+		})
+
+		g.It("revokes another session", func() {
+			laptop := login(Map{})
+			phone := login(Map{})
+
+			var phoneSession string
+			for _, s := range list(laptop.Data.Token) {
+				if !s.Current {
+					phoneSession = s.ID
+				}
+			}
+
+			resp := TSend(app, "DELETE", "/api/v1/sessions/"+phoneSession, laptop.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			// its access token stops working right away and it can't be refreshed
+			resp = TSend(app, "GET", "/api/v1/user", phone.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			resp, _ = TRefresh(app, phone.Data.RefreshToken)
+			g.Assert(resp.StatusCode).Equal(401)
+
+			sessions := list(laptop.Data.Token)
+			g.Assert(len(sessions)).Equal(1)
+			g.Assert(sessions[0].Current).IsTrue()
+
+			// the refreshed tokens stay in the same session
+			resp, refreshed := TRefresh(app, laptop.Data.RefreshToken)
+			g.Assert(resp.StatusCode).Equal(200)
+			g.Assert(list(refreshed.Data.Token)[0].ID).Equal(sessions[0].ID)
+		})
+
+		g.It("doesn't revoke the sessions of other users", func() {
+			own := login(Map{})
+			ownSession := list(own.Data.Token)[0].ID
+
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, _ := TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, otherLogin := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "DELETE", "/api/v1/sessions/"+ownSession, otherLogin.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(404)
+
+			resp = TSend(app, "GET", "/api/v1/user", own.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+		})
+
+		g.It("ends the session on logout", func() {
+			laptop := login(Map{})
+			phone := login(Map{})
+
+			resp := TSend(app, "POST", "/api/v1/logout", phone.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			g.Assert(len(list(laptop.Data.Token))).Equal(1)
+		})
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SuggestionHandlerInterface interface {
+	GetSuggestions(c *fiber.Ctx) interface{}
+}
+
+// SuggestionHandler serves the cached follow suggestions,
+// the ones of a user are computed on their first request and refreshed by jobs.Suggester.
+type SuggestionHandler struct {
+	Suggestions store.SuggestionStore
+	Suggester   jobs.Suggester
+}
+
+/**
+ * @Route /users/suggestions?limit=
+ * @Mothod GET
+ * @Protected ✔️
+ */
+func (s SuggestionHandler) GetSuggestions(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	suggestions, err := s.Suggestions.Find(c.Fasthttp, userId)
+	if err == store.ErrNotFound {
+		suggestions, err = s.Suggester.Refresh(c.Fasthttp, userId)
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	limit := 10
+	if lim, err := strconv.Atoi(c.Query("limit")); err == nil && lim > 0 {
+		limit = lim
+	}
+
+	// the cache lags behind, leave out who the user followed or blocked since
+	excluded := map[string]bool{}
+	for _, ids := range [][]primitive.ObjectID{user.Following, user.Blocked} {
+		for _, id := range ids {
+			excluded[id.Hex()] = true
+		}
+	}
+
+	users := []models.Suggestion{}
+	for _, suggestion := range suggestions.Users {
+		if len(users) < limit && !excluded[suggestion.ID] {
+			users = append(users, suggestion)
+		}
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"users":     users,
+		"updatedAt": suggestions.UpdatedAt,
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
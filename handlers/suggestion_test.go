@@ -0,0 +1,136 @@
+package handlers_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+func TestFollowSuggestions(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var s store.Store
+	var token, userId string
+
+	type account struct{ id, token string }
+
+	signup := func(name string) account {
+		inputs := TSignInputs{Email: name + "@gotter.local", UserName: name, Password: "password"}
+		resp, _, user := TSignup(app, inputs)
+		g.Assert(resp.StatusCode).Equal(201)
+
+		resp, login := TLogin(app, TLoginInputs{Email: inputs.Email, Password: inputs.Password})
+		g.Assert(resp.StatusCode).Equal(200)
+		return account{id: user.ID, token: login.Data.Token}
+	}
+
+	follow := func(token, id string) {
+		g.Assert(TSend(app, "PUT", "/api/v1/users/"+id+"/follow", token, nil).StatusCode).Equal(200)
+	}
+
+	suggestions := func() []models.Suggestion {
+		resp := TSend(app, "GET", "/api/v1/users/suggestions", token, nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data models.Suggestions
+		TDecode(resp, &data)
+		return data.Users
+	}
+
+	names := func(users []models.Suggestion) []string {
+		out := []string{}
+		for _, u := range users {
+			out = append(out, u.UserName)
+		}
+		return out
+	}
+
+	g.Describe("Follow Suggestions Test", func() {
+		g.BeforeEach(func() {
+			s = store.NewMemory()
+
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  s,
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, user := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+			userId = user.ID
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.It("ranks friends of friends, followers and active posters", func() {
+			friend, poster, blocked, fan := signup("friend"), signup("poster"), signup("blocked"), signup("fan")
+			signup("stranger")
+
+			follow(token, friend.id)
+			follow(friend.token, poster.id)
+			follow(friend.token, blocked.id)
+			follow(fan.token, userId)
+
+			resp, _, _ := TCreatePost(app, poster.token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			g.Assert(TSend(app, "POST", "/api/v1/user/"+blocked.id+"/block", token, nil).StatusCode).Equal(200)
+
+			users := suggestions()
+			g.Assert(names(users)).Equal([]string{"poster", "fan"})
+
+			g.Assert(users[0].MutualCount).Equal(1)
+			g.Assert(users[0].RecentlyActive).IsTrue()
+			g.Assert(users[1].FollowsYou).IsTrue()
+		})
+
+		g.It("serves the cache until the job refreshes it", func() {
+			friend, other := signup("friend"), signup("other")
+			follow(friend.token, other.id)
+			follow(token, friend.id)
+
+			g.Assert(names(suggestions())).Equal([]string{"other"})
+
+			// following is reflected right away
+			follow(token, other.id)
+			g.Assert(len(suggestions())).Equal(0)
+
+			late := signup("late")
+			resp, _, _ := TCreatePost(app, late.token)
+			g.Assert(resp.StatusCode).Equal(201)
+			g.Assert(len(suggestions())).Equal(0)
+
+			suggester := jobs.Suggester{Users: s.Users, Posts: s.Posts, Suggestions: s.Suggestions}
+
+			n, err := suggester.RefreshStale(context.Background(), time.Now())
+			g.Assert(err == nil).IsTrue()
+			g.Assert(n).Equal(0)
+
+			n, err = suggester.RefreshStale(context.Background(), time.Now().Add(utils.SuggestionsTTL()+time.Minute))
+			g.Assert(err == nil).IsTrue()
+			g.Assert(n).Equal(1)
+
+			g.Assert(names(suggestions())).Equal([]string{"late"})
+		})
+
+		g.It("isn't taken for a profile", func() {
+			resp := TSend(app, "GET", "/api/v1/users/suggestions", "", nil)
+			g.Assert(resp.StatusCode).Equal(401)
+		})
+	})
+}
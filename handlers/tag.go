@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+)
+
+/**
+ * @Route /tags/:tag?page=&limit=
+ * @Mothod GET
+ */
+func (p PostHandler) TagTimeline(c *fiber.Ctx) {
+	// the tag may come with its # encoded
+	tag, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	tag = models.NormalizeTag(tag)
+	if tag == "" {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid tag"})
+		return
+	}
+
+	posts, count, err := p.Posts.ListByTag(c.Fasthttp, tag, pageQuery(c))
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if posts == nil {
+		posts = []models.PostWithComment{}
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"tag":   tag,
+		"count": count,
+		"posts": posts,
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+	}
+}
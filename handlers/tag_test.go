@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestTags(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token string
+
+	create := func(token, title, description string) models.Post {
+		resp := TSend(app, "POST", "/api/v1/post", token, map[string]string{"title": title, "description": description})
+		g.Assert(resp.StatusCode).Equal(201)
+
+		var post models.Post
+		TDecode(resp, &post)
+		return post
+	}
+
+	tagged := func(tag string) (int32, []models.PostWithComment) {
+		resp := TSend(app, "GET", "/api/v1/tags/"+tag, "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Count int32                    `json:"count"`
+			Posts []models.PostWithComment `json:"posts"`
+		}
+		TDecode(resp, &data)
+		return data.Count, data.Posts
+	}
+
+	g.Describe("Tags Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+		})
+
+		g.It("parses and normalizes the hashtags", func() {
+			g.Assert(models.ParseTags("#Go and #golang_1, #go again", "#Café")).Equal([]string{"go", "golang_1", "café"})
+			g.Assert(len(models.ParseTags("a#b &#39; #2020 # ##x"))).Equal(0)
+			g.Assert(models.NormalizeTag("#GoLang")).Equal("golang")
+			g.Assert(models.NormalizeTag("go-lang")).Equal("")
+		})
+
+		g.It("stores the tags on create and update", func() {
+			post := create(token, "Hello #World", "learning #Go")
+			g.Assert(post.Tags).Equal([]string{"world", "go"})
+
+			resp := TSend(app, "PUT", "/api/v1/post/"+post.ID, token, map[string]string{"description": "learning #rust"})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			TDecode(resp, &post)
+			g.Assert(post.Tags).Equal([]string{"world", "rust"})
+
+			count, _ := tagged("go")
+			g.Assert(count).Equal(int32(0))
+
+			count, posts := tagged("RUST")
+			g.Assert(count).Equal(int32(1))
+			g.Assert(posts[0].ID).Equal(post.ID)
+		})
+
+		g.It("paginates the tag timeline, newest first", func() {
+			first := create(token, "first", "#gotter is out")
+			create(token, "untagged", "nothing to see")
+			second := create(token, "second", "hello #Gotter")
+
+			count, posts := tagged("%23gotter?limit=1")
+			g.Assert(count).Equal(int32(2))
+			g.Assert(len(posts)).Equal(1)
+			g.Assert(posts[0].ID).Equal(second.ID)
+
+			_, posts = tagged("gotter?limit=1&page=2")
+			g.Assert(posts[0].ID).Equal(first.ID)
+
+			// the count is the total, even past the end
+			count, posts = tagged("gotter?limit=1&page=5")
+			g.Assert(count).Equal(int32(2))
+			g.Assert(len(posts)).Equal(0)
+
+			g.Assert(TSend(app, "GET", "/api/v1/tags/not-a-tag", "", nil).StatusCode).Equal(400)
+		})
+
+		g.It("leaves the private accounts out", func() {
+			create(token, "hidden", "a #secret")
+			g.Assert(TSend(app, "PUT", "/api/v1/user", token, map[string]bool{"private": true}).StatusCode).Equal(200)
+
+			count, posts := tagged("secret")
+			g.Assert(count).Equal(int32(0))
+			g.Assert(len(posts)).Equal(0)
+		})
+	})
+}
@@ -16,7 +16,10 @@ type TLoginInputs struct {
 
 type TLoginOutput struct {
 	Data struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
+		// MFAToken is returned instead of the tokens when the user has 2FA enabled
+		MFAToken string `json:"mfaToken"`
 	} `json:"data"`
 	Message string `json:"message"`
 }
@@ -0,0 +1,36 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gofiber/fiber"
+)
+
+func TRefresh(app *fiber.App, refreshToken string) (*http.Response, TLoginOutput) {
+	buf := new(bytes.Buffer)
+	err := json.NewEncoder(buf).Encode(map[string]string{"refreshToken": refreshToken})
+
+	if err != nil {
+		panic(err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/token/refresh", buf)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, _ := app.Test(req, -1)
+
+	var data TLoginOutput
+
+	if resp.StatusCode == 200 {
+		err := json.NewDecoder(resp.Body).Decode(&data)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return resp, data
+}
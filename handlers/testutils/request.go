@@ -1,9 +1,13 @@
 package testutils
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+
+	"github.com/gofiber/fiber"
 )
 
 type Map map[string]string
@@ -33,3 +37,46 @@ func MakeRequest(inputs Req) *http.Request {
 
 	return req
 }
+
+// JSONBody encodes v as a request body, use it with a "Content-Type: application/json" header.
+func JSONBody(v interface{}) io.Reader {
+	buf := new(bytes.Buffer)
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// TSend performs a JSON request, token is sent as the Bearer token when not empty and body is skipped when nil.
+func TSend(app *fiber.App, method, target, token string, body interface{}) *http.Response {
+	header := Map{}
+	if token != "" {
+		header["Authorization"] = "Bearer " + token
+	}
+
+	var reader io.Reader
+	if body != nil {
+		header["Content-Type"] = "application/json"
+		reader = JSONBody(body)
+	}
+
+	resp, err := app.Test(MakeRequest(Req{
+		Method:  method,
+		Target:  target,
+		Body:    reader,
+		Options: Opt{Header: header},
+	}), -1)
+
+	if err != nil {
+		panic(err)
+	}
+	return resp
+}
+
+// TDecode decodes the JSON body of the response into v.
+func TDecode(resp *http.Response, v interface{}) {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,49 @@
+package testutils
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gofiber/fiber"
+)
+
+// TUpload posts the data as the "file" field of a multipart form, fields are sent alongside.
+func TUpload(app *fiber.App, target, token, filename string, data []byte, fields Map) *http.Response {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+
+	for key, val := range fields {
+		if err := form.WriteField(key, val); err != nil {
+			panic(err)
+		}
+	}
+
+	file, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := file.Write(data); err != nil {
+		panic(err)
+	}
+	if err := form.Close(); err != nil {
+		panic(err)
+	}
+
+	header := Map{"Content-Type": form.FormDataContentType()}
+	if token != "" {
+		header["Authorization"] = "Bearer " + token
+	}
+
+	resp, err := app.Test(MakeRequest(Req{
+		Method:  "POST",
+		Target:  target,
+		Body:    body,
+		Options: Opt{Header: header},
+	}), -1)
+
+	if err != nil {
+		panic(err)
+	}
+	return resp
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the thread is nested 3 levels deep by default, each level shows at most maxThreadLimit replies
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+	maxThreadLimit     = 50
+)
+
+/**
+ * @Route /post/:id/thread?depth=&page=&limit=&parent=
+ *  - limit applies to every level and page to the first one,
+ *    the next replies of a comment are fetched with ?parent=<comment id>&page=
+ * @Mothod GET
+ */
+func (CH CommentHandler) GetThread(c *fiber.Ctx) {
+	postId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	post, err := CH.Posts.FindByID(c.Fasthttp, postId)
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Post not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// the conversations of private accounts are only shown to themselves and their followers
+	authorId, err := primitive.ObjectIDFromHex(post.Author.ID)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	author, err := CH.Users.FindByID(c.Fasthttp, authorId)
+
+	if err != nil && err != store.ErrNotFound {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err == nil && !canView(loggedInUser(c), *author) {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "This account is private"})
+		return
+	}
+
+	depth := defaultThreadDepth
+	if d, err := strconv.Atoi(c.Query("depth")); err == nil && d > 0 {
+		depth = d
+	}
+	if depth > maxThreadDepth {
+		depth = maxThreadDepth
+	}
+
+	// every level multiplies the size of the thread
+	page := pageQuery(c)
+	if page.Limit > maxThreadLimit {
+		page.Skip = page.Skip / page.Limit * maxThreadLimit
+		page.Limit = maxThreadLimit
+	}
+
+	// the first level holds the comments on the post, or the replies to ?parent=
+	var level []models.Comment
+	var count int32
+
+	if parent := c.Query("parent"); parent != "" {
+		parentId, err := primitive.ObjectIDFromHex(parent)
+		if err != nil {
+			c.Status(fiber.StatusBadRequest).Send(err)
+			return
+		}
+
+		comment, err := CH.Comments.FindByID(c.Fasthttp, parentId)
+
+		if err == store.ErrNotFound || (err == nil && comment.Post != postId) {
+			c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "Comment not found"})
+			return
+		}
+
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		level, err = CH.Comments.ListReplies(c.Fasthttp, []primitive.ObjectID{parentId}, page)
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+		count = int32(comment.ReplyCount)
+	} else {
+		level, count, err = CH.Comments.ListTopLevel(c.Fasthttp, postId, page)
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+	}
+
+	// one query per level, the first page of replies of every comment of the level above
+	levels := [][]models.Comment{level}
+	for len(levels) < depth && len(level) > 0 {
+		parentIds := make([]primitive.ObjectID, 0, len(level))
+		for _, comment := range level {
+			if comment.ReplyCount > 0 {
+				id, _ := primitive.ObjectIDFromHex(comment.ID)
+				parentIds = append(parentIds, id)
+			}
+		}
+
+		if len(parentIds) == 0 {
+			break
+		}
+
+		level, err = CH.Comments.ListReplies(c.Fasthttp, parentIds, store.Page{Limit: page.Limit})
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+		levels = append(levels, level)
+	}
+
+	// the replies of every comment below the first level, in order
+	replies := map[string][]models.Comment{}
+	for _, level := range levels[1:] {
+		for _, comment := range level {
+			replies[comment.Parent.Hex()] = append(replies[comment.Parent.Hex()], comment)
+		}
+	}
+
+	var thread func(comments []models.Comment) []models.CommentThread
+	thread = func(comments []models.Comment) []models.CommentThread {
+		nodes := []models.CommentThread{}
+		for _, comment := range comments {
+			nodes = append(nodes, models.CommentThread{Comment: comment, Replies: thread(replies[comment.ID])})
+		}
+		return nodes
+	}
+
+	type Data struct {
+		Count    int32                  `json:"count"`
+		Comments []models.CommentThread `json:"comments"`
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(Data{
+		Count:    count,
+		Comments: thread(levels[0]),
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
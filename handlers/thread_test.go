@@ -0,0 +1,170 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestThreads(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var token, postId string
+
+	reply := func(parent, message string) models.Comment {
+		resp := TSend(app, "POST", "/api/v1/comment", token, map[string]string{"postId": postId, "message": message, "parent": parent})
+		g.Assert(resp.StatusCode).Equal(201)
+
+		var comment models.Comment
+		TDecode(resp, &comment)
+		return comment
+	}
+
+	thread := func(query string) (int32, []models.CommentThread) {
+		resp := TSend(app, "GET", "/api/v1/post/"+postId+"/thread"+query, "", nil)
+		g.Assert(resp.StatusCode).Equal(200)
+
+		var data struct {
+			Count    int32                  `json:"count"`
+			Comments []models.CommentThread `json:"comments"`
+		}
+		TDecode(resp, &data)
+		return data.Count, data.Comments
+	}
+
+	g.Describe("Threads Test", func() {
+		g.BeforeEach(func() {
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
+
+			resp, _, _ := TSignup(app)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, login := TLogin(app, TLoginInputs{Email: TSignupInputsVal.Email, Password: TSignupInputsVal.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+			token = login.Data.Token
+
+			resp, _, post := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+			postId = post.ID
+		})
+
+		g.It("nests the replies up to the depth", func() {
+			first := reply("", "first")
+			second := reply("", "second")
+			answer := reply(first.ID, "answer")
+			g.Assert(answer.Parent.Hex()).Equal(first.ID)
+
+			deeper := reply(answer.ID, "deeper")
+			reply(deeper.ID, "deepest")
+
+			count, comments := thread("")
+			g.Assert(count).Equal(int32(2))
+			g.Assert(comments[0].ID).Equal(first.ID)
+			g.Assert(comments[0].ReplyCount).Equal(1)
+			g.Assert(comments[1].ID).Equal(second.ID)
+			g.Assert(len(comments[1].Replies)).Equal(0)
+
+			level2 := comments[0].Replies[0]
+			g.Assert(level2.Message).Equal("answer")
+
+			// the third level has the count but not the replies
+			level3 := level2.Replies[0]
+			g.Assert(level3.ID).Equal(deeper.ID)
+			g.Assert(level3.ReplyCount).Equal(1)
+			g.Assert(len(level3.Replies)).Equal(0)
+
+			_, comments = thread("?depth=4")
+			g.Assert(comments[0].Replies[0].Replies[0].Replies[0].Message).Equal("deepest")
+
+			_, comments = thread("?depth=1")
+			g.Assert(len(comments[0].Replies)).Equal(0)
+			g.Assert(comments[0].ReplyCount).Equal(1)
+		})
+
+		g.It("paginates every level", func() {
+			first := reply("", "first")
+			reply("", "second")
+			for _, message := range []string{"a", "b", "c"} {
+				reply(first.ID, message)
+			}
+
+			count, comments := thread("?limit=1")
+			g.Assert(count).Equal(int32(2))
+			g.Assert(len(comments)).Equal(1)
+			g.Assert(len(comments[0].Replies)).Equal(1)
+			g.Assert(comments[0].ReplyCount).Equal(3)
+
+			_, comments = thread("?limit=1&page=2")
+			g.Assert(comments[0].Message).Equal("second")
+
+			count, comments = thread("?parent=" + first.ID + "&limit=2&page=2")
+			g.Assert(count).Equal(int32(3))
+			g.Assert(len(comments)).Equal(1)
+			g.Assert(comments[0].Message).Equal("c")
+
+			// pages past the end are empty, whatever their size
+			count, comments = thread("?page=922337203685477580&limit=20")
+			g.Assert(count).Equal(int32(2))
+			g.Assert(len(comments)).Equal(0)
+
+			_, comments = thread("?page=2&limit=9223372036854775807")
+			g.Assert(len(comments)).Equal(0)
+
+			resp := TSend(app, "GET", "/api/v1/post/"+postId+"/thread?parent="+postId, "", nil)
+			g.Assert(resp.StatusCode).Equal(404)
+		})
+
+		g.It("only replies to the comments of the same post", func() {
+			resp, _, other := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp = TSend(app, "POST", "/api/v1/comment", token, map[string]string{"postId": other.ID, "message": "hi"})
+			g.Assert(resp.StatusCode).Equal(201)
+
+			var elsewhere models.Comment
+			TDecode(resp, &elsewhere)
+
+			resp = TSend(app, "POST", "/api/v1/comment", token, map[string]string{"postId": postId, "message": "hi", "parent": elsewhere.ID})
+			g.Assert(resp.StatusCode).Equal(400)
+		})
+
+		g.It("deletes the replies with the comment", func() {
+			first := reply("", "first")
+			answer := reply(first.ID, "answer")
+			reply(answer.ID, "deeper")
+			kept := reply("", "kept")
+			gone := reply(kept.ID, "gone")
+
+			// deleting a reply updates the count of its parent
+			g.Assert(TSend(app, "DELETE", "/api/v1/comment/"+gone.ID, token, nil).StatusCode).Equal(200)
+
+			g.Assert(TSend(app, "DELETE", "/api/v1/comment/"+first.ID, token, nil).StatusCode).Equal(200)
+
+			count, comments := thread("")
+			g.Assert(count).Equal(int32(1))
+			g.Assert(comments[0].ID).Equal(kept.ID)
+
+			// the post only keeps the remaining comment, which has no reply left
+			resp := TSend(app, "GET", "/api/v1/comment", "", nil)
+			var data struct {
+				Comments []models.Comment `json:"comments"`
+			}
+			TDecode(resp, &data)
+			g.Assert(len(data.Comments)).Equal(1)
+			g.Assert(data.Comments[0].ReplyCount).Equal(0)
+		})
+	})
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// issueTokens creates a short lived access token and a refresh token for the user,
+// the refresh token joins the given family or starts a new one when family is empty.
+func issueTokens(ctx context.Context, tokens store.RefreshTokenStore, user *models.User, family string) (fiber.Map, error) {
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := utils.AccessTokenTTL()
+
+	if family == "" {
+		family = primitive.NewObjectID().Hex()
+	}
+
+	// create access token, sid ties it to the refresh token family
+	// and tv to the user's token version so both can be revoked
+	accessToken, err := utils.CreateAccessToken(map[string]interface{}{
+		"username": user.UserName,
+		"email":    user.Email,
+		"id":       user.ID,
+		"jti":      primitive.NewObjectID().Hex(),
+		"sid":      family,
+		"tv":       user.TokenVersion,
+		"role":     userRole(user),
+		"typ":      models.AccessToken,
+		"aud":      utils.AccessTokenAudience(),
+	}, ttl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// create refresh token, only its hash is stored
+	refreshToken, err := utils.RandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = tokens.Create(ctx, &models.RefreshToken{
+		Hash:      utils.HashToken(refreshToken),
+		Family:    family,
+		User:      userId,
+		CreatedAt: now,
+		ExpiresAt: now.Add(utils.RefreshTokenTTL()),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+		"expiresIn":    int64(ttl.Seconds()),
+	}, nil
+}
+
+// issueOneTimeToken replaces the user's tokens for the purpose with a new one and returns it,
+// the token is meant to be emailed so only its hash is stored.
+func issueOneTimeToken(ctx context.Context, tokens store.OneTimeTokenStore, purpose string, userId primitive.ObjectID, ttl time.Duration) (string, error) {
+	// only the latest link works
+	if err := tokens.DeleteUser(ctx, purpose, userId); err != nil {
+		return "", err
+	}
+
+	token, err := utils.RandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = tokens.Create(ctx, &models.OneTimeToken{
+		Hash:      utils.HashToken(token),
+		Purpose:   purpose,
+		User:      userId,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+/**
+ * @Route /token/refresh
+ * @Body {refreshToken: string}
+ * @Mothod POST
+ */
+func (a AuthHandler) RefreshToken(c *fiber.Ctx) {
+	inputs := new(models.RefreshInputs)
+
+	if err := c.BodyParser(inputs); err != nil || inputs.RefreshToken == "" {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid Inputs"})
+		return
+	}
+
+	token, err := a.Tokens.FindByHash(c.Fasthttp, utils.HashToken(inputs.RefreshToken))
+
+	if err != nil {
+		if err != store.ErrNotFound {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid refresh token"})
+		return
+	}
+
+	if token.Revoked || time.Now().After(token.ExpiresAt) {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid refresh token"})
+		return
+	}
+
+	tokenId, err := primitive.ObjectIDFromHex(token.ID)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	reused := token.Used
+	if !reused {
+		err = a.Tokens.MarkUsed(c.Fasthttp, tokenId)
+
+		if err != nil && err != store.ErrNotFound {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+		reused = err == store.ErrNotFound
+	}
+
+	// a refresh token can only be used once, seeing it again means it was stolen
+	// so the whole family (including the token the legit client holds now) gets revoked
+	if reused {
+		err := revokeSession(c.Fasthttp, a.Sessions, a.Tokens, token.Family, token.User)
+
+		if err != nil && err != store.ErrNotFound {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Refresh token reuse detected"})
+		return
+	}
+
+	user, err := a.Users.FindByID(c.Fasthttp, token.User)
+	if err != nil {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid refresh token"})
+		return
+	}
+
+	data, err := issueTokens(c.Fasthttp, a.Tokens, user, token.Family)
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// families started before sessions were tracked have none to touch
+	if sessionId, err := primitive.ObjectIDFromHex(token.Family); err == nil {
+		if err := a.Sessions.Touch(c.Fasthttp, sessionId, time.Now(), c.IP()); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Token Refreshed",
+		"data":    data,
+	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /logout
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (a AuthHandler) Logout(c *fiber.Ctx) {
+	claims := c.Locals("claims").(models.TokenClaims)
+
+	// denylist the access token until it expires
+	if err := a.Revocations.Revoke(c.Fasthttp, claims.ID, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	// and end the session with its refresh tokens
+	if claims.Session != "" {
+		userId, _ := primitive.ObjectIDFromHex(c.Locals("user").(models.User).ID)
+
+		if err := revokeSession(c.Fasthttp, a.Sessions, a.Tokens, claims.Session, userId); err != nil && err != store.ErrNotFound {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Logged out"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /logout/all
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (a AuthHandler) LogoutAll(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// bumping the token version invalidates every access token issued so far
+	if err := a.Users.IncrementTokenVersion(c.Fasthttp, userId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := a.Tokens.RevokeUser(c.Fasthttp, userId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := a.Sessions.RevokeUser(c.Fasthttp, userId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Logged out of all sessions"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
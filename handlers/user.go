@@ -1,23 +1,41 @@
 package handlers
 
 import (
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber"
-	. "github.com/kiranbhalerao123/gotter/config"
 	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
 	"github.com/kiranbhalerao123/gotter/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UserHandlersInterface interface {
 	GetUser(ctx *fiber.Ctx) interface{}
 	UpdateUser(ctx *fiber.Ctx) interface{}
 	FollowUnFollowUser(c *fiber.Ctx) interface{}
+	DeleteUser(c *fiber.Ctx) interface{}
+	GetProfile(c *fiber.Ctx) interface{}
+	RestoreUser(c *fiber.Ctx) interface{}
+	BlockUser(c *fiber.Ctx) interface{}
+	UnblockUser(c *fiber.Ctx) interface{}
+	MuteUser(c *fiber.Ctx) interface{}
+	UnmuteUser(c *fiber.Ctx) interface{}
+	Follow(c *fiber.Ctx) interface{}
+	Unfollow(c *fiber.Ctx) interface{}
+	ListFollowers(c *fiber.Ctx) interface{}
+	ListFollowing(c *fiber.Ctx) interface{}
+	ListFollowRequests(c *fiber.Ctx) interface{}
+	ApproveFollowRequest(c *fiber.Ctx) interface{}
+	RejectFollowRequest(c *fiber.Ctx) interface{}
 }
 
 type UserHandler struct {
-	UserColl *mongo.Collection
+	Users store.UserStore
+	// RefreshTokens and Sessions are revoked when the password changes
+	RefreshTokens store.RefreshTokenStore
+	Sessions      store.SessionStore
 }
 
 func (u UserHandler) GetUser(c *fiber.Ctx) {
@@ -31,10 +49,7 @@ func (u UserHandler) GetUser(c *fiber.Ctx) {
 		return
 	}
 
-	filter := bson.D{{Key: "_id", Value: userId}}
-
-	var usr models.User
-	err = u.UserColl.FindOne(c.Fasthttp, filter).Decode(&usr)
+	usr, err := u.Users.FindByID(c.Fasthttp, userId)
 
 	if err != nil {
 		c.Status(400).Send(err)
@@ -51,8 +66,7 @@ func (u UserHandler) UpdateUser(c *fiber.Ctx) {
 	user := c.Locals("user").(models.User)
 
 	var inputs models.UpdateInputs
-	var username = user.UserName
-	var password = user.Password
+	var update store.UserUpdate
 
 	userId, err := primitive.ObjectIDFromHex(user.ID)
 
@@ -67,40 +81,134 @@ func (u UserHandler) UpdateUser(c *fiber.Ctx) {
 		return
 	}
 
+	// a leaked API key mustn't be enough to take the account over
+	if _, ok := c.Locals("apiKey").(models.APIKey); ok && (inputs.UserName != "" || inputs.Password != "") {
+		c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "API keys can't change the username or the password"})
+		return
+	}
+
+	// surrounding spaces don't count, a blank field clears it
+	for _, field := range []*string{inputs.DisplayName, inputs.Bio, inputs.Location, inputs.Website, inputs.Avatar, inputs.Banner} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// the usernames are unique, the profiles and the @mentions find the users by it
+	if inputs.UserName != "" && inputs.UserName != user.UserName {
+		_, err := u.Users.FindByUserName(c.Fasthttp, inputs.UserName)
+
+		if err != nil && err != store.ErrNotFound {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err == nil {
+			c.Status(fiber.StatusConflict).Send(fiber.Map{"message": "Username already taken"})
+			return
+		}
+	}
+
+	// going public lets the pending requests in
+	if inputs.Private != nil && !*inputs.Private {
+		for _, requesterId := range user.FollowRequests {
+			if err := u.approveFollowRequest(c.Fasthttp, requesterId, userId); err != nil {
+				c.Status(fiber.StatusInternalServerError).Send(err)
+				return
+			}
+		}
+	}
+
+	update.Private = inputs.Private
+	update.DisplayName = inputs.DisplayName
+	update.Bio = inputs.Bio
+	update.Location = inputs.Location
+	update.Website = inputs.Website
+	update.Avatar = inputs.Avatar
+	update.Banner = inputs.Banner
+
 	if inputs.UserName != "" {
-		username = inputs.UserName
+		update.UserName = &inputs.UserName
 	}
 
 	if inputs.Password != "" {
 		hashPassword := utils.Password{Password: inputs.Password}.Hash()
 
-		password = hashPassword
+		update.Password = &hashPassword
 	}
 
-	filter := bson.D{{Key: "_id", Value: userId}}
-	update := bson.M{"$set": bson.M{"username": username, "password": password}}
-	// update := bson.D{{Key: "$set", Value: bson.M{"username": username, "password": password}}} ✔️
+	updatedUser, err := u.Users.Update(c.Fasthttp, userId, update)
 
-	var updatedUser models.User
-
-	err = u.UserColl.FindOneAndUpdate(c.Fasthttp, filter, update, &MongoOps.New).Decode(&updatedUser)
+	if err == store.ErrDuplicate {
+		c.Status(fiber.StatusConflict).Send(fiber.Map{"message": "Username already taken"})
+		return
+	}
 
 	if err != nil {
 		c.Status(fiber.StatusInternalServerError).Send(err)
 		return
 	}
 
+	// like a password reset, whoever knew the old password shouldn't stay logged in
+	if update.Password != nil {
+		if err := u.Users.IncrementTokenVersion(c.Fasthttp, userId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err := u.RefreshTokens.RevokeUser(c.Fasthttp, userId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err := u.Sessions.RevokeUser(c.Fasthttp, userId); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+	}
+
 	if err := c.Status(200).JSON(updatedUser); err != nil {
 		c.Status(500).Send(err)
 		return
 	}
 }
 
+/**
+ * @Route /users/:username
+ * @Mothod GET
+ */
+func (u UserHandler) GetProfile(c *fiber.Ctx) {
+	user, err := u.Users.FindByUserName(c.Fasthttp, c.Params("username"))
+
+	// suspended accounts aren't shown to the public
+	if err == store.ErrNotFound || (err == nil && user.Suspended) {
+		c.Status(fiber.StatusNotFound).Send(fiber.Map{"message": "User not found"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(models.NewProfile(*user)); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
 /**
  * @Params /:id
  *  - another users id
  * @Mothod POST
  * @Protected ✔️
+ *
+ * toggles the follow, the PUT and DELETE /users/:id/follow routes are safe to retry
  */
 func (u UserHandler) FollowUnFollowUser(c *fiber.Ctx) {
 	user := c.Locals("user").(models.User)
@@ -119,56 +227,152 @@ func (u UserHandler) FollowUnFollowUser(c *fiber.Ctx) {
 	}
 
 	// check the user exists or not
-	err = u.UserColl.FindOne(c.Fasthttp, bson.M{"_id": anotherUserId}).Decode(&models.User{})
+	anotherUser, err := u.Users.FindByID(c.Fasthttp, anotherUserId)
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
 	// check for already following
-	err = u.UserColl.FindOne(c.Fasthttp, bson.M{"_id": anotherUserId, "followers": bson.M{"$in": bson.A{currentUserId}}}).Decode(&models.User{})
-
-	if err != nil && err.Error() != "mongo: no documents in result" {
+	alreadyFollowing, err := u.Users.IsFollowing(c.Fasthttp, currentUserId, anotherUserId)
+	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	alreadyFollowing := err == nil || err.Error() != "mongo: no documents in result"
+	// a pending request to a private account is cancelled the same way
+	alreadyRequested := false
+	if !alreadyFollowing {
+		alreadyRequested, err = u.Users.IsFollowRequested(c.Fasthttp, currentUserId, anotherUserId)
+		if err != nil {
+			c.Status(fiber.StatusBadRequest).Send(err)
+			return
+		}
+	}
 
-	// follow the user
-	currentUserUpdate := bson.M{"$push": bson.M{"following": anotherUserId}}
-	anotherUserUpdate := bson.M{"$push": bson.M{"followers": currentUserId}}
+	// unfollowing always works, following needs neither of them to have blocked the other
+	if !alreadyFollowing && !alreadyRequested {
+		blocked, err := isBlockedBetween(c.Fasthttp, u.Users, currentUserId, anotherUserId)
+		if err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if blocked {
+			c.Status(fiber.StatusForbidden).Send(fiber.Map{"message": "You can't follow this user"})
+			return
+		}
+	}
 
-	if alreadyFollowing {
-		// unfollow the user
-		currentUserUpdate = bson.M{"$pull": bson.M{"following": anotherUserId}}
-		anotherUserUpdate = bson.M{"$pull": bson.M{"followers": currentUserId}}
+	status := fiber.StatusOK
+	response := fiber.Map{"isFollowing": false, "isRequested": false}
+
+	// follow/unfollow the user, this updates both following[] and another users followers[],
+	// private accounts get a follow request they approve with /user/follow-requests/:id/approve
+	switch {
+	case alreadyFollowing:
+		err = u.Users.Unfollow(c.Fasthttp, currentUserId, anotherUserId)
+		response["message"] = "UnFollowed the user"
+	case alreadyRequested:
+		err = u.Users.RemoveFollowRequest(c.Fasthttp, currentUserId, anotherUserId)
+		response["message"] = "Cancelled the follow request"
+	case anotherUser.Private:
+		err = u.Users.RequestFollow(c.Fasthttp, currentUserId, anotherUserId)
+		status = fiber.StatusAccepted
+		response["message"] = "Follow request sent"
+		response["isRequested"] = true
+	default:
+		err = u.Users.Follow(c.Fasthttp, currentUserId, anotherUserId)
+		response["message"] = "Followed the user"
+		response["isFollowing"] = true
 	}
 
-	// follow/unfollow the user
-	_, err = u.UserColl.UpdateOne(c.Fasthttp, bson.M{"_id": currentUserId}, currentUserUpdate)
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	// add/remove from another users followers[]
-	_, err = u.UserColl.UpdateOne(c.Fasthttp, bson.M{"_id": anotherUserId}, anotherUserUpdate)
+	if err := c.Status(status).JSON(response); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /user
+ * @Body {password: string}
+ * @Mothod DELETE
+ * @Protected ✔️
+ */
+func (u UserHandler) DeleteUser(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+	inputs := new(models.DeleteAccountInputs)
+
+	if err := c.BodyParser(inputs); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	if err := inputs.Validate(); err != nil {
+		c.Status(fiber.StatusBadRequest).Send(err)
+		return
+	}
+
+	// accounts created with a social login have to set a password first
+	if !(utils.Password{Password: inputs.Password}).Compare(user.Password) {
+		c.Status(fiber.StatusUnauthorized).Send(fiber.Map{"message": "Invalid Credentials"})
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
 	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
 
-	message := "Followed the user"
-	if alreadyFollowing {
-		message = "UnFollowed the user"
+	// nothing is removed yet, jobs.PurgeDeletedAccounts deletes the account once the grace period is over
+	deleteAt := time.Now().Add(utils.AccountDeletionGrace())
+
+	if err := u.Users.ScheduleDeletion(c.Fasthttp, userId, deleteAt); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
 	}
 
-	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":     message,
-		"isFollowing": !alreadyFollowing,
+	if err := c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":  "Account scheduled for deletion",
+		"deleteAt": deleteAt,
 	}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /user/restore
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (u UserHandler) RestoreUser(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	if user.DeleteAt == nil {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Account isn't scheduled for deletion"})
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
 		c.Status(fiber.StatusBadRequest).Send(err)
 		return
 	}
+
+	if err := u.Users.CancelDeletion(c.Fasthttp, userId); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Account deletion cancelled"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
 }
@@ -2,33 +2,34 @@ package handlers_test
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
+	"io/ioutil"
 	"net/http/httptest"
 	"testing"
 
 	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
 	. "github.com/kiranbhalerao123/gotter/app"
-	. "github.com/kiranbhalerao123/gotter/config"
 	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
 	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestUserRoutes(t *testing.T) {
 	g := Goblin(t)
 
-	app := SetupApp()
-	SetupDB()
-	SetupRouter(app)
+	var app *fiber.App
 
 	g.Describe("User Routes Test", func() {
 		g.BeforeEach(func() {
-			err := Mongo.DB.Drop(context.Background())
-
-			if err != nil {
-				panic(err)
-			}
+			// every case runs against a fresh app with an empty in-memory store
+			app = SetupApp()
+			SetupRouter(app, Options{
+				Store:  store.NewMemory(),
+				Mailer: mailer.NewLogMailer(ioutil.Discard, "test@gotter.local"),
+			})
 		})
 
 		g.Describe("GET User Route Suits", func() {
@@ -151,6 +152,28 @@ func TestUserRoutes(t *testing.T) {
 				})
 				g.Assert(resp.StatusCode).Equal(401)
 			})
+
+			g.It("refuses malformed or taken usernames", func() {
+				resp, inputs, _ := TSignup(app)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+				resp, _, _ = TSignup(app, other)
+				g.Assert(resp.StatusCode).Equal(201)
+
+				resp, data := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+				g.Assert(resp.StatusCode).Equal(200)
+
+				resp = TSend(app, "PUT", "/api/v1/user", data.Data.Token, Map{"username": "@other"})
+				g.Assert(resp.StatusCode).Equal(400)
+
+				resp = TSend(app, "PUT", "/api/v1/user", data.Data.Token, Map{"username": inputs.UserName})
+				g.Assert(resp.StatusCode).Equal(409)
+
+				// keeping one's own username is fine
+				resp = TSend(app, "PUT", "/api/v1/user", data.Data.Token, Map{"username": other.UserName})
+				g.Assert(resp.StatusCode).Equal(200)
+			})
 		})
 
 		g.Describe("Follow/unfollow User Route Suits", func() {
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// markVerified marks the email of the user as verified, that's when the ADMIN_EMAILS accounts get
+// their admin role: until then nothing proves the address is theirs.
+func markVerified(ctx context.Context, users store.UserStore, userId primitive.ObjectID) (*models.User, error) {
+	user, err := users.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Verified {
+		return user, nil
+	}
+
+	verified := true
+	update := store.UserUpdate{Verified: &verified}
+
+	role := signupRole(user.Email)
+	promote := role == models.RoleAdmin && userRole(user) == models.RoleUser
+
+	if promote {
+		update.Role = &role
+	}
+
+	user, err = users.Update(ctx, userId, update)
+	if err != nil {
+		return nil, err
+	}
+
+	// the role is carried by the access tokens, the user has to log in again to get the new one
+	if promote {
+		if err := users.IncrementTokenVersion(ctx, userId); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+type VerificationHandlerInterface interface {
+	VerifyEmail(c *fiber.Ctx) interface{}
+	ResendVerification(c *fiber.Ctx) interface{}
+}
+
+type VerificationHandler struct {
+	Users  store.UserStore
+	Tokens store.OneTimeTokenStore
+	Mailer mailer.Mailer
+}
+
+// sendVerificationEmail emails a new verification link to the user, older links stop working.
+func sendVerificationEmail(ctx context.Context, tokens store.OneTimeTokenStore, m mailer.Mailer, user *models.User) error {
+	userId, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return err
+	}
+
+	ttl := utils.EmailVerificationTTL()
+	token, err := issueOneTimeToken(ctx, tokens, models.EmailVerificationToken, userId, ttl)
+
+	if err != nil {
+		return err
+	}
+
+	return m.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your gotter email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address using the link below, it expires in %s.\n\n%s/api/v1/verify-email?token=%s\n\nVerification token: %s\n",
+			user.UserName, ttl, utils.AppURL(), token, token,
+		),
+	})
+}
+
+/**
+ * @Route /verify-email?token=
+ * @Mothod GET
+ */
+func (v VerificationHandler) VerifyEmail(c *fiber.Ctx) {
+	token := c.Query("token")
+
+	if token == "" {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid Inputs"})
+		return
+	}
+
+	t, err := v.Tokens.Consume(c.Fasthttp, models.EmailVerificationToken, utils.HashToken(token))
+
+	if err == store.ErrNotFound {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Invalid or expired token"})
+		return
+	}
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if _, err := markVerified(c.Fasthttp, v.Users, t.User); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Email verified"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
+
+/**
+ * @Route /verify-email/resend
+ * @Mothod POST
+ * @Protected ✔️
+ */
+func (v VerificationHandler) ResendVerification(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	if user.Verified {
+		c.Status(fiber.StatusBadRequest).Send(fiber.Map{"message": "Email already verified"})
+		return
+	}
+
+	if err := sendVerificationEmail(c.Fasthttp, v.Tokens, v.Mailer, &user); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+
+	if err := c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Verification email sent"}); err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+}
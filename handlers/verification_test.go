@@ -0,0 +1,169 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/gofiber/fiber"
+	. "github.com/kiranbhalerao123/gotter/app"
+	. "github.com/kiranbhalerao123/gotter/handlers/testutils"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+func TestVerificationRoutes(t *testing.T) {
+	g := Goblin(t)
+
+	var app *fiber.App
+	var mails *bytes.Buffer
+
+	setup := func(requireVerifiedEmail bool) {
+		mails = new(bytes.Buffer)
+		app = SetupApp()
+		SetupRouter(app, Options{
+			Store:                store.NewMemory(),
+			Mailer:               mailer.NewLogMailer(mails, "test@gotter.local"),
+			RequireVerifiedEmail: requireVerifiedEmail,
+		})
+	}
+
+	// verificationToken returns the token of the last verification email
+	verificationToken := func() string {
+		matches := regexp.MustCompile(`Verification token: (\S+)`).FindAllStringSubmatch(mails.String(), -1)
+
+		if len(matches) == 0 {
+			return ""
+		}
+		return matches[len(matches)-1][1]
+	}
+
+	verify := func(token string) int {
+		req := MakeRequest(Req{
+			Method: "GET",
+			Target: "/api/v1/verify-email?token=" + token,
+		})
+
+		resp, _ := app.Test(req, -1)
+		return resp.StatusCode
+	}
+
+	signupAndLogin := func() string {
+		resp, inputs, _ := TSignup(app)
+		g.Assert(resp.StatusCode).Equal(201)
+
+		resp, login := TLogin(app, TLoginInputs{
+			Email:    inputs.Email,
+			Password: inputs.Password,
+		})
+		g.Assert(resp.StatusCode).Equal(200)
+
+		return login.Data.Token
+	}
+
+	g.Describe("Email Verification Routes Test", func() {
+		g.BeforeEach(func() {
+			setup(false)
+		})
+
+		g.It("returns 400 on unknown token", func() {
+			g.Assert(verify("not-a-token")).Equal(400)
+		})
+
+		g.It("verifies the email with the token sent at signup", func() {
+			token := signupAndLogin()
+
+			g.Assert(verify(verificationToken())).Equal(200)
+
+			// the token is single use
+			g.Assert(verify(verificationToken())).Equal(400)
+
+			req := MakeRequest(Req{
+				Method: "GET",
+				Target: "/api/v1/user",
+				Options: Opt{
+					Header: Map{"Authorization": "Bearer " + token},
+				},
+			})
+
+			resp, _ := app.Test(req, -1)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			var user struct {
+				Verified bool `json:"verified"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+				panic(err)
+			}
+			g.Assert(user.Verified).IsTrue()
+		})
+
+		g.It("resends a new token and invalidates the old one", func() {
+			token := signupAndLogin()
+			first := verificationToken()
+
+			resend := MakeRequest(Req{
+				Method: "POST",
+				Target: "/api/v1/verify-email/resend",
+				Options: Opt{
+					Header: Map{"Authorization": "Bearer " + token},
+				},
+			})
+
+			resp, _ := app.Test(resend, -1)
+			g.Assert(resp.StatusCode).Equal(200)
+
+			second := verificationToken()
+			g.Assert(first != second).IsTrue()
+
+			g.Assert(verify(first)).Equal(400)
+			g.Assert(verify(second)).Equal(200)
+
+			// nothing left to verify
+			resend = MakeRequest(Req{
+				Method: "POST",
+				Target: "/api/v1/verify-email/resend",
+				Options: Opt{
+					Header: Map{"Authorization": "Bearer " + token},
+				},
+			})
+
+			resp, _ = app.Test(resend, -1)
+			g.Assert(resp.StatusCode).Equal(400)
+		})
+
+		g.It("blocks posting until the email is verified when required", func() {
+			setup(true)
+			token := signupAndLogin()
+
+			resp, _, _ := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(403)
+
+			g.Assert(verify(verificationToken())).Equal(200)
+
+			resp, _, post := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			// nor reposting
+			other := TSignInputs{Email: "other@gotter.local", UserName: "other", Password: "password"}
+			resp, _, _ = TSignup(app, other)
+			g.Assert(resp.StatusCode).Equal(201)
+
+			resp, login := TLogin(app, TLoginInputs{Email: other.Email, Password: other.Password})
+			g.Assert(resp.StatusCode).Equal(200)
+
+			resp = TSend(app, "PUT", "/api/v1/post/"+post.ID+"/repost", login.Data.Token, nil)
+			g.Assert(resp.StatusCode).Equal(403)
+		})
+
+		g.It("allows posting unverified when not required", func() {
+			token := signupAndLogin()
+
+			resp, _, _ := TCreatePost(app, token)
+			g.Assert(resp.StatusCode).Equal(201)
+		})
+	})
+}
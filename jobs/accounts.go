@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PurgeDeletedAccounts deletes the accounts whose grace period ended before now and returns how many were deleted.
+func PurgeDeletedAccounts(ctx context.Context, s store.Store, blobs store.BlobStore, now time.Time) (int, error) {
+	users, err := s.Users.ListDeletionDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, user := range users {
+		userId, err := primitive.ObjectIDFromHex(user.ID)
+		if err != nil {
+			return i, err
+		}
+
+		if err := DeleteAccount(ctx, s, blobs, userId); err != nil {
+			return i, err
+		}
+	}
+	return len(users), nil
+}
+
+// DeleteAccount removes the user with everything they wrote and every trace of them in other documents.
+// What hangs off a document is deleted before it and the user goes last, so the documents a failure
+// leaves behind can still be found from the user and the next purge picks them up again.
+func DeleteAccount(ctx context.Context, s store.Store, blobs store.BlobStore, userId primitive.ObjectID) error {
+	// the comments on their posts and the reposts of them, then the posts
+	posts, err := s.Posts.ListByAuthor(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		postId, err := primitive.ObjectIDFromHex(post.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.Comments.DeleteByPost(ctx, postId); err != nil {
+			return err
+		}
+
+		reposts, err := s.Posts.DeleteReposts(ctx, postId)
+		if err != nil {
+			return err
+		}
+
+		for _, repost := range reposts {
+			repostId, _ := primitive.ObjectIDFromHex(repost.ID)
+			if authorId, err := primitive.ObjectIDFromHex(repost.Author.ID); err == nil {
+				if err := s.Users.RemovePost(ctx, authorId, repostId); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if _, err := s.Posts.DeleteByAuthor(ctx, userId); err != nil {
+		return err
+	}
+
+	// the files they uploaded, the attachments of their posts included
+	media, err := s.Media.DeleteByOwner(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteMediaFiles(ctx, blobs, media); err != nil {
+		return err
+	}
+
+	user, err := s.Users.FindByID(ctx, userId)
+	if err != nil && err != store.ErrNotFound {
+		return err
+	}
+
+	if err == nil {
+		if err := store.DeleteProfileImage(ctx, blobs, user.Avatar, "avatars/"+user.ID); err != nil {
+			return err
+		}
+		if err := store.DeleteProfileImage(ctx, blobs, user.Banner, "banners/"+user.ID); err != nil {
+			return err
+		}
+	}
+
+	// the conversations under their comments on the posts of others, then the comments
+	written, err := s.Comments.ListByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	comments := make([]primitive.ObjectID, 0, len(written))
+	for _, comment := range written {
+		commentId, err := primitive.ObjectIDFromHex(comment.ID)
+		if err != nil {
+			return err
+		}
+		comments = append(comments, commentId)
+	}
+
+	replies, err := s.Comments.DeleteReplies(ctx, comments)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Posts.RemoveComments(ctx, append(comments, replies...)); err != nil {
+		return err
+	}
+
+	if _, err := s.Comments.DeleteByUser(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := s.Posts.UnlikeAll(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := s.Comments.UnlikeAll(ctx, userId); err != nil {
+		return err
+	}
+
+	// and the ways to log in as them
+	if err := s.RefreshTokens.DeleteByUser(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := s.Sessions.DeleteByUser(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := s.APIKeys.DeleteByUser(ctx, userId); err != nil {
+		return err
+	}
+
+	for _, purpose := range []string{models.PasswordResetToken, models.EmailVerificationToken} {
+		if err := s.OneTimeTokens.DeleteUser(ctx, purpose, userId); err != nil {
+			return err
+		}
+	}
+
+	// their exports are removed with the archives on the next run of the exporter
+	if err := s.Exports.ExpireByUser(ctx, userId, time.Now()); err != nil {
+		return err
+	}
+
+	if err := s.Suggestions.Delete(ctx, userId); err != nil {
+		return err
+	}
+
+	err = s.Users.Delete(ctx, userId)
+	if err == store.ErrNotFound {
+		return nil
+	}
+	return err
+}
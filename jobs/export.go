@@ -0,0 +1,323 @@
+package jobs
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Exporter builds the archives of the pending personal data exports and removes the expired ones.
+type Exporter struct {
+	Store  store.Store
+	Mailer mailer.Mailer
+	// Dir is where the archives are kept until they expire
+	Dir string
+}
+
+// exportData is everything the archive holds about the user.
+type exportData struct {
+	Profile       models.User
+	Posts         []models.Post
+	Comments      []models.Comment
+	LikedPosts    []models.Post
+	LikedComments []models.Comment
+	Followers     []models.Author
+	Following     []models.Author
+	GeneratedAt   time.Time
+}
+
+// Run builds every pending export, an export that can't be built is marked failed
+// and the error of the last failure is returned once the others are done.
+func (e Exporter) Run(ctx context.Context) error {
+	now := time.Now()
+
+	if err := e.cleanup(ctx, now); err != nil {
+		return err
+	}
+
+	pending, err := e.Store.Exports.ListPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	var failure error
+	for _, export := range pending {
+		id, err := primitive.ObjectIDFromHex(export.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := e.build(ctx, id, export); err != nil {
+			failure = fmt.Errorf("export %s: %w", export.ID, err)
+
+			if err := e.Store.Exports.MarkFailed(ctx, id, "Couldn't build the archive"); err != nil {
+				return err
+			}
+		}
+	}
+	return failure
+}
+
+// cleanup removes the archives and the records of the exports that expired.
+func (e Exporter) cleanup(ctx context.Context, now time.Time) error {
+	expired, err := e.Store.Exports.ListExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range expired {
+		id, err := primitive.ObjectIDFromHex(export.ID)
+		if err != nil {
+			return err
+		}
+
+		if export.File != "" {
+			if err := os.Remove(export.File); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		if err := e.Store.Exports.Delete(ctx, id); err != nil && err != store.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e Exporter) build(ctx context.Context, id primitive.ObjectID, export models.Export) error {
+	data, err := e.collect(ctx, export.User)
+
+	// the account was deleted since, there's nothing left to export
+	if err == store.ErrNotFound {
+		return e.Store.Exports.MarkFailed(ctx, id, "The account doesn't exist anymore")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(e.Dir, 0700); err != nil {
+		return err
+	}
+
+	// written aside and renamed so a download never sees half an archive
+	file := filepath.Join(e.Dir, export.ID+".zip")
+	size, err := writeArchive(file+".tmp", data)
+	if err != nil {
+		os.Remove(file + ".tmp")
+		return err
+	}
+
+	if err := os.Rename(file+".tmp", file); err != nil {
+		return err
+	}
+
+	readyAt := time.Now()
+	expiresAt := readyAt.Add(utils.ExportTTL())
+
+	if err := e.Store.Exports.MarkReady(ctx, id, file, size, readyAt, expiresAt); err != nil {
+		return err
+	}
+
+	export.ExpiresAt = &expiresAt
+	link, err := DownloadURL(export)
+	if err != nil {
+		return err
+	}
+
+	// the archive stays available from GET /user/export when the email doesn't make it
+	if err := e.Mailer.Send(ctx, mailer.Message{
+		To:      data.Profile.Email,
+		Subject: "Your gotter data export is ready",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThe archive of your gotter data is ready, download it using the link below before %s.\n\n%s\n",
+			data.Profile.UserName, expiresAt.UTC().Format(time.RFC1123), link,
+		),
+	}); err != nil {
+		log.Printf("jobs: export %s: couldn't email the download link: %v", export.ID, err)
+	}
+	return nil
+}
+
+func (e Exporter) collect(ctx context.Context, userId primitive.ObjectID) (*exportData, error) {
+	user, err := e.Store.Users.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &exportData{Profile: *user, GeneratedAt: time.Now()}
+
+	if data.Posts, err = e.Store.Posts.ListByAuthor(ctx, userId); err != nil {
+		return nil, err
+	}
+	if data.Comments, err = e.Store.Comments.ListByUser(ctx, userId); err != nil {
+		return nil, err
+	}
+	if data.LikedPosts, err = e.Store.Posts.ListLikedBy(ctx, userId); err != nil {
+		return nil, err
+	}
+	if data.LikedComments, err = e.Store.Comments.ListLikedBy(ctx, userId); err != nil {
+		return nil, err
+	}
+	if data.Followers, err = e.authors(ctx, user.Followers); err != nil {
+		return nil, err
+	}
+	if data.Following, err = e.authors(ctx, user.Following); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// authors returns the public part of the accounts, other users' emails don't belong in the archive.
+func (e Exporter) authors(ctx context.Context, ids []primitive.ObjectID) ([]models.Author, error) {
+	users, err := e.Store.Users.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := []models.Author{}
+	for _, user := range users {
+		authors = append(authors, models.Author{ID: user.ID, UserName: user.UserName})
+	}
+	return authors, nil
+}
+
+// writeArchive writes the zip of the data and returns its size.
+func writeArchive(file string, data *exportData) (int64, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	archive := zip.NewWriter(f)
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"posts.json", data.Posts},
+		{"comments.json", data.Comments},
+		{"likes.json", map[string]interface{}{"posts": data.LikedPosts, "comments": data.LikedComments}},
+		{"followers.json", data.Followers},
+		{"following.json", data.Following},
+	}
+
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return 0, err
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.content); err != nil {
+			return 0, err
+		}
+	}
+
+	w, err := archive.Create("index.html")
+	if err != nil {
+		return 0, err
+	}
+	if err := exportIndex.Execute(w, data); err != nil {
+		return 0, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return 0, err
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return size, f.Close()
+}
+
+// DownloadURL returns the link the archive of the export is downloaded from, it expires with the export.
+func DownloadURL(export models.Export) (string, error) {
+	if export.ExpiresAt == nil {
+		return "", fmt.Errorf("export %s isn't ready", export.ID)
+	}
+
+	token, err := utils.CreateAccessToken(map[string]interface{}{
+		"typ": models.ExportDownloadToken,
+		"id":  export.ID,
+	}, time.Until(*export.ExpiresAt))
+
+	if err != nil {
+		return "", err
+	}
+	return utils.AppURL() + "/api/v1/user/export/download?token=" + token, nil
+}
+
+// exportIndex is the human readable page of the archive, the JSON files next to it hold the same data.
+var exportIndex = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>gotter data of @{{.Profile.UserName}}</title>
+</head>
+<body>
+<h1>gotter data of @{{.Profile.UserName}}</h1>
+<p>Generated on {{.GeneratedAt.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+
+<h2>Profile</h2>
+<dl>
+<dt>Username</dt><dd>{{.Profile.UserName}}</dd>
+<dt>Email</dt><dd>{{.Profile.Email}}</dd>
+<dt>Display name</dt><dd>{{.Profile.DisplayName}}</dd>
+<dt>Bio</dt><dd>{{.Profile.Bio}}</dd>
+<dt>Location</dt><dd>{{.Profile.Location}}</dd>
+<dt>Website</dt><dd>{{.Profile.Website}}</dd>
+<dt>Role</dt><dd>{{.Profile.Role}}</dd>
+</dl>
+
+<h2>Posts ({{len .Posts}})</h2>
+<ul>
+{{range .Posts}}<li><strong>{{.Title}}</strong> {{.Description}} <small>{{.CreatedAt.UTC.Format "2006-01-02"}}</small></li>
+{{end}}</ul>
+
+<h2>Comments ({{len .Comments}})</h2>
+<ul>
+{{range .Comments}}<li>{{.Message}} <small>{{.CreatedAt.UTC.Format "2006-01-02"}}</small></li>
+{{end}}</ul>
+
+<h2>Liked posts ({{len .LikedPosts}})</h2>
+<ul>
+{{range .LikedPosts}}<li><strong>{{.Title}}</strong> by @{{.Author.UserName}}</li>
+{{end}}</ul>
+
+<h2>Liked comments ({{len .LikedComments}})</h2>
+<ul>
+{{range .LikedComments}}<li>{{.Message}} by @{{.User.UserName}}</li>
+{{end}}</ul>
+
+<h2>Followers ({{len .Followers}})</h2>
+<ul>
+{{range .Followers}}<li>@{{.UserName}}</li>
+{{end}}</ul>
+
+<h2>Following ({{len .Following}})</h2>
+<ul>
+{{range .Following}}<li>@{{.UserName}}</li>
+{{end}}</ul>
+
+<p>The JSON files of this archive hold the same data.</p>
+</body>
+</html>
+`))
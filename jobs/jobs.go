@@ -0,0 +1,25 @@
+// Package jobs holds the work done in the background, outside of the requests.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Schedule runs the job every interval until ctx is done, failures are logged and retried on the next tick.
+func Schedule(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("jobs: %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+)
+
+// PurgeUnattachedMedia deletes the uploads created before the time that were never attached to a post,
+// with their files, and returns how many were deleted.
+func PurgeUnattachedMedia(ctx context.Context, s store.Store, blobs store.BlobStore, before time.Time) (int, error) {
+	media, err := s.Media.DeleteUnattached(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := deleteMediaFiles(ctx, blobs, media); err != nil {
+		return 0, err
+	}
+	return len(media), nil
+}
+
+// deleteMediaFiles removes the files of the deleted media, thumbnails included.
+func deleteMediaFiles(ctx context.Context, blobs store.BlobStore, media []models.Media) error {
+	for _, m := range media {
+		if err := blobs.Delete(ctx, m.Key); err != nil {
+			return err
+		}
+		if err := blobs.Delete(ctx, m.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
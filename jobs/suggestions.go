@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// activeWindow is how far back posting makes an account recently active.
+const activeWindow = 7 * 24 * time.Hour
+
+// Suggester builds the "who to follow" suggestions from the follow graph and the recent posts.
+// They're computed on the first request of a user, Run keeps them fresh afterwards.
+type Suggester struct {
+	Users       store.UserStore
+	Posts       store.PostStore
+	Suggestions store.SuggestionStore
+}
+
+// Run refreshes the suggestions older than utils.SuggestionsTTL.
+func (s Suggester) Run(ctx context.Context) error {
+	_, err := s.RefreshStale(ctx, time.Now())
+	return err
+}
+
+// RefreshStale refreshes the suggestions that are older than utils.SuggestionsTTL at now
+// and returns how many were refreshed.
+func (s Suggester) RefreshStale(ctx context.Context, now time.Time) (int, error) {
+	users, err := s.Suggestions.ListStale(ctx, now.Add(-utils.SuggestionsTTL()))
+	if err != nil {
+		return 0, err
+	}
+
+	for i, userId := range users {
+		_, err := s.Refresh(ctx, userId)
+
+		// the account is gone, so are its suggestions
+		if err == store.ErrNotFound {
+			err = s.Suggestions.Delete(ctx, userId)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(users), nil
+}
+
+// Refresh computes and caches the suggestions of the user.
+func (s Suggester) Refresh(ctx context.Context, userId primitive.ObjectID) (*models.Suggestions, error) {
+	user, err := s.Users.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.suggest(ctx, userId, *user)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := &models.Suggestions{User: userId, Users: users, UpdatedAt: time.Now()}
+
+	if err := s.Suggestions.Save(ctx, suggestions); err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+// suggest ranks the accounts followed by the accounts the user follows, the followers they didn't
+// follow back and the recent posters. The accounts already followed or asked, and the blocked ones
+// either way, are left out.
+func (s Suggester) suggest(ctx context.Context, userId primitive.ObjectID, user models.User) ([]models.Suggestion, error) {
+	excluded := map[primitive.ObjectID]bool{userId: true}
+	for _, ids := range [][]primitive.ObjectID{user.Following, user.Blocked} {
+		for _, id := range ids {
+			excluded[id] = true
+		}
+	}
+
+	candidates := map[primitive.ObjectID]*models.Suggestion{}
+	candidate := func(id primitive.ObjectID) *models.Suggestion {
+		if excluded[id] {
+			return nil
+		}
+		if _, ok := candidates[id]; !ok {
+			candidates[id] = &models.Suggestion{}
+		}
+		return candidates[id]
+	}
+
+	// friends of friends
+	following, err := s.Users.FindByIDs(ctx, user.Following)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range following {
+		for _, id := range f.Following {
+			if c := candidate(id); c != nil {
+				c.MutualCount++
+			}
+		}
+	}
+
+	for _, id := range user.Followers {
+		if c := candidate(id); c != nil {
+			c.FollowsYou = true
+		}
+	}
+
+	active, err := s.Posts.ActiveAuthors(ctx, time.Now().Add(-activeWindow), 100)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range active {
+		if c := candidate(id); c != nil {
+			c.RecentlyActive = true
+		}
+	}
+
+	ids := []primitive.ObjectID{}
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+
+	accounts, err := s.Users.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []models.Suggestion{}
+	for _, account := range accounts {
+		if account.Suspended || account.DeleteAt != nil ||
+			hasID(account.Blocked, userId) || hasID(account.FollowRequests, userId) {
+			continue
+		}
+
+		id, _ := primitive.ObjectIDFromHex(account.ID)
+		suggestion := *candidates[id]
+
+		suggestion.ID = account.ID
+		suggestion.UserName = account.UserName
+		suggestion.DisplayName = account.DisplayName
+		suggestion.Avatar = account.Avatar
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+
+		if score(a) != score(b) {
+			return score(a) > score(b)
+		}
+		if a.MutualCount != b.MutualCount {
+			return a.MutualCount > b.MutualCount
+		}
+		return a.UserName < b.UserName
+	})
+
+	if len(suggestions) > models.MaxSuggestions {
+		suggestions = suggestions[:models.MaxSuggestions]
+	}
+	return suggestions, nil
+}
+
+// score weighs the reasons of a suggestion, a mutual follow counts the most.
+func score(s models.Suggestion) int {
+	n := 3 * s.MutualCount
+	if s.FollowsYou {
+		n += 2
+	}
+	if s.RecentlyActive {
+		n++
+	}
+	return n
+}
+
+func hasID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
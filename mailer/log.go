@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// LogMailer doesn't deliver anything, it writes the messages to a writer (stdout, a file, a buffer in tests)
+// so the links they contain can be used locally.
+type LogMailer struct {
+	out  io.Writer
+	from string
+	mu   *sync.Mutex
+}
+
+func NewLogMailer(out io.Writer, from string) LogMailer {
+	return LogMailer{out: out, from: from, mu: &sync.Mutex{}}
+}
+
+func (m LogMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.out.Write(format(m.from, msg)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(m.out, "\r\n")
+	return err
+}
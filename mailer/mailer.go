@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends the transactional emails (password reset, email verification...).
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the mailer selected by MAIL_DRIVER:
+//   - smtp: SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and MAIL_FROM
+//   - file: appends every message to MAIL_FILE
+//   - log (default): prints every message to stdout
+func FromEnv() (Mailer, error) {
+	from := utils.GoDotEnvVariable("MAIL_FROM")
+	if from == "" {
+		from = "gotter <no-reply@gotter.local>"
+	}
+
+	switch utils.GoDotEnvVariable("MAIL_DRIVER") {
+	case "smtp":
+		return SMTPMailer{
+			Host:     utils.GoDotEnvVariable("SMTP_HOST"),
+			Port:     utils.GoDotEnvVariable("SMTP_PORT"),
+			Username: utils.GoDotEnvVariable("SMTP_USERNAME"),
+			Password: utils.GoDotEnvVariable("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "file":
+		f, err := os.OpenFile(utils.GoDotEnvVariable("MAIL_FILE"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return NewLogMailer(f, from), nil
+	default:
+		return NewLogMailer(os.Stdout, from), nil
+	}
+}
+
+// format renders the message as a plain text RFC 5322 email.
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return []byte(b.String())
+}
@@ -1,17 +1,83 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	. "github.com/kiranbhalerao123/gotter/app"
 	. "github.com/kiranbhalerao123/gotter/config"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/mailer"
+	"github.com/kiranbhalerao123/gotter/oidc"
 	. "github.com/kiranbhalerao123/gotter/router"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
 )
 
 func main() {
 	app := SetupApp()
-	SetupDB()
-	SetupRouter(app)
+
+	// STORE=memory runs the API without a database, everything is lost on restart
+	var s store.Store
+	if utils.GoDotEnvVariable("STORE") == "memory" {
+		s = store.NewMemory()
+	} else {
+		SetupDB()
+		s = store.NewMongo(Mongo.DB)
+
+		if err := store.EnsureIndexes(context.Background(), Mongo.DB); err != nil {
+			log.Fatal(err)
+		}
+
+		// share the failed login counters between instances
+		if utils.GoDotEnvVariable("LOGIN_ATTEMPTS_STORE") == "mongo" {
+			s.LoginAttempts = store.NewMongoLoginAttempts(Mongo.DB)
+		}
+	}
+
+	m, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	providers, err := oidc.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blobs, err := store.BlobsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	SetupRouter(app, Options{
+		Store:                s,
+		Mailer:               m,
+		RequireVerifiedEmail: utils.GoDotEnvVariable("REQUIRE_VERIFIED_EMAIL") == "true",
+		Providers:            providers,
+		Blobs:                blobs,
+	})
+
+	// deleted accounts are purged once their grace period is over
+	go jobs.Schedule(context.Background(), "purge deleted accounts", time.Hour, func(ctx context.Context) error {
+		_, err := jobs.PurgeDeletedAccounts(ctx, s, blobs, time.Now())
+		return err
+	})
+
+	// the uploads never attached to a post are deleted once they're older than MEDIA_UNATTACHED_TTL
+	go jobs.Schedule(context.Background(), "purge unattached media", time.Hour, func(ctx context.Context) error {
+		_, err := jobs.PurgeUnattachedMedia(ctx, s, blobs, time.Now().Add(-utils.MediaUnattachedTTL()))
+		return err
+	})
+
+	// personal data exports are built in the background and emailed
+	exporter := jobs.Exporter{Store: s, Mailer: m, Dir: utils.ExportDir()}
+	go jobs.Schedule(context.Background(), "build data exports", time.Minute, exporter.Run)
+
+	// the follow suggestions are cached per user and refreshed once they're older than SUGGESTIONS_TTL
+	suggester := jobs.Suggester{Users: s.Users, Posts: s.Posts, Suggestions: s.Suggestions}
+	go jobs.Schedule(context.Background(), "refresh follow suggestions", 10*time.Minute, suggester.Run)
 
 	if err := app.Listen(3000); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,71 @@
+package media
+
+import "encoding/binary"
+
+// MaxFrames bounds the frames of an animation, each one is decoded in full.
+const MaxFrames = 1000
+
+// gifFrames walks the blocks of a GIF without decoding them and returns how many frames it has
+// and their total number of pixels, a small file can hold thousands of frames of the full canvas.
+// ok is false when the file isn't a well formed GIF.
+func gifFrames(data []byte) (frames, pixels int, ok bool) {
+	// header and logical screen descriptor
+	if len(data) < 13 || string(data[:3]) != "GIF" {
+		return 0, 0, false
+	}
+
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // extension: label and sub-blocks
+			if i+2 > len(data) {
+				return 0, 0, false
+			}
+			if i = skipSubBlocks(data, i+2); i < 0 {
+				return 0, 0, false
+			}
+
+		case 0x2C: // image descriptor, local color table, LZW code size and sub-blocks
+			if i+11 > len(data) {
+				return 0, 0, false
+			}
+			frames++
+			pixels += int(binary.LittleEndian.Uint16(data[i+5:])) * int(binary.LittleEndian.Uint16(data[i+7:]))
+
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			if i = skipSubBlocks(data, i+1); i < 0 {
+				return 0, 0, false
+			}
+
+		case 0x3B: // trailer
+			return frames, pixels, true
+
+		default:
+			return 0, 0, false
+		}
+	}
+
+	// the decoder tolerates a missing trailer
+	return frames, pixels, true
+}
+
+// skipSubBlocks returns the index after the sub-blocks starting at i, -1 when they're cut short.
+func skipSubBlocks(data []byte, i int) int {
+	for i < len(data) {
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
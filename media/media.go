@@ -0,0 +1,198 @@
+// Package media checks and normalizes the images users upload, with the standard library only:
+// the type is sniffed from the content, the image is decoded and encoded again, which drops
+// the EXIF metadata (location, camera...), and a thumbnail is generated.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	// ErrUnsupported is returned for anything that isn't a JPEG, PNG or GIF image.
+	ErrUnsupported = errors.New("media: unsupported file type")
+	// ErrTooLarge is returned when the image, or all the frames of an animation, have more pixels
+	// than MaxPixels, or the animation has more frames than MaxFrames.
+	ErrTooLarge = errors.New("media: image dimensions too large")
+)
+
+// MaxPixels bounds the size of the decoded images, a small file can claim huge dimensions.
+const MaxPixels = 40 * 1000 * 1000
+
+// Types are the content types that can be uploaded and their file extension.
+var Types = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type Options struct {
+	// MaxSide scales the image down to fit in a MaxSide square, 0 keeps its size
+	MaxSide int
+	// Square crops the center square of the image (avatars)
+	Square bool
+	// Still flattens animations to their first frame
+	Still bool
+	// ThumbSide is the size of the square the thumbnail fits in
+	ThumbSide int
+}
+
+// Image is an uploaded image ready to be stored.
+type Image struct {
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+	// Blurhash is the placeholder shown while the image loads
+	Blurhash string
+
+	ThumbnailType string
+	Thumbnail     []byte
+}
+
+// Ext is the file extension of the image.
+func (i Image) Ext() string {
+	return Types[i.ContentType]
+}
+
+// ThumbnailExt is the file extension of the thumbnail.
+func (i Image) ThumbnailExt() string {
+	return Types[i.ThumbnailType]
+}
+
+// Sniff returns the content type of the data, the name and type the client sent aren't trusted.
+func Sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// Process checks the uploaded data is an image we accept and returns it without its metadata,
+// upright and scaled to the options, with its thumbnail.
+func Process(data []byte, opts Options) (*Image, error) {
+	contentType := Sniff(data)
+	if _, ok := Types[contentType]; !ok {
+		return nil, ErrUnsupported
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	// animations are kept as they are, without the comments and extensions of the file
+	if contentType == "image/gif" && !opts.Still {
+		return processGIF(data, opts)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	img := toNRGBA(src)
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	if opts.Square {
+		img = cropSquare(img)
+	}
+	img = fit(img, opts.MaxSide)
+
+	// GIFs flattened to a frame become PNGs
+	out := &Image{
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Blurhash:    placeholder(img),
+	}
+	if contentType == "image/gif" {
+		out.ContentType = "image/png"
+	}
+
+	if out.Data, err = encode(img, out.ContentType); err != nil {
+		return nil, err
+	}
+
+	out.ThumbnailType = out.ContentType
+	if out.Thumbnail, err = encode(fit(img, opts.ThumbSide), out.ThumbnailType); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func processGIF(data []byte, opts Options) (*Image, error) {
+	// every frame is decoded, they're counted first
+	frames, pixels, ok := gifFrames(data)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	if frames > MaxFrames || pixels > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(anim.Image) == 0 {
+		return nil, ErrUnsupported
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:     anim.Image,
+		Delay:     anim.Delay,
+		LoopCount: anim.LoopCount,
+		Disposal:  anim.Disposal,
+		Config:    anim.Config,
+	}); err != nil {
+		return nil, err
+	}
+
+	// the first frame drawn on the canvas of the animation
+	first := image.NewNRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+	draw.Draw(first, anim.Image[0].Bounds(), anim.Image[0], anim.Image[0].Bounds().Min, draw.Over)
+
+	thumbnail, err := encode(fit(first, opts.ThumbSide), "image/png")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Image{
+		ContentType:   "image/gif",
+		Data:          buf.Bytes(),
+		Width:         anim.Config.Width,
+		Height:        anim.Config.Height,
+		Blurhash:      placeholder(first),
+		ThumbnailType: "image/png",
+		Thumbnail:     thumbnail,
+	}, nil
+}
+
+// placeholder returns the blurhash of the image with 4×3 components, computed on a 32px version of it.
+func placeholder(img *image.NRGBA) string {
+	return blurhash(fit(img, 32), 4, 3)
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	default:
+		err = png.Encode(&buf, img)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
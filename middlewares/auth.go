@@ -2,63 +2,301 @@ package middlewares
 
 import (
 	"encoding/json"
-	"log"
+	"strings"
+	"time"
 
-	jwToken "github.com/dgrijalva/jwt-go"
+	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/gofiber/fiber"
-	jwt "github.com/gofiber/jwt"
 	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/store"
 	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var secret string
-var WithGuard func(*fiber.Ctx)
+// Guard protects the routes, WithGuard checks the JWT signature (see utils.LoadJWTKeys) and expiry
+// and WithUser checks the token wasn't revoked and loads the user.
+// Routes using WithScope instead of WithGuard also accept personal API keys.
+type Guard struct {
+	Users       store.UserStore
+	Revocations store.RevocationStore
+	APIKeys     store.APIKeyStore
+	Sessions    store.SessionStore
+	// RequireVerifiedEmail turns WithVerifiedEmail on
+	RequireVerifiedEmail bool
+}
+
+func NewGuard(s store.Store) Guard {
+	// fail on startup rather than on the first request when the keys are misconfigured
+	if _, err := utils.JWTKeys(); err != nil {
+		panic(err.Error())
+	}
+
+	return Guard{
+		Users:       s.Users,
+		Revocations: s.Revocations,
+		APIKeys:     s.APIKeys,
+		Sessions:    s.Sessions,
+	}
+}
 
-func init() {
-	secret = utils.GoDotEnvVariable("JWT_SECRET")
+func (g Guard) WithGuard(c *fiber.Ctx) {
+	auth := c.Get(fiber.HeaderAuthorization)
 
-	if secret == "" {
-		panic("JWT_SECRET not provided")
+	if !strings.HasPrefix(auth, "Bearer ") || len(auth) == len("Bearer ") {
+		unauthorized(c, "Missing or malformed JWT")
+		return
 	}
 
-	WithGuard = jwt.New(jwt.Config{
-		SigningKey:   []byte(secret),
-		ErrorHandler: jwtError,
-		ContextKey:   "payload",
-	})
+	claims, err := utils.ParseJWTToken(strings.TrimPrefix(auth, "Bearer "))
+	if err != nil {
+		unauthorized(c, "Invalid or expired JWT")
+		return
+	}
+
+	c.Locals("payload", claims)
+	c.Next()
+}
+
+// WithScope works like WithGuard but also accepts the API keys having the scope,
+// sent as the Bearer token or in the X-API-Key header.
+func (g Guard) WithScope(scope string) func(*fiber.Ctx) {
+	return func(c *fiber.Ctx) {
+		key := apiKeyFromRequest(c)
+
+		if key == "" {
+			g.WithGuard(c)
+			return
+		}
+
+		apiKey, err := g.APIKeys.FindByHash(c.Fasthttp, utils.HashToken(key))
+
+		if err != nil && err != store.ErrNotFound {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return
+		}
+
+		if err == store.ErrNotFound || apiKey.Revoked {
+			unauthorized(c, "Invalid or revoked API key")
+			return
+		}
+
+		if !apiKey.HasScope(scope) {
+			forbidden(c, "API key is missing the "+scope+" scope")
+			return
+		}
+
+		// only record the usage once in a while, not on every request
+		now := time.Now()
+		if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > time.Minute {
+			keyId, _ := primitive.ObjectIDFromHex(apiKey.ID)
+
+			if err := g.APIKeys.Touch(c.Fasthttp, keyId, now); err != nil {
+				c.Status(fiber.StatusInternalServerError).Send(err)
+				return
+			}
+		}
+
+		c.Locals("apiKey", *apiKey)
+		c.Next()
+	}
 }
 
-func WithUser(c *fiber.Ctx) {
-	payload := c.Locals("payload").(*jwToken.Token)
+func (g Guard) WithUser(c *fiber.Ctx) {
+	if apiKey, ok := c.Locals("apiKey").(models.APIKey); ok {
+		g.withAPIKeyUser(c, apiKey)
+		return
+	}
+
+	payload := c.Locals("payload").(jwt.MapClaims)
 
 	userPayload := models.User{}
+	claims := models.TokenClaims{}
+
+	p, err := json.Marshal(payload)
+
+	if err != nil {
+		unauthorized(c, "Invalid or expired JWT")
+		return
+	}
+
+	if json.Unmarshal(p, &userPayload) != nil || json.Unmarshal(p, &claims) != nil {
+		unauthorized(c, "Invalid or expired JWT")
+		return
+	}
+
+	// neither a half finished 2FA login nor the other tokens signed with the same keys are a session
+	if claims.Type != models.AccessToken || claims.Audience != utils.AccessTokenAudience() {
+		unauthorized(c, "Invalid or expired JWT")
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(userPayload.ID)
+	if err != nil {
+		unauthorized(c, "Invalid or expired JWT")
+		return
+	}
+
+	// a token without jti couldn't be logged out, the ones issued before logout existed need a new login
+	if claims.ID == "" {
+		unauthorized(c, "Invalid or expired JWT")
+		return
+	}
+
+	// the token might have been logged out
+	revoked, err := g.Revocations.IsRevoked(c.Fasthttp, claims.ID)
+
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return
+	}
+	if revoked {
+		unauthorized(c, "Token has been revoked")
+		return
+	}
+
+	user, err := g.Users.FindByID(c.Fasthttp, userId)
+	if err != nil {
+		unauthorized(c, "Invalid or expired JWT")
+		return
+	}
+
+	// or the user might have logged out of every session
+	if user.TokenVersion != claims.Version {
+		unauthorized(c, "Token has been revoked")
+		return
+	}
+
+	if user.Suspended {
+		forbidden(c, "Account suspended")
+		return
+	}
+
+	// or the session might have been revoked from another device
+	if claims.Session != "" && !g.checkSession(c, claims.Session) {
+		return
+	}
+
+	c.Locals("user", *user)
+	c.Locals("claims", claims)
+	c.Next()
+}
+
+// checkSession rejects the tokens of revoked sessions and records when the session was last seen.
+func (g Guard) checkSession(c *fiber.Ctx, id string) bool {
+	sessionId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		unauthorized(c, "Invalid or expired JWT")
+		return false
+	}
+
+	session, err := g.Sessions.FindByID(c.Fasthttp, sessionId)
 
-	p, err := json.Marshal(payload.Claims.(jwToken.MapClaims))
+	// tokens issued before sessions were tracked have none
+	if err == store.ErrNotFound {
+		return true
+	}
 
 	if err != nil {
-		log.Fatal(err)
+		c.Status(fiber.StatusInternalServerError).Send(err)
+		return false
+	}
+
+	if session.Revoked {
+		unauthorized(c, "Session has been revoked")
+		return false
 	}
 
-	err = json.Unmarshal(p, &userPayload)
+	// only record the activity once in a while, not on every request
+	now := time.Now()
+	if now.Sub(session.LastSeen) > time.Minute || session.IP != c.IP() {
+		if err := g.Sessions.Touch(c.Fasthttp, sessionId, now, c.IP()); err != nil {
+			c.Status(fiber.StatusInternalServerError).Send(err)
+			return false
+		}
+	}
+	return true
+}
 
+// withAPIKeyUser loads the owner of the API key, the claims only carry the role
+// since API keys aren't sessions and are revoked on their own.
+func (g Guard) withAPIKeyUser(c *fiber.Ctx, apiKey models.APIKey) {
+	user, err := g.Users.FindByID(c.Fasthttp, apiKey.User)
 	if err != nil {
-		log.Fatal(err)
+		unauthorized(c, "Invalid or revoked API key")
+		return
+	}
+
+	if user.Suspended {
+		forbidden(c, "Account suspended")
+		return
+	}
+
+	c.Locals("user", *user)
+	c.Locals("claims", models.TokenClaims{Role: user.Role})
+	c.Next()
+}
+
+// apiKeyFromRequest returns the API key sent with the request, if any.
+func apiKeyFromRequest(c *fiber.Ctx) string {
+	if key := c.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	auth := c.Get(fiber.HeaderAuthorization)
+	if strings.HasPrefix(auth, "Bearer "+models.APIKeyPrefix) {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// WithVerifiedEmail rejects users who didn't verify their email yet, when the policy is on.
+// It runs after WithUser.
+func (g Guard) WithVerifiedEmail(c *fiber.Ctx) {
+	user := c.Locals("user").(models.User)
+
+	if g.RequireVerifiedEmail && !user.Verified {
+		forbidden(c, "Email address is not verified")
+		return
 	}
 
-	c.Locals("user", userPayload)
 	c.Next()
 }
 
-func jwtError(c *fiber.Ctx, err error) {
-	if err.Error() == "Missing or malformed JWT" {
-		if err := c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Missing or malformed JWT", "data": nil}); err != nil {
-			c.Status(500).Send(err)
+// Optional runs the middleware only when the request carries a token or an API key,
+// anonymous requests go through without a user.
+func (g Guard) Optional(middleware func(*fiber.Ctx)) func(*fiber.Ctx) {
+	return func(c *fiber.Ctx) {
+		if c.Get(fiber.HeaderAuthorization) == "" && c.Get("X-API-Key") == "" {
+			c.Next()
 			return
 		}
-	} else {
-		if err := c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid or expired JWT", "data": nil}); err != nil {
-			c.Status(500).Send(err)
+		middleware(c)
+	}
+}
+
+// RequireRole only lets through users having one of the roles, the role comes from the JWT claims
+// (changing it bumps the token version so it's never stale). It runs after WithUser.
+func RequireRole(roles ...string) func(*fiber.Ctx) {
+	return func(c *fiber.Ctx) {
+		claims := c.Locals("claims").(models.TokenClaims)
+
+		if !(models.User{Role: claims.Role}).HasRole(roles...) {
+			forbidden(c, "Insufficient role")
 			return
 		}
+
+		c.Next()
+	}
+}
+
+func unauthorized(c *fiber.Ctx, message string) {
+	if err := c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": message, "data": nil}); err != nil {
+		c.Status(500).Send(err)
+	}
+}
+
+func forbidden(c *fiber.Ctx, message string) {
+	if err := c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": message, "data": nil}); err != nil {
+		c.Status(500).Send(err)
 	}
 }
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopes an API key can be given, a key only opens the routes requiring one of its scopes.
+const (
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsWrite = "comments:write"
+	ScopeTimelineRead  = "timeline:read"
+	ScopeUserRead      = "user:read"
+	ScopeUserWrite     = "user:write"
+)
+
+var Scopes = []string{ScopePostsWrite, ScopeCommentsWrite, ScopeTimelineRead, ScopeUserRead, ScopeUserWrite}
+
+// APIKeyPrefix starts every API key so the guard can tell them from JWTs.
+const APIKeyPrefix = "gtr_"
+
+// APIKey is a personal access key for bots and scripts, only the hash of the key is stored.
+type APIKey struct {
+	ID   string             `json:"id,omitempty" bson:"_id,omitempty"`
+	Name string             `json:"name" bson:"name"`
+	Hash string             `json:"-" bson:"hash"`
+	User primitive.ObjectID `json:"user" bson:"user"`
+	// Hint is the end of the key, shown so users can tell their keys apart
+	Hint       string     `json:"hint" bson:"hint"`
+	Scopes     []string   `json:"scopes" bson:"scopes"`
+	Revoked    bool       `json:"revoked" bson:"revoked"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt" bson:"lastUsedAt"`
+}
+
+// HasScope tells if the key was given the scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyInputs struct {
+	Name   string   `json:"name" bson:"name" valid:"length(1|50),required"`
+	Scopes []string `json:"scopes" bson:"scopes"`
+}
+
+func (i APIKeyInputs) Validate() error {
+	if err := utils.Validator(i); err != nil {
+		return err
+	}
+
+	if len(i.Scopes) == 0 {
+		return fmt.Errorf("scopes: at least one scope is required")
+	}
+
+	for _, scope := range i.Scopes {
+		if !(APIKey{Scopes: Scopes}).HasScope(scope) {
+			return fmt.Errorf("scopes: unknown scope %q", scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginAttempts counts the consecutive failed logins of an account or an IP address,
+// Key is "account:<email>" or "ip:<address>".
+type LoginAttempts struct {
+	Key         string    `json:"key" bson:"_id"`
+	Failures    int       `json:"failures" bson:"failures"`
+	LastFailure time.Time `json:"lastFailure" bson:"lastFailure"`
+}
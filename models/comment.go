@@ -13,4 +13,16 @@ type Comment struct {
 	User      Author               `json:"user" bson:"user"`
 	CreatedAt time.Time            `json:"createdAt"`
 	Likes     []primitive.ObjectID `json:"likes" bson:"likes"`
+	// Parent is the comment this one replies to, nil for the comments on the post itself
+	Parent *primitive.ObjectID `json:"parent,omitempty" bson:"parent,omitempty"`
+	// ReplyCount is the number of direct replies
+	ReplyCount int `json:"replyCount" bson:"replyCount"`
+	// Mentions are the @usernames of the message that link to a user
+	Mentions []Mention `json:"mentions,omitempty" bson:"mentions,omitempty"`
+}
+
+// CommentThread is a comment with a page of its replies, GET /post/:id/thread nests them up to the requested depth.
+type CommentThread struct {
+	Comment
+	Replies []CommentThread `json:"replies"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// ExportDownloadToken is the Type of the token in the download link of an export,
+// it expires with the export.
+const ExportDownloadToken = "export_download"
+
+// Export is a request for an archive of everything we hold about a user,
+// the archive is built in the background and kept until ExpiresAt.
+type Export struct {
+	ID        string             `json:"id,omitempty" bson:"_id,omitempty"`
+	User      primitive.ObjectID `json:"user" bson:"user"`
+	Status    string             `json:"status" bson:"status"`
+	Error     string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	ReadyAt   *time.Time         `json:"readyAt,omitempty" bson:"readyAt,omitempty"`
+	ExpiresAt *time.Time         `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	// File is the path of the archive on the server
+	File string `json:"-" bson:"file,omitempty"`
+	Size int64  `json:"size,omitempty" bson:"size,omitempty"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"unicode/utf8"
+
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Media is an image uploaded to be attached to a post, the files are kept in the blob store.
+type Media struct {
+	ID           string             `json:"id,omitempty" bson:"_id,omitempty"`
+	Owner        primitive.ObjectID `json:"owner" bson:"owner"`
+	ContentType  string             `json:"contentType" bson:"contentType"`
+	URL          string             `json:"url" bson:"url"`
+	ThumbnailURL string             `json:"thumbnailUrl" bson:"thumbnailUrl"`
+	Width        int                `json:"width" bson:"width"`
+	Height       int                `json:"height" bson:"height"`
+	Size         int                `json:"size" bson:"size"`
+	Alt          string             `json:"alt" bson:"alt"`
+	Blurhash     string             `json:"blurhash" bson:"blurhash"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+	// Post is set once the media is attached to a post, it can't be attached to another one
+	Post *primitive.ObjectID `json:"post,omitempty" bson:"post,omitempty"`
+	// Key and ThumbnailKey are where the files are in the blob store
+	Key          string `json:"-" bson:"key"`
+	ThumbnailKey string `json:"-" bson:"thumbnailKey"`
+}
+
+const (
+	// MaxAttachments is how many media a post can carry
+	MaxAttachments = 4
+	// MaxAltLength is the longest alt text in characters
+	MaxAltLength = 1000
+)
+
+// Attachment is a media shown with a post, it's copied from the upload so timelines don't need a join.
+type Attachment struct {
+	ID           string `json:"id" bson:"_id"`
+	ContentType  string `json:"contentType" bson:"contentType"`
+	URL          string `json:"url" bson:"url"`
+	ThumbnailURL string `json:"thumbnailUrl" bson:"thumbnailUrl"`
+	Width        int    `json:"width" bson:"width"`
+	Height       int    `json:"height" bson:"height"`
+	Alt          string `json:"alt" bson:"alt"`
+	Blurhash     string `json:"blurhash" bson:"blurhash"`
+}
+
+// NewAttachment returns the attachment of the media, alt replaces the alt text of the upload when not empty.
+func NewAttachment(m Media, alt string) Attachment {
+	if alt == "" {
+		alt = m.Alt
+	}
+
+	return Attachment{
+		ID:           m.ID,
+		ContentType:  m.ContentType,
+		URL:          m.URL,
+		ThumbnailURL: m.ThumbnailURL,
+		Width:        m.Width,
+		Height:       m.Height,
+		Alt:          alt,
+		Blurhash:     m.Blurhash,
+	}
+}
+
+// AttachmentInput picks an uploaded media for a post.
+type AttachmentInput struct {
+	ID  string `json:"id" bson:"id"`
+	Alt string `json:"alt" bson:"alt"`
+}
+
+// ValidAlt tells if the alt text isn't too long.
+func ValidAlt(alt string) bool {
+	return utf8.RuneCountInString(alt) <= MaxAltLength
+}
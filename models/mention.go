@@ -0,0 +1,43 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Mention links an @username of a text to the user, Start and End are the character offsets of the mention
+// in its Field ("title", "description" or "message"), the @ included and End excluded.
+type Mention struct {
+	User     primitive.ObjectID `json:"user" bson:"user"`
+	UserName string             `json:"username" bson:"username"`
+	Field    string             `json:"field" bson:"field"`
+	Start    int                `json:"start" bson:"start"`
+	End      int                `json:"end" bson:"end"`
+}
+
+// ParseMentions returns the @usernames of the text in order of appearance, their User is left to resolve.
+// Like the hashtags an @ only starts a mention when it doesn't follow a letter, a digit or an underscore,
+// so the emails aren't mentions.
+func ParseMentions(field, text string) []Mention {
+	var mentions []Mention
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '@')) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+
+		if end > i+1 {
+			mentions = append(mentions, Mention{
+				UserName: string(runes[i+1 : end]),
+				Field:    field,
+				Start:    i,
+				End:      end,
+			})
+		}
+		i = end - 1
+	}
+	return mentions
+}
@@ -10,8 +10,14 @@ import (
 type PostInput struct {
 	Title       string `json:"title" bson:"title" valid:"length(3|30)"`
 	Description string `json:"description" bson:"description" valid:"length(3|300)"`
+	// Media are uploads of /media, at most MaxAttachments
+	Media []AttachmentInput `json:"media" bson:"media"`
+	// Quote is the ID of the post quoted, blank for a standalone post
+	Quote string `json:"quote" bson:"-"`
 }
 
+// Post is also the timeline entry of a repost, which has no content and sets RepostOf,
+// a quote post is a post of its own that sets QuoteOf.
 type Post struct {
 	ID          string               `json:"id,omitempty" bson:"_id,omitempty"`
 	Title       string               `json:"title" bson:"title"`
@@ -21,6 +27,23 @@ type Post struct {
 	Author      Author               `json:"author" bson:"author"`
 	Comments    []primitive.ObjectID `json:"comments" bson:"comments"`
 	Likes       []primitive.ObjectID `json:"likes" bson:"likes"`
+	Media       []Attachment         `json:"media,omitempty" bson:"media,omitempty"`
+	RepostOf    *primitive.ObjectID  `json:"repostOf,omitempty" bson:"repostOf,omitempty"`
+	QuoteOf     *primitive.ObjectID  `json:"quoteOf,omitempty" bson:"quoteOf,omitempty"`
+	RepostCount int                  `json:"repostCount" bson:"repostCount"`
+	QuoteCount  int                  `json:"quoteCount" bson:"quoteCount"`
+	// Tags are the normalized #hashtags of the title and description
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// Mentions are the @usernames of the title and description that link to a user
+	Mentions []Mention `json:"mentions,omitempty" bson:"mentions,omitempty"`
+}
+
+// Shared returns the ID of the post reposted or quoted, nil for a standalone post.
+func (p Post) Shared() *primitive.ObjectID {
+	if p.RepostOf != nil {
+		return p.RepostOf
+	}
+	return p.QuoteOf
 }
 
 type PostWithComment struct {
@@ -32,6 +55,15 @@ type PostWithComment struct {
 	Author      Author               `json:"author" bson:"author"`
 	Comments    []Comment            `json:"comments" bson:"comments"`
 	Likes       []primitive.ObjectID `json:"likes" bson:"likes"`
+	Media       []Attachment         `json:"media,omitempty" bson:"media,omitempty"`
+	RepostOf    *primitive.ObjectID  `json:"repostOf,omitempty" bson:"repostOf,omitempty"`
+	QuoteOf     *primitive.ObjectID  `json:"quoteOf,omitempty" bson:"quoteOf,omitempty"`
+	RepostCount int                  `json:"repostCount" bson:"repostCount"`
+	QuoteCount  int                  `json:"quoteCount" bson:"quoteCount"`
+	Tags        []string             `json:"tags,omitempty" bson:"tags,omitempty"`
+	Mentions    []Mention            `json:"mentions,omitempty" bson:"mentions,omitempty"`
+	// Original is the post reposted or quoted, missing once it was deleted
+	Original *Post `json:"original,omitempty" bson:"original,omitempty"`
 }
 
 func (i PostInput) Validate() error {
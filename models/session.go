@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is a login on a device, its ID is the refresh token family and the sid claim of its access tokens.
+type Session struct {
+	ID        string             `json:"id,omitempty" bson:"_id,omitempty"`
+	User      primitive.ObjectID `json:"user" bson:"user"`
+	Device    string             `json:"device" bson:"device"`
+	IP        string             `json:"ip" bson:"ip"`
+	UserAgent string             `json:"userAgent" bson:"userAgent"`
+	Revoked   bool               `json:"revoked" bson:"revoked"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	LastSeen  time.Time          `json:"lastSeen" bson:"lastSeen"`
+	// Current is set on the session the listing was requested from
+	Current bool `json:"current" bson:"-"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxSuggestions is how many accounts the follow suggestions of a user keep.
+const MaxSuggestions = 20
+
+// Suggestion is an account the user might want to follow with the reasons it was picked.
+type Suggestion struct {
+	ID          string `json:"id" bson:"id"`
+	UserName    string `json:"username" bson:"username"`
+	DisplayName string `json:"displayName" bson:"displayName"`
+	Avatar      string `json:"avatar" bson:"avatar"`
+	// MutualCount is how many of the accounts the user follows follow this one
+	MutualCount    int  `json:"mutualCount" bson:"mutualCount"`
+	FollowsYou     bool `json:"followsYou" bson:"followsYou"`
+	RecentlyActive bool `json:"recentlyActive" bson:"recentlyActive"`
+}
+
+// Suggestions are the cached follow suggestions of a user, the best first.
+type Suggestions struct {
+	User      primitive.ObjectID `json:"-" bson:"_id"`
+	Users     []Suggestion       `json:"users" bson:"users"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the longest hashtag kept, in characters, longer ones stay plain text.
+const MaxTagLength = 64
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// NormalizeTag strips the leading # and lowercases the tag, it returns "" when it isn't a valid tag:
+// tags are made of letters, digits and underscores and aren't only digits.
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return ""
+	}
+
+	digits := true
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return ""
+		}
+		if !unicode.IsDigit(r) {
+			digits = false
+		}
+	}
+
+	if digits {
+		return ""
+	}
+	return tag
+}
+
+// ParseTags returns the normalized #hashtags of the texts in order of appearance, without duplicates.
+// A # only starts a tag at the beginning of the text or after a character that can't be part of one,
+// so neither "a#b" nor "&#39;" are tags.
+func ParseTags(texts ...string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	for _, text := range texts {
+		runes := []rune(text)
+
+		for i := 0; i < len(runes); i++ {
+			if runes[i] != '#' || (i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '&' || runes[i-1] == '#')) {
+				continue
+			}
+
+			end := i + 1
+			for end < len(runes) && isTagRune(runes[end]) {
+				end++
+			}
+
+			tag := NormalizeTag(string(runes[i+1 : end]))
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+			i = end - 1
+		}
+	}
+	return tags
+}
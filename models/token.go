@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is the server side record of a refresh token, only the hash of the token is stored.
+// Every token issued by rotating another one shares its Family, so a replayed token can revoke them all.
+type RefreshToken struct {
+	ID        string             `json:"id,omitempty" bson:"_id,omitempty"`
+	Hash      string             `json:"-" bson:"hash"`
+	Family    string             `json:"family" bson:"family"`
+	User      primitive.ObjectID `json:"user" bson:"user"`
+	Used      bool               `json:"used" bson:"used"`
+	Revoked   bool               `json:"revoked" bson:"revoked"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiresAt time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
+
+type RefreshInputs struct {
+	RefreshToken string `json:"refreshToken" bson:"refreshToken"`
+}
+
+// TokenClaims are the registered claims of an access token the guard checks besides the user ones.
+type TokenClaims struct {
+	ID        string `json:"jti"`
+	Session   string `json:"sid"`
+	Version   int    `json:"tv"`
+	Role      string `json:"role"`
+	ExpiresAt int64  `json:"exp"`
+	// Type is AccessToken and Audience the API, the other tokens signed with the same keys don't grant access
+	Type     string `json:"typ,omitempty"`
+	Audience string `json:"aud,omitempty"`
+}
+
+// AccessToken is the Type of the tokens returned by /login and /refresh, the only ones the guard accepts.
+const AccessToken = "access"
+
+// MFAPendingToken is the Type of the token /login returns when the user still has to enter a TOTP code,
+// it's only accepted by /login/mfa.
+const MFAPendingToken = "mfa_pending"
+
+// OAuthStateToken is the Type of the cookie holding the state, nonce and PKCE verifier of a social login
+// between /oauth/:provider/login and its callback.
+const OAuthStateToken = "oauth_state"
+
+const (
+	PasswordResetToken     = "password_reset"
+	EmailVerificationToken = "email_verification"
+)
+
+// OneTimeToken backs the links we email (password reset, email verification), only the hash of the token is stored
+// and it can be consumed a single time before it expires.
+type OneTimeToken struct {
+	ID        string             `json:"id,omitempty" bson:"_id,omitempty"`
+	Hash      string             `json:"-" bson:"hash"`
+	Purpose   string             `json:"purpose" bson:"purpose"`
+	User      primitive.ObjectID `json:"user" bson:"user"`
+	Used      bool               `json:"used" bson:"used"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiresAt time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
@@ -1,18 +1,76 @@
 package models
 
 import (
+	"time"
+
 	"github.com/kiranbhalerao123/gotter/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Roles a user can have, users without a role are plain users.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	ID        string               `json:"id,omitempty" bson:"_id,omitempty"`
 	Email     string               `json:"email" bson:"email"`
+	Verified  bool                 `json:"verified" bson:"verified"`
 	UserName  string               `json:"username" bson:"username"`
 	Password  string               `json:"-" bson:"password,omitempty"`
+	Role      string               `json:"role" bson:"role"`
+	Suspended bool                 `json:"suspended" bson:"suspended"`
 	Posts     []primitive.ObjectID `json:"posts,omitempty" bson:"posts"`
 	Following []primitive.ObjectID `json:"following,omitempty" bson:"following"`
 	Followers []primitive.ObjectID `json:"followers,omitempty" bson:"followers"`
+	// Blocked users can't follow the user or interact with their posts, Muted users' posts stay out of the home timeline
+	Blocked []primitive.ObjectID `json:"blocked,omitempty" bson:"blocked,omitempty"`
+	Muted   []primitive.ObjectID `json:"muted,omitempty" bson:"muted,omitempty"`
+	// Private accounts approve their followers, FollowRequests are the users waiting for it
+	Private        bool                 `json:"private" bson:"private"`
+	FollowRequests []primitive.ObjectID `json:"followRequests,omitempty" bson:"followRequests,omitempty"`
+	// the public profile, shown on GET /users/:username
+	DisplayName string `json:"displayName,omitempty" bson:"displayName,omitempty"`
+	Bio         string `json:"bio,omitempty" bson:"bio,omitempty"`
+	Location    string `json:"location,omitempty" bson:"location,omitempty"`
+	Website     string `json:"website,omitempty" bson:"website,omitempty"`
+	Avatar      string `json:"avatar,omitempty" bson:"avatar,omitempty"`
+	Banner      string `json:"banner,omitempty" bson:"banner,omitempty"`
+	// TokenVersion is bumped to log the user out of every session
+	TokenVersion int `json:"-" bson:"tokenVersion"`
+	// MFAEnabled is set once the TOTP secret was confirmed, TOTPSecret alone means enrollment is pending
+	MFAEnabled    bool     `json:"mfaEnabled" bson:"mfaEnabled"`
+	TOTPSecret    string   `json:"-" bson:"totpSecret,omitempty"`
+	RecoveryCodes []string `json:"-" bson:"recoveryCodes,omitempty"`
+	// LastTOTPStep is the time step of the last accepted code, a code is only good once
+	LastTOTPStep int64 `json:"-" bson:"lastTotpStep,omitempty"`
+	// DeleteAt is when the account gets deleted, the user can cancel it until then
+	DeleteAt *time.Time `json:"deleteAt,omitempty" bson:"deleteAt,omitempty"`
+	// Identities are the social logins linked to the account
+	Identities []Identity `json:"identities,omitempty" bson:"identities,omitempty"`
+}
+
+// Identity is an account of an OpenID Connect provider, Subject is the provider's ID of the user.
+type Identity struct {
+	Provider string `json:"provider" bson:"provider"`
+	Subject  string `json:"-" bson:"subject"`
+}
+
+// HasRole tells if the user has one of the roles.
+func (u User) HasRole(roles ...string) bool {
+	role := u.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 type Author struct {
@@ -22,7 +80,7 @@ type Author struct {
 
 type SignupInputs struct {
 	Email    string `json:"email" bson:"email" valid:"email"`
-	UserName string `json:"username" bson:"username" valid:"length(3|30)"`
+	UserName string `json:"username" bson:"username" valid:"matches(^[a-zA-Z0-9_]+$),length(3|30)"`
 	Password string `json:"password" bson:"password,omitempty" valid:"length(6|30)"`
 }
 
@@ -32,10 +90,157 @@ type LoginInputs struct {
 }
 
 type UpdateInputs struct {
-	UserName string `json:"username" bson:"username" valid:"length(3|30)"`
-	Password string `json:"password" bson:"password,omitempty" valid:"length(3|30)"`
+	UserName string `json:"username" bson:"username"`
+	Password string `json:"password" bson:"password,omitempty"`
+	// Private switches the follow requests on or off, turning them off approves the pending ones
+	Private *bool `json:"private" bson:"private"`
+	ProfileInputs
+}
+
+// ProfileInputs change the profile fields that are set, a blank field clears it.
+type ProfileInputs struct {
+	DisplayName *string `json:"displayName" bson:"displayName" valid:"runelength(1|50)"`
+	Bio         *string `json:"bio" bson:"bio" valid:"runelength(1|160)"`
+	Location    *string `json:"location" bson:"location" valid:"runelength(1|30)"`
+	Website     *string `json:"website" bson:"website" valid:"url,matches(^https?://),length(1|100)"`
+	Avatar      *string `json:"avatar" bson:"avatar" valid:"url,matches(^https?://),length(1|300)"`
+	Banner      *string `json:"banner" bson:"banner" valid:"url,matches(^https?://),length(1|300)"`
+}
+
+// Profile is the public part of a user with the size of their network.
+type Profile struct {
+	ID             string `json:"id"`
+	UserName       string `json:"username"`
+	DisplayName    string `json:"displayName"`
+	Bio            string `json:"bio"`
+	Location       string `json:"location"`
+	Website        string `json:"website"`
+	Avatar         string `json:"avatar"`
+	Banner         string `json:"banner"`
+	Private        bool   `json:"private"`
+	FollowersCount int    `json:"followersCount"`
+	FollowingCount int    `json:"followingCount"`
+	PostsCount     int    `json:"postsCount"`
+}
+
+// NewProfile returns the public profile of the user.
+func NewProfile(u User) Profile {
+	return Profile{
+		ID:             u.ID,
+		UserName:       u.UserName,
+		DisplayName:    u.DisplayName,
+		Bio:            u.Bio,
+		Location:       u.Location,
+		Website:        u.Website,
+		Avatar:         u.Avatar,
+		Banner:         u.Banner,
+		Private:        u.Private,
+		FollowersCount: len(u.Followers),
+		FollowingCount: len(u.Following),
+		PostsCount:     len(u.Posts),
+	}
+}
+
+// UserSummary is a user of the follower listings, FollowsYou tells if they follow the logged in user.
+type UserSummary struct {
+	ID          string `json:"id"`
+	UserName    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Avatar      string `json:"avatar"`
+	Private     bool   `json:"private"`
+	FollowsYou  bool   `json:"followsYou"`
+}
+
+type ForgotPasswordInputs struct {
+	Email string `json:"email" bson:"email" valid:"email,required"`
+}
+
+type ResetPasswordInputs struct {
+	Token    string `json:"token" bson:"token" valid:"required"`
+	Password string `json:"password" bson:"password,omitempty" valid:"length(6|30),required"`
+}
+
+type RoleInputs struct {
+	Role string `json:"role" bson:"role" valid:"in(user|moderator|admin),required"`
+}
+
+type MFACodeInputs struct {
+	Code string `json:"code" bson:"code" valid:"numeric,length(6|6),required"`
+}
+
+type DisableMFAInputs struct {
+	Password string `json:"password" bson:"password,omitempty" valid:"required"`
+}
+
+type DeleteAccountInputs struct {
+	Password string `json:"password" bson:"password,omitempty" valid:"required"`
+}
+
+// LoginMFAInputs finish a login started with /login, either Code or RecoveryCode is required.
+type LoginMFAInputs struct {
+	MFAToken     string `json:"mfaToken" bson:"mfaToken" valid:"required"`
+	Code         string `json:"code" bson:"code"`
+	RecoveryCode string `json:"recoveryCode" bson:"recoveryCode"`
 }
 
 func (i SignupInputs) Validate() error {
 	return utils.Validator(i)
 }
+
+// Validate checks the profile fields, then the username and the password when they change.
+func (i UpdateInputs) Validate() error {
+	if err := i.ProfileInputs.Validate(); err != nil {
+		return err
+	}
+
+	changes := struct {
+		UserName *string `json:"username" valid:"matches(^[a-zA-Z0-9_]+$),length(3|30)"`
+		Password *string `json:"password" valid:"length(6|30)"`
+	}{}
+
+	if i.UserName != "" {
+		changes.UserName = &i.UserName
+	}
+	if i.Password != "" {
+		changes.Password = &i.Password
+	}
+	return utils.Validator(changes)
+}
+
+// Validate checks the fields that are set, the blank ones clear the field so they're skipped.
+func (i ProfileInputs) Validate() error {
+	for _, field := range []**string{&i.DisplayName, &i.Bio, &i.Location, &i.Website, &i.Avatar, &i.Banner} {
+		if *field != nil && **field == "" {
+			*field = nil
+		}
+	}
+	return utils.Validator(i)
+}
+
+func (i ForgotPasswordInputs) Validate() error {
+	return utils.Validator(i)
+}
+
+func (i ResetPasswordInputs) Validate() error {
+	return utils.Validator(i)
+}
+
+func (i RoleInputs) Validate() error {
+	return utils.Validator(i)
+}
+
+func (i MFACodeInputs) Validate() error {
+	return utils.Validator(i)
+}
+
+func (i DisableMFAInputs) Validate() error {
+	return utils.Validator(i)
+}
+
+func (i DeleteAccountInputs) Validate() error {
+	return utils.Validator(i)
+}
+
+func (i LoginMFAInputs) Validate() error {
+	return utils.Validator(i)
+}
@@ -0,0 +1,48 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+// FromEnv builds the providers listed in OIDC_PROVIDERS (comma separated names), each of them reads:
+//   - OIDC_<NAME>_ISSUER, its endpoints are discovered from the issuer
+//   - OIDC_<NAME>_CLIENT_ID and OIDC_<NAME>_CLIENT_SECRET (empty for public clients)
+//   - OIDC_<NAME>_SCOPES (space separated, default "openid email profile")
+//   - OIDC_<NAME>_REDIRECT_URL (default APP_URL/api/v1/oauth/<name>/callback)
+func FromEnv() (map[string]*Provider, error) {
+	providers := map[string]*Provider{}
+
+	for _, name := range strings.Split(utils.GoDotEnvVariable("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		env := func(key string) string {
+			return utils.GoDotEnvVariable(prefix + key)
+		}
+
+		p := &Provider{
+			Name:         name,
+			Issuer:       env("ISSUER"),
+			ClientID:     env("CLIENT_ID"),
+			ClientSecret: env("CLIENT_SECRET"),
+			RedirectURL:  env("REDIRECT_URL"),
+			Scopes:       strings.Fields(env("SCOPES")),
+		}
+
+		if p.Issuer == "" || p.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID are required", prefix, prefix)
+		}
+
+		if p.RedirectURL == "" {
+			p.RedirectURL = utils.AppURL() + "/api/v1/oauth/" + name + "/callback"
+		}
+		providers[name] = p
+	}
+	return providers, nil
+}
@@ -0,0 +1,124 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+// publicKey is a key of the provider's JWKS and the only algorithm tokens signed with it may use.
+type publicKey struct {
+	key interface{}
+	alg string
+}
+
+// key returns the provider key with the given kid, the JWKS is fetched again
+// when the kid is unknown since the provider may have rotated its keys.
+func (p *Provider) key(ctx context.Context, kid string) (publicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return publicKey{}, err
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok = keys[kid]; !ok {
+		return publicKey{}, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]publicKey, error) {
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+
+	if err := p.getJSON(ctx, p.JWKSURL, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]publicKey{}
+	for _, jwk := range set.Keys {
+		if use := jwk["use"]; use != "" && use != "sig" {
+			continue
+		}
+
+		// keys we can't use (other curves, encryption keys...) are skipped, not fatal
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk["kid"]] = key
+	}
+	return keys, nil
+}
+
+// parseJWK decodes an RSA, EC P-256 or Ed25519 public JWK (RFC 7517, RFC 8037).
+func parseJWK(jwk map[string]string) (publicKey, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+
+	switch jwk["kty"] {
+	case "RSA":
+		n, err := b64(jwk["n"])
+		if err != nil {
+			return publicKey{}, err
+		}
+		e, err := b64(jwk["e"])
+		if err != nil {
+			return publicKey{}, err
+		}
+
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return publicKey{key: key, alg: jwt.SigningMethodRS256.Alg()}, nil
+	case "EC":
+		if jwk["crv"] != "P-256" {
+			return publicKey{}, fmt.Errorf("unsupported curve %q", jwk["crv"])
+		}
+
+		x, err := b64(jwk["x"])
+		if err != nil {
+			return publicKey{}, err
+		}
+		y, err := b64(jwk["y"])
+		if err != nil {
+			return publicKey{}, err
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return publicKey{}, errors.New("invalid EC key")
+		}
+		return publicKey{key: key, alg: jwt.SigningMethodES256.Alg()}, nil
+	case "OKP":
+		if jwk["crv"] != "Ed25519" {
+			return publicKey{}, fmt.Errorf("unsupported curve %q", jwk["crv"])
+		}
+
+		x, err := b64(jwk["x"])
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return publicKey{}, errors.New("invalid Ed25519 key")
+		}
+		// jwt-go doesn't ship EdDSA, utils registers it
+		return publicKey{key: ed25519.PublicKey(x), alg: utils.SigningMethodEdDSA.Alg()}, nil
+	}
+	return publicKey{}, fmt.Errorf("unsupported key type %q", jwk["kty"])
+}
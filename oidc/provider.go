@@ -0,0 +1,272 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// DefaultScopes are asked for when the provider doesn't configure its own.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// Provider is an OpenID Connect provider users can log in with using the authorization code flow with PKCE.
+type Provider struct {
+	// Name is the provider in the login URLs (/oauth/:provider/login) and in the linked identities
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// AuthURL, TokenURL and JWKSURL are discovered from the issuer when empty
+	AuthURL  string
+	TokenURL string
+	JWKSURL  string
+
+	// Client defaults to a client with a 10s timeout
+	Client *http.Client
+
+	mu   sync.Mutex
+	keys map[string]publicKey
+}
+
+// Claims are the ID token claims used to find or create the user.
+type Claims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     bool   `json:"email_verified,omitempty"`
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+}
+
+// CodeChallenge is the S256 PKCE challenge of the verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL is the provider page the user is sent to, it comes back to RedirectURL with a code and the state.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + query.Encode(), nil
+}
+
+// Exchange trades the code for the tokens and returns the claims of the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	// public clients only have PKCE
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oidc: token response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("oidc: token request failed: %s %s", body.Error, body.ErrorDescription)
+	}
+
+	if body.IDToken == "" {
+		return nil, errors.New("oidc: no id_token in the token response")
+	}
+
+	return p.verify(ctx, body.IDToken, nonce)
+}
+
+// verify checks the signature of the ID token with the provider's keys and that it was issued for us and this login.
+func (p *Provider) verify(ctx context.Context, idToken, nonce string) (*Claims, error) {
+	mapClaims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, mapClaims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		key, err := p.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// never let the token pick the algorithm, HS256 would be verified with a public key
+		if t.Method.Alg() != key.alg {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return key.key, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id_token: %v", err)
+	}
+
+	if iss, _ := mapClaims["iss"].(string); iss != p.Issuer {
+		return nil, fmt.Errorf("oidc: id_token issued by %q", iss)
+	}
+
+	if !hasAudience(mapClaims["aud"], p.ClientID) {
+		return nil, errors.New("oidc: id_token wasn't issued for this client")
+	}
+
+	if n, _ := mapClaims["nonce"].(string); n == "" || n != nonce {
+		return nil, errors.New("oidc: id_token nonce mismatch")
+	}
+
+	claims := &Claims{}
+	claims.Subject, _ = mapClaims["sub"].(string)
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.Name, _ = mapClaims["name"].(string)
+	claims.PreferredUsername, _ = mapClaims["preferred_username"].(string)
+
+	// some providers send it as a string
+	switch verified := mapClaims["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("oidc: id_token without a subject")
+	}
+	return claims, nil
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// discover fills the missing endpoints from the issuer's openid-configuration.
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.AuthURL != "" && p.TokenURL != "" && p.JWKSURL != "" {
+		return nil
+	}
+
+	var config struct {
+		Issuer   string `json:"issuer"`
+		AuthURL  string `json:"authorization_endpoint"`
+		TokenURL string `json:"token_endpoint"`
+		JWKSURL  string `json:"jwks_uri"`
+	}
+
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &config); err != nil {
+		return err
+	}
+
+	if config.Issuer != p.Issuer {
+		return fmt.Errorf("oidc: %s discovery returned the issuer %q", p.Name, config.Issuer)
+	}
+
+	if p.AuthURL == "" {
+		p.AuthURL = config.AuthURL
+	}
+	if p.TokenURL == "" {
+		p.TokenURL = config.TokenURL
+	}
+	if p.JWKSURL == "" {
+		p.JWKSURL = config.JWKSURL
+	}
+	return nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s: %s", target, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (p *Provider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
@@ -1,48 +1,216 @@
 package router
 
 import (
+	"os"
+
 	"github.com/gofiber/fiber"
-	. "github.com/kiranbhalerao123/gotter/config"
 	. "github.com/kiranbhalerao123/gotter/handlers"
+	"github.com/kiranbhalerao123/gotter/jobs"
+	"github.com/kiranbhalerao123/gotter/mailer"
 	. "github.com/kiranbhalerao123/gotter/middlewares"
+	"github.com/kiranbhalerao123/gotter/models"
+	"github.com/kiranbhalerao123/gotter/oidc"
+	"github.com/kiranbhalerao123/gotter/store"
+	"github.com/kiranbhalerao123/gotter/utils"
 )
 
-func SetupRouter(app *fiber.App) {
+// Options holds the dependencies shared by the route handlers.
+type Options struct {
+	// Store selects the storage backend, store.NewMongo or store.NewMemory
+	Store store.Store
+	// Mailer sends the emails, mailer.FromEnv or a mailer.LogMailer (used when nil)
+	Mailer mailer.Mailer
+	// RequireVerifiedEmail blocks posts and comments until the user verified their email
+	RequireVerifiedEmail bool
+	// Providers are the OpenID Connect providers of the social login, oidc.FromEnv
+	Providers map[string]*oidc.Provider
+	// Blobs keeps the uploaded files, store.BlobsFromEnv (local files in the temp directory when nil)
+	Blobs store.BlobStore
+}
+
+func SetupRouter(app *fiber.App, opts Options) {
+	if opts.Mailer == nil {
+		opts.Mailer = mailer.NewLogMailer(os.Stdout, "gotter <no-reply@gotter.local>")
+	}
+	if opts.Blobs == nil {
+		opts.Blobs = store.NewLocalBlobs(utils.BlobDir())
+	}
+
+	// JWKS lets other services verify our tokens, it lives outside of the versioned API
+	app.Get("/.well-known/jwks.json", WellKnownHandler{}.JWKS)
+
 	// Router Setup
 	router := app.Group("/api/v1")
 
+	guard := NewGuard(opts.Store)
+	guard.RequireVerifiedEmail = opts.RequireVerifiedEmail
+
 	// Auth Routes
-	_authHandler := AuthHandler{UsersColl: Mongo.DB.Collection("users")}
+	_authHandler := AuthHandler{
+		Users:         opts.Store.Users,
+		Tokens:        opts.Store.RefreshTokens,
+		Revocations:   opts.Store.Revocations,
+		OneTimeTokens: opts.Store.OneTimeTokens,
+		Mailer:        opts.Mailer,
+		Attempts:      opts.Store.LoginAttempts,
+		Sessions:      opts.Store.Sessions,
+		Providers:     opts.Providers,
+	}
 	router.Post("/signup", _authHandler.Signup)
 	router.Post("/login", _authHandler.Login)
+	router.Post("/login/mfa", _authHandler.LoginMFA)
+	router.Post("/token/refresh", _authHandler.RefreshToken)
+	router.Post("/logout", guard.WithGuard, guard.WithUser, _authHandler.Logout)
+	router.Post("/logout/all", guard.WithGuard, guard.WithUser, _authHandler.LogoutAll)
+	router.Get("/oauth/:provider/login", _authHandler.OAuthLogin)
+	router.Get("/oauth/:provider/callback", _authHandler.OAuthCallback)
+
+	// Two-Factor Authentication Routes
+	_mfaHandler := MFAHandler{Users: opts.Store.Users}
+	router.Post("/mfa/totp/enroll", guard.WithGuard, guard.WithUser, _mfaHandler.EnrollTOTP)
+	router.Post("/mfa/totp/confirm", guard.WithGuard, guard.WithUser, _mfaHandler.ConfirmTOTP)
+	router.Delete("/mfa/totp", guard.WithGuard, guard.WithUser, _mfaHandler.DisableTOTP)
+
+	// Password Routes
+	_passwordHandler := PasswordHandler{
+		Users:         opts.Store.Users,
+		Tokens:        opts.Store.OneTimeTokens,
+		RefreshTokens: opts.Store.RefreshTokens,
+		Sessions:      opts.Store.Sessions,
+		Mailer:        opts.Mailer,
+	}
+	router.Post("/password/forgot", _passwordHandler.ForgotPassword)
+	router.Post("/password/reset", _passwordHandler.ResetPassword)
+
+	// Email Verification Routes
+	_verificationHandler := VerificationHandler{
+		Users:  opts.Store.Users,
+		Tokens: opts.Store.OneTimeTokens,
+		Mailer: opts.Mailer,
+	}
+	router.Get("/verify-email", _verificationHandler.VerifyEmail)
+	router.Post("/verify-email/resend", guard.WithGuard, guard.WithUser, _verificationHandler.ResendVerification)
+
+	// Session Routes
+	_sessionHandler := SessionHandler{
+		Sessions: opts.Store.Sessions,
+		Tokens:   opts.Store.RefreshTokens,
+	}
+	router.Get("/sessions", guard.WithGuard, guard.WithUser, _sessionHandler.ListSessions)
+	router.Delete("/sessions/:id", guard.WithGuard, guard.WithUser, _sessionHandler.RevokeSession)
+
+	// API Key Routes, managing keys needs a real login
+	_apiKeyHandler := APIKeyHandler{Keys: opts.Store.APIKeys}
+	router.Post("/api-keys", guard.WithGuard, guard.WithUser, _apiKeyHandler.CreateAPIKey)
+	router.Get("/api-keys", guard.WithGuard, guard.WithUser, _apiKeyHandler.ListAPIKeys)
+	router.Delete("/api-keys/:id", guard.WithGuard, guard.WithUser, _apiKeyHandler.RevokeAPIKey)
+
+	// Media Routes, the uploads are served from /media/<key>, /user/avatar and /user/banner go before /user/:id
+	_mediaHandler := MediaHandler{
+		Users: opts.Store.Users,
+		Media: opts.Store.Media,
+		Blobs: opts.Blobs,
+	}
+	router.Post("/user/avatar", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _mediaHandler.UploadAvatar)
+	router.Post("/user/banner", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _mediaHandler.UploadBanner)
+	router.Post("/media", guard.WithScope(models.ScopePostsWrite), guard.WithUser, guard.WithVerifiedEmail, _mediaHandler.UploadMedia)
+	router.Get("/media/*", _mediaHandler.GetMedia)
+
+	// Follow Suggestion Routes, /users/suggestions goes before /users/:username
+	_suggestionHandler := SuggestionHandler{
+		Suggestions: opts.Store.Suggestions,
+		Suggester: jobs.Suggester{
+			Users:       opts.Store.Users,
+			Posts:       opts.Store.Posts,
+			Suggestions: opts.Store.Suggestions,
+		},
+	}
+	router.Get("/users/suggestions", guard.WithScope(models.ScopeUserRead), guard.WithUser, _suggestionHandler.GetSuggestions)
 
 	// User Routes
-	_userHandler := UserHandler{UserColl: Mongo.DB.Collection("users")}
-	router.Get("/user", WithGuard, WithUser, _userHandler.GetUser)
-	router.Put("/user", WithGuard, WithUser, _userHandler.UpdateUser)
-	router.Post("/user/:id", WithGuard, WithUser, _userHandler.FollowUnFollowUser)
+	_userHandler := UserHandler{
+		Users:         opts.Store.Users,
+		RefreshTokens: opts.Store.RefreshTokens,
+		Sessions:      opts.Store.Sessions,
+	}
+	router.Get("/user", guard.WithScope(models.ScopeUserRead), guard.WithUser, _userHandler.GetUser)
+	router.Put("/user", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.UpdateUser)
+	// deleting the account needs a real login, /user/restore goes before /user/:id
+	router.Delete("/user", guard.WithGuard, guard.WithUser, _userHandler.DeleteUser)
+	router.Post("/user/restore", guard.WithGuard, guard.WithUser, _userHandler.RestoreUser)
+
+	// Data Export Routes, the download link is opened from the email without a login
+	_exportHandler := ExportHandler{Exports: opts.Store.Exports}
+	router.Post("/user/export", guard.WithGuard, guard.WithUser, _exportHandler.RequestExport)
+	router.Get("/user/export", guard.WithGuard, guard.WithUser, _exportHandler.GetExport)
+	router.Get("/user/export/download", _exportHandler.DownloadExport)
+
+	// Follow Routes, they go after the other /user/xxx routes
+	router.Get("/user/follow-requests", guard.WithScope(models.ScopeUserRead), guard.WithUser, _userHandler.ListFollowRequests)
+	router.Post("/user/follow-requests/:id/approve", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.ApproveFollowRequest)
+	router.Post("/user/follow-requests/:id/reject", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.RejectFollowRequest)
+	router.Post("/user/:id", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.FollowUnFollowUser)
+	router.Post("/user/:id/block", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.BlockUser)
+	router.Delete("/user/:id/block", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.UnblockUser)
+	router.Post("/user/:id/mute", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.MuteUser)
+	router.Delete("/user/:id/mute", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.UnmuteUser)
+	router.Get("/users/:username", _userHandler.GetProfile)
+	router.Put("/users/:id/follow", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.Follow)
+	router.Delete("/users/:id/follow", guard.WithScope(models.ScopeUserWrite), guard.WithUser, _userHandler.Unfollow)
+	// logging in is optional, it sets the "follows you" flags and opens the lists of the private accounts one follows
+	router.Get("/users/:id/followers", guard.Optional(guard.WithScope(models.ScopeUserRead)), guard.Optional(guard.WithUser), _userHandler.ListFollowers)
+	router.Get("/users/:id/following", guard.Optional(guard.WithScope(models.ScopeUserRead)), guard.Optional(guard.WithUser), _userHandler.ListFollowing)
 
 	// Post Routes
 	_postHandler := PostHandler{
-		UserColl:    Mongo.DB.Collection("users"),
-		PostColl:    Mongo.DB.Collection("posts"),
-		CommentColl: Mongo.DB.Collection("comments"),
+		Users:    opts.Store.Users,
+		Posts:    opts.Store.Posts,
+		Comments: opts.Store.Comments,
+		Media:    opts.Store.Media,
+		Blobs:    opts.Blobs,
 	}
-	router.Post("/post", WithGuard, WithUser, _postHandler.CreatePost)
-	router.Put("/post/:id", WithGuard, WithUser, _postHandler.UpdatePost)
-	router.Delete("/post/:id", WithGuard, WithUser, _postHandler.DeletePost)
-	router.Post("/post/:id", WithGuard, WithUser, _postHandler.LikeDislikePost)
-	router.Get("/post/timeline/user/:userId", _postHandler.UserTimeline)  // another users userId
-	router.Get("/post/timeline/home/:userId?", _postHandler.HomeTimeline) // current users userId (optional)
+	router.Post("/post", guard.WithScope(models.ScopePostsWrite), guard.WithUser, guard.WithVerifiedEmail, _postHandler.CreatePost)
+	router.Put("/post/:id", guard.WithScope(models.ScopePostsWrite), guard.WithUser, _postHandler.UpdatePost)
+	router.Delete("/post/:id", guard.WithScope(models.ScopePostsWrite), guard.WithUser, _postHandler.DeletePost)
+	router.Post("/post/:id", guard.WithScope(models.ScopePostsWrite), guard.WithUser, _postHandler.LikeDislikePost)
+	router.Put("/post/:id/repost", guard.WithScope(models.ScopePostsWrite), guard.WithUser, guard.WithVerifiedEmail, _postHandler.Repost)
+	router.Delete("/post/:id/repost", guard.WithScope(models.ScopePostsWrite), guard.WithUser, _postHandler.Unrepost)
+	// another users userId, logging in is optional and lets the followers of private accounts see their posts
+	router.Get("/post/timeline/user/:userId", guard.Optional(guard.WithScope(models.ScopeTimelineRead)), guard.Optional(guard.WithUser), _postHandler.UserTimeline)
+	// current users userId (optional), logging in is needed to see the posts of the private accounts one follows
+	router.Get("/post/timeline/home/:userId?", guard.Optional(guard.WithScope(models.ScopeTimelineRead)), guard.Optional(guard.WithUser), _postHandler.HomeTimeline)
+	router.Get("/tags/:tag", _postHandler.TagTimeline)
 
 	// Comment Routes
 	_commentHandler := CommentHandler{
-		CommentColl: Mongo.DB.Collection("comments"),
-		PostColl:    Mongo.DB.Collection("posts"),
+		Comments: opts.Store.Comments,
+		Posts:    opts.Store.Posts,
+		Users:    opts.Store.Users,
 	}
 	router.Get("/comment", _commentHandler.GetComment)
-	router.Post("/comment", WithGuard, WithUser, _commentHandler.CommentPost)
-	router.Put("/comment/:id", WithGuard, WithUser, _commentHandler.UpdateComment)
-	router.Delete("/comment/:id", WithGuard, WithUser, _commentHandler.DeleteComment)
-	router.Post("/comment/:id", WithGuard, WithUser, _commentHandler.LikeDislikeComment)
+	router.Get("/post/:id/thread", guard.Optional(guard.WithScope(models.ScopeTimelineRead)), guard.Optional(guard.WithUser), _commentHandler.GetThread)
+	router.Post("/comment", guard.WithScope(models.ScopeCommentsWrite), guard.WithUser, guard.WithVerifiedEmail, _commentHandler.CommentPost)
+	router.Put("/comment/:id", guard.WithScope(models.ScopeCommentsWrite), guard.WithUser, _commentHandler.UpdateComment)
+	router.Delete("/comment/:id", guard.WithScope(models.ScopeCommentsWrite), guard.WithUser, _commentHandler.DeleteComment)
+	router.Post("/comment/:id", guard.WithScope(models.ScopeCommentsWrite), guard.WithUser, _commentHandler.LikeDislikeComment)
+
+	// Admin Routes
+	_adminHandler := AdminHandler{
+		Users:    opts.Store.Users,
+		Posts:    opts.Store.Posts,
+		Comments: opts.Store.Comments,
+		Tokens:   opts.Store.RefreshTokens,
+		Sessions: opts.Store.Sessions,
+		Attempts: opts.Store.LoginAttempts,
+		Media:    opts.Store.Media,
+		Blobs:    opts.Blobs,
+	}
+	admin := router.Group("/admin", guard.WithGuard, guard.WithUser)
+	admin.Get("/users", RequireRole(models.RoleAdmin), _adminHandler.ListUsers)
+	admin.Post("/users/:id/suspend", RequireRole(models.RoleAdmin), _adminHandler.SuspendUser)
+	admin.Post("/users/:id/unsuspend", RequireRole(models.RoleAdmin), _adminHandler.UnsuspendUser)
+	admin.Put("/users/:id/role", RequireRole(models.RoleAdmin), _adminHandler.UpdateRole)
+	admin.Post("/users/:id/unlock", RequireRole(models.RoleAdmin), _adminHandler.UnlockUser)
+	admin.Delete("/post/:id", RequireRole(models.RoleModerator, models.RoleAdmin), _adminHandler.DeletePost)
+	admin.Delete("/comment/:id", RequireRole(models.RoleModerator, models.RoleAdmin), _adminHandler.DeleteComment)
 }
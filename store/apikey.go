@@ -0,0 +1,22 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyStore interface {
+	// Create inserts the key and sets its generated ID.
+	Create(ctx context.Context, key *models.APIKey) error
+	FindByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// ListByUser returns the user's keys, newest first.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error)
+	// Revoke revokes the key when it belongs to userID.
+	Revoke(ctx context.Context, id, userID primitive.ObjectID) error
+	// Touch records the last time the key was used.
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
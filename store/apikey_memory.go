@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAPIKeys struct {
+	db *memoryDB
+}
+
+func (m memoryAPIKeys) Create(ctx context.Context, key *models.APIKey) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	id := primitive.NewObjectID()
+	key.ID = id.Hex()
+
+	k := cloneAPIKey(key)
+	m.db.apiKeys[id] = &k
+	return nil
+}
+
+func (m memoryAPIKeys) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, key := range m.db.apiKeys {
+		if key.Hash == hash {
+			k := cloneAPIKey(key)
+			return &k, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m memoryAPIKeys) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	keys := []models.APIKey{}
+	for _, key := range m.db.apiKeys {
+		if key.User == userID {
+			keys = append(keys, cloneAPIKey(key))
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID > keys[j].ID
+	})
+	return keys, nil
+}
+
+func (m memoryAPIKeys) Revoke(ctx context.Context, id, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	key, ok := m.db.apiKeys[id]
+	if !ok || key.User != userID {
+		return ErrNotFound
+	}
+
+	key.Revoked = true
+	return nil
+}
+
+func (m memoryAPIKeys) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if key, ok := m.db.apiKeys[id]; ok {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
+
+func (m memoryAPIKeys) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for id, doc := range m.db.apiKeys {
+		if doc.User == userID {
+			delete(m.db.apiKeys, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAPIKeys struct {
+	coll *mongo.Collection
+}
+
+func (m mongoAPIKeys) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	key.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (m mongoAPIKeys) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	key := new(models.APIKey)
+
+	if err := m.coll.FindOne(ctx, bson.M{"hash": hash}).Decode(key); err != nil {
+		return nil, mongoError(err)
+	}
+	return key, nil
+}
+
+func (m mongoAPIKeys) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	cur, err := m.coll.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []models.APIKey{}
+	if err := cur.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (m mongoAPIKeys) Revoke(ctx context.Context, id, userID primitive.ObjectID) error {
+	result, err := m.coll.UpdateOne(ctx, bson.M{"_id": id, "user": userID}, bson.M{"$set": bson.M{"revoked": true}})
+
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoAPIKeys) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	return err
+}
+
+func (m mongoAPIKeys) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
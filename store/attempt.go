@@ -0,0 +1,19 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+)
+
+// LoginAttemptStore keeps the failed login counters, the in-memory one is used by default
+// and the MongoDB one shares the counters between several instances of the API.
+type LoginAttemptStore interface {
+	// Get returns the counter of the key, ErrNotFound when there was no failure.
+	Get(ctx context.Context, key string) (*models.LoginAttempts, error)
+	// Fail records a failed attempt and returns the updated counter,
+	// counting restarts from 1 when the last failure is older than window.
+	Fail(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error)
+	Reset(ctx context.Context, key string) error
+}
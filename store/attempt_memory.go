@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+)
+
+type memoryLoginAttempts struct {
+	mu        sync.Mutex
+	attempts  map[string]*models.LoginAttempts
+	lastPrune time.Time
+}
+
+// NewMemoryLoginAttempts returns a LoginAttemptStore that keeps the counters in process memory.
+func NewMemoryLoginAttempts() LoginAttemptStore {
+	return &memoryLoginAttempts{attempts: map[string]*models.LoginAttempts{}}
+}
+
+func (m *memoryLoginAttempts) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts, ok := m.attempts[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	a := *attempts
+	return &a, nil
+}
+
+func (m *memoryLoginAttempts) Fail(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.prune(now, window)
+
+	attempts, ok := m.attempts[key]
+	if !ok || now.Sub(attempts.LastFailure) > window {
+		attempts = &models.LoginAttempts{Key: key}
+		m.attempts[key] = attempts
+	}
+
+	attempts.Failures++
+	attempts.LastFailure = now
+
+	a := *attempts
+	return &a, nil
+}
+
+func (m *memoryLoginAttempts) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+// prune drops the counters nobody failed with for a while, so scanning many IPs doesn't grow the map forever.
+// The caller must hold the lock.
+func (m *memoryLoginAttempts) prune(now time.Time, window time.Duration) {
+	if now.Sub(m.lastPrune) < window {
+		return
+	}
+	m.lastPrune = now
+
+	for key, attempts := range m.attempts {
+		if now.Sub(attempts.LastFailure) > window {
+			delete(m.attempts, key)
+		}
+	}
+}
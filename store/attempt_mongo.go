@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoLoginAttempts struct {
+	coll *mongo.Collection
+}
+
+// NewMongoLoginAttempts returns a LoginAttemptStore backed by the login_attempts collection.
+func NewMongoLoginAttempts(db *mongo.Database) LoginAttemptStore {
+	return mongoLoginAttempts{coll: db.Collection("login_attempts")}
+}
+
+func (m mongoLoginAttempts) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	attempts := new(models.LoginAttempts)
+
+	if err := m.coll.FindOne(ctx, bson.M{"_id": key}).Decode(attempts); err != nil {
+		return nil, mongoError(err)
+	}
+	return attempts, nil
+}
+
+func (m mongoLoginAttempts) Fail(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+	now := time.Now()
+
+	// an update pipeline keeps the "restart after window" check atomic
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$lastFailure", now.Add(-window)}},
+				bson.M{"$add": bson.A{"$failures", 1}},
+				1,
+			}},
+			"lastFailure": now,
+		}},
+	}
+
+	attempts := new(models.LoginAttempts)
+	err := m.coll.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, returnUpdated().SetUpsert(true)).Decode(attempts)
+
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	return attempts, nil
+}
+
+func (m mongoLoginAttempts) Reset(ctx context.Context, key string) error {
+	_, err := m.coll.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
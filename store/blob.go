@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kiranbhalerao123/gotter/utils"
+)
+
+// BlobStore keeps the uploaded files, keys are slash separated paths like avatars/<user>/<name>.png.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get returns the content of the blob and its content type, the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// Delete removes the blob, missing blobs aren't an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// BlobsFromEnv builds the blob store selected by BLOB_DRIVER:
+//   - s3: an S3 compatible bucket, S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY
+//   - local (default): files under BLOB_DIR (default gotter-blobs in the temp directory)
+func BlobsFromEnv() (BlobStore, error) {
+	switch driver := utils.GoDotEnvVariable("BLOB_DRIVER"); driver {
+	case "s3":
+		blobs := &S3Blobs{
+			Endpoint:  utils.GoDotEnvVariable("S3_ENDPOINT"),
+			Region:    utils.GoDotEnvVariable("S3_REGION"),
+			Bucket:    utils.GoDotEnvVariable("S3_BUCKET"),
+			AccessKey: utils.GoDotEnvVariable("S3_ACCESS_KEY_ID"),
+			SecretKey: utils.GoDotEnvVariable("S3_SECRET_ACCESS_KEY"),
+		}
+
+		if blobs.Endpoint == "" || blobs.Bucket == "" {
+			return nil, fmt.Errorf("store: S3_ENDPOINT and S3_BUCKET are required with BLOB_DRIVER=s3")
+		}
+		return blobs, nil
+	case "", "local":
+		return NewLocalBlobs(utils.BlobDir()), nil
+	default:
+		return nil, fmt.Errorf("store: unknown BLOB_DRIVER %q", driver)
+	}
+}
+
+// DeleteProfileImage removes a profile image we stored in the folder with its thumbnail,
+// images the user linked from elsewhere are left alone.
+func DeleteProfileImage(ctx context.Context, blobs BlobStore, url, folder string) error {
+	prefix := utils.MediaURL(folder + "/")
+	if !strings.HasPrefix(url, prefix) {
+		return nil
+	}
+
+	key := strings.TrimPrefix(url, utils.MediaURL(""))
+	if err := blobs.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	// profile images are still, their thumbnail has the same type
+	ext := path.Ext(key)
+	return blobs.Delete(ctx, strings.TrimSuffix(key, ext)+"_thumb"+ext)
+}
+
+// validBlobKey rejects the keys that could escape the root of the store.
+func validBlobKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return filepath.Clean(key) == filepath.FromSlash(key)
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentStore interface {
+	// Create inserts the comment and sets its generated ID, a reply bumps the reply count of its parent.
+	Create(ctx context.Context, comment *models.Comment) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error)
+	// Update and Delete only touch the comment when it was written by userID,
+	// Delete removes any comment when userID is the NilObjectID (moderation).
+	// Deleting a reply lowers the reply count of its parent, the replies to it are left to DeleteReplies.
+	// Update replaces the message and its mentions.
+	Update(ctx context.Context, id, userID primitive.ObjectID, message string, mentions []models.Mention) (*models.Comment, error)
+	Delete(ctx context.Context, id, userID primitive.ObjectID) (*models.Comment, error)
+	DeleteByPost(ctx context.Context, postID primitive.ObjectID) error
+	// DeleteReplies removes the replies to the comments, and the replies to them, and returns their IDs.
+	DeleteReplies(ctx context.Context, commentIDs []primitive.ObjectID) ([]primitive.ObjectID, error)
+	// ListTopLevel returns a page of the comments on the post itself, oldest first, and how many there are.
+	ListTopLevel(ctx context.Context, postID primitive.ObjectID, page Page) ([]models.Comment, int32, error)
+	// ListReplies returns a page of the direct replies to each of the comments, oldest first.
+	ListReplies(ctx context.Context, parentIDs []primitive.ObjectID, page Page) ([]models.Comment, error)
+	// ListByUser returns every comment written by the user, newest first.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Comment, error)
+	// ListLikedBy returns every comment the user likes, newest first.
+	ListLikedBy(ctx context.Context, userID primitive.ObjectID) ([]models.Comment, error)
+	// DeleteByUser removes every comment written by the user and returns their IDs.
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error)
+
+	IsLiked(ctx context.Context, commentID, userID primitive.ObjectID) (bool, error)
+	Like(ctx context.Context, commentID, userID primitive.ObjectID) error
+	Unlike(ctx context.Context, commentID, userID primitive.ObjectID) error
+	// UnlikeAll pulls the user out of the likes[] of every comment.
+	UnlikeAll(ctx context.Context, userID primitive.ObjectID) error
+
+	// List returns the most liked comments and the total number of comments.
+	List(ctx context.Context, page Page) ([]models.Comment, int32, error)
+}
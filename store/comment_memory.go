@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryComments struct {
+	db *memoryDB
+}
+
+func (m memoryComments) Create(ctx context.Context, comment *models.Comment) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	id := primitive.NewObjectID()
+	comment.ID = id.Hex()
+
+	c := cloneComment(comment)
+	m.db.comments[id] = &c
+
+	if comment.Parent != nil {
+		if parent, ok := m.db.comments[*comment.Parent]; ok {
+			parent.ReplyCount++
+		}
+	}
+	return nil
+}
+
+func (m memoryComments) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	comment, ok := m.db.comments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	c := cloneComment(comment)
+	return &c, nil
+}
+
+func (m memoryComments) Update(ctx context.Context, id, userID primitive.ObjectID, message string, mentions []models.Mention) (*models.Comment, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	comment, ok := m.db.comments[id]
+	if !ok || comment.User.ID != userID.Hex() {
+		return nil, ErrNotFound
+	}
+
+	comment.Message = message
+	comment.Mentions = append([]models.Mention{}, mentions...)
+
+	c := cloneComment(comment)
+	return &c, nil
+}
+
+func (m memoryComments) Delete(ctx context.Context, id, userID primitive.ObjectID) (*models.Comment, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	comment, ok := m.db.comments[id]
+	if !ok || (!userID.IsZero() && comment.User.ID != userID.Hex()) {
+		return nil, ErrNotFound
+	}
+
+	delete(m.db.comments, id)
+	m.removeReply(comment)
+	return comment, nil
+}
+
+// removeReply lowers the reply count of the parent of a deleted comment, the caller must hold the lock.
+func (m memoryComments) removeReply(comment *models.Comment) {
+	if comment.Parent == nil {
+		return
+	}
+	if parent, ok := m.db.comments[*comment.Parent]; ok && parent.ReplyCount > 0 {
+		parent.ReplyCount--
+	}
+}
+
+func (m memoryComments) DeleteByPost(ctx context.Context, postID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for id, comment := range m.db.comments {
+		if comment.Post == postID {
+			delete(m.db.comments, id)
+		}
+	}
+	return nil
+}
+
+func (m memoryComments) DeleteReplies(ctx context.Context, commentIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	deleted := []primitive.ObjectID{}
+	parents := commentIDs
+
+	// one level of replies at a time
+	for len(parents) > 0 {
+		replies := []primitive.ObjectID{}
+
+		for id, comment := range m.db.comments {
+			if comment.Parent != nil && containsID(parents, *comment.Parent) {
+				delete(m.db.comments, id)
+				replies = append(replies, id)
+			}
+		}
+
+		deleted = append(deleted, replies...)
+		parents = replies
+	}
+	return deleted, nil
+}
+
+func (m memoryComments) ListTopLevel(ctx context.Context, postID primitive.ObjectID, page Page) ([]models.Comment, int32, error) {
+	comments := m.oldestFirst(func(comment *models.Comment) bool {
+		return comment.Post == postID && comment.Parent == nil
+	})
+
+	start, end := paginate(len(comments), page)
+	return comments[start:end], int32(len(comments)), nil
+}
+
+func (m memoryComments) ListReplies(ctx context.Context, parentIDs []primitive.ObjectID, page Page) ([]models.Comment, error) {
+	replies := m.oldestFirst(func(comment *models.Comment) bool {
+		return comment.Parent != nil && containsID(parentIDs, *comment.Parent)
+	})
+
+	byParent := map[primitive.ObjectID][]models.Comment{}
+	for _, reply := range replies {
+		byParent[*reply.Parent] = append(byParent[*reply.Parent], reply)
+	}
+
+	comments := []models.Comment{}
+	for _, id := range parentIDs {
+		start, end := paginate(len(byParent[id]), page)
+		comments = append(comments, byParent[id][start:end]...)
+	}
+	return comments, nil
+}
+
+// oldestFirst returns the matching comments, the oldest first.
+func (m memoryComments) oldestFirst(match func(comment *models.Comment) bool) []models.Comment {
+	comments := m.listWhere(match)
+
+	// listWhere returns the newest first
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+	return comments
+}
+
+func (m memoryComments) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Comment, error) {
+	return m.listWhere(func(comment *models.Comment) bool {
+		return comment.User.ID == userID.Hex()
+	}), nil
+}
+
+func (m memoryComments) ListLikedBy(ctx context.Context, userID primitive.ObjectID) ([]models.Comment, error) {
+	return m.listWhere(func(comment *models.Comment) bool {
+		return containsID(comment.Likes, userID)
+	}), nil
+}
+
+func (m memoryComments) listWhere(match func(comment *models.Comment) bool) []models.Comment {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, comment := range m.db.comments {
+		if match(comment) {
+			comments = append(comments, cloneComment(comment))
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.After(comments[j].CreatedAt)
+	})
+	return comments
+}
+
+func (m memoryComments) DeleteByUser(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	ids := []primitive.ObjectID{}
+	deleted := []*models.Comment{}
+
+	for id, comment := range m.db.comments {
+		if comment.User.ID == userID.Hex() {
+			delete(m.db.comments, id)
+			ids = append(ids, id)
+			deleted = append(deleted, comment)
+		}
+	}
+
+	// once they're all gone, so the replies among them don't count
+	for _, comment := range deleted {
+		m.removeReply(comment)
+	}
+	return ids, nil
+}
+
+func (m memoryComments) IsLiked(ctx context.Context, commentID, userID primitive.ObjectID) (bool, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	comment, ok := m.db.comments[commentID]
+	if !ok {
+		return false, nil
+	}
+	return containsID(comment.Likes, userID), nil
+}
+
+func (m memoryComments) Like(ctx context.Context, commentID, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if comment, ok := m.db.comments[commentID]; ok {
+		comment.Likes = addID(comment.Likes, userID)
+	}
+	return nil
+}
+
+func (m memoryComments) Unlike(ctx context.Context, commentID, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if comment, ok := m.db.comments[commentID]; ok {
+		comment.Likes = removeID(comment.Likes, userID)
+	}
+	return nil
+}
+
+func (m memoryComments) UnlikeAll(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, comment := range m.db.comments {
+		comment.Likes = removeID(comment.Likes, userID)
+	}
+	return nil
+}
+
+func (m memoryComments) List(ctx context.Context, page Page) ([]models.Comment, int32, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, comment := range m.db.comments {
+		comments = append(comments, cloneComment(comment))
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return len(comments[i].Likes) > len(comments[j].Likes)
+	})
+
+	start, end := paginate(len(comments), page)
+	return comments[start:end], int32(len(comments)), nil
+}
@@ -0,0 +1,323 @@
+package store
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoComments struct {
+	coll *mongo.Collection
+}
+
+func (m mongoComments) Create(ctx context.Context, comment *models.Comment) error {
+	comment.ID = ""
+	insertedResult, err := m.coll.InsertOne(ctx, comment)
+
+	if err != nil {
+		return err
+	}
+
+	comment.ID = insertedResult.InsertedID.(primitive.ObjectID).Hex()
+
+	if comment.Parent != nil {
+		return m.incrementReplies(ctx, []primitive.ObjectID{*comment.Parent}, 1)
+	}
+	return nil
+}
+
+// incrementReplies adds delta to the reply count of the comments.
+func (m mongoComments) incrementReplies(ctx context.Context, ids []primitive.ObjectID, delta int) error {
+	for _, id := range ids {
+		if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"replyCount": delta}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m mongoComments) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	comment := new(models.Comment)
+
+	if err := m.coll.FindOne(ctx, bson.M{"_id": id}).Decode(comment); err != nil {
+		return nil, mongoError(err)
+	}
+	return comment, nil
+}
+
+func (m mongoComments) Update(ctx context.Context, id, userID primitive.ObjectID, message string, mentions []models.Mention) (*models.Comment, error) {
+	comment := new(models.Comment)
+
+	filter := bson.M{"_id": id, "user._id": userID.Hex()}
+	update := bson.M{"$set": bson.M{"message": message, "mentions": append([]models.Mention{}, mentions...)}}
+
+	if err := m.coll.FindOneAndUpdate(ctx, filter, update, returnUpdated()).Decode(comment); err != nil {
+		return nil, mongoError(err)
+	}
+	return comment, nil
+}
+
+func (m mongoComments) Delete(ctx context.Context, id, userID primitive.ObjectID) (*models.Comment, error) {
+	comment := new(models.Comment)
+
+	filter := bson.M{"_id": id}
+	if !userID.IsZero() {
+		filter["user._id"] = userID.Hex()
+	}
+
+	if err := m.coll.FindOneAndDelete(ctx, filter).Decode(comment); err != nil {
+		return nil, mongoError(err)
+	}
+
+	if comment.Parent != nil {
+		if err := m.incrementReplies(ctx, []primitive.ObjectID{*comment.Parent}, -1); err != nil {
+			return nil, err
+		}
+	}
+	return comment, nil
+}
+
+func (m mongoComments) DeleteByPost(ctx context.Context, postID primitive.ObjectID) error {
+	_, err := m.coll.DeleteMany(ctx, bson.M{"post": postID})
+	return err
+}
+
+func (m mongoComments) DeleteReplies(ctx context.Context, commentIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	deleted := []primitive.ObjectID{}
+	parents := commentIDs
+
+	// one level of replies at a time
+	for len(parents) > 0 {
+		replies, err := m.ids(ctx, bson.M{"parent": bson.M{"$in": parents}})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(replies) > 0 {
+			if _, err := m.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": replies}}); err != nil {
+				return nil, err
+			}
+		}
+
+		deleted = append(deleted, replies...)
+		parents = replies
+	}
+	return deleted, nil
+}
+
+// the ObjectIDs grow with the creation time, sorting on them puts the oldest comments first
+func (m mongoComments) ListTopLevel(ctx context.Context, postID primitive.ObjectID, page Page) ([]models.Comment, int32, error) {
+	cur, err := m.coll.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"post": postID, "parent": bson.M{"$exists": false}}},
+		{"$sort": bson.M{"_id": 1}},
+		{"$facet": bson.M{
+			"count":    bson.A{bson.M{"$count": "count"}},
+			"comments": bson.A{bson.M{"$skip": page.Skip}, bson.M{"$limit": page.Limit}},
+		}},
+		{"$project": bson.M{
+			"count":    bson.M{"$arrayElemAt": bson.A{"$count.count", 0}},
+			"comments": 1,
+		}},
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Close the cursor once finished
+	defer cur.Close(ctx)
+
+	var data struct {
+		Count    int32            `bson:"count"`
+		Comments []models.Comment `bson:"comments"`
+	}
+
+	if cur.Next(ctx) {
+		if err := cur.Decode(&data); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	return data.Comments, data.Count, nil
+}
+
+func (m mongoComments) ListReplies(ctx context.Context, parentIDs []primitive.ObjectID, page Page) ([]models.Comment, error) {
+	cur, err := m.coll.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"parent": bson.M{"$in": parentIDs}}},
+		{"$sort": bson.M{"_id": 1}},
+		{"$group": bson.M{"_id": "$parent", "replies": bson.M{"$push": "$$ROOT"}}},
+		{"$project": bson.M{"replies": bson.M{"$slice": bson.A{"$replies", page.Skip, page.Limit}}}},
+		{"$unwind": "$replies"},
+		{"$replaceRoot": bson.M{"newRoot": "$replies"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comments := []models.Comment{}
+	if err := cur.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (m mongoComments) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Comment, error) {
+	return m.find(ctx, bson.M{"user._id": userID.Hex()})
+}
+
+func (m mongoComments) ListLikedBy(ctx context.Context, userID primitive.ObjectID) ([]models.Comment, error) {
+	return m.find(ctx, bson.M{"likes": userID})
+}
+
+func (m mongoComments) find(ctx context.Context, filter bson.M) ([]models.Comment, error) {
+	cur, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	comments := []models.Comment{}
+	if err := cur.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (m mongoComments) DeleteByUser(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := bson.M{"user._id": userID.Hex()}
+
+	cur, err := m.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1, "parent": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		ID     primitive.ObjectID  `bson:"_id"`
+		Parent *primitive.ObjectID `bson:"parent"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := []primitive.ObjectID{}
+	parents := []primitive.ObjectID{}
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+		if doc.Parent != nil {
+			parents = append(parents, *doc.Parent)
+		}
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return nil, err
+	}
+
+	// the parents among the deleted comments are gone already
+	if err := m.incrementReplies(ctx, parents, -1); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ids returns the IDs of the matching comments.
+func (m mongoComments) ids(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	cur, err := m.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	return ids, nil
+}
+
+func (m mongoComments) IsLiked(ctx context.Context, commentID, userID primitive.ObjectID) (bool, error) {
+	count, err := m.coll.CountDocuments(ctx, bson.M{"_id": commentID, "likes": userID})
+
+	return count > 0, err
+}
+
+func (m mongoComments) Like(ctx context.Context, commentID, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": commentID}, bson.M{"$addToSet": bson.M{"likes": userID}})
+	return err
+}
+
+func (m mongoComments) Unlike(ctx context.Context, commentID, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": commentID}, bson.M{"$pull": bson.M{"likes": userID}})
+	return err
+}
+
+func (m mongoComments) UnlikeAll(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateMany(ctx, bson.M{"likes": userID}, bson.M{"$pull": bson.M{"likes": userID}})
+	return err
+}
+
+func (m mongoComments) List(ctx context.Context, page Page) ([]models.Comment, int32, error) {
+	cur, err := m.coll.Aggregate(ctx, []bson.M{
+		{"$project": bson.M{
+			"_id":        1,
+			"message":    1,
+			"post":       1,
+			"user":       1,
+			"createdAt":  1,
+			"likes":      1,
+			"parent":     1,
+			"replyCount": 1,
+			"mentions":   1,
+			"count":      bson.M{"$size": "$likes"},
+		}},
+		{"$sort": bson.M{"count": -1}},
+		// the count is taken before $skip, a page past the end still has the total
+		{"$facet": bson.M{
+			"count":    bson.A{bson.M{"$count": "count"}},
+			"comments": bson.A{bson.M{"$skip": page.Skip}, bson.M{"$limit": page.Limit}},
+		}},
+		{"$project": bson.M{
+			"count":    bson.M{"$arrayElemAt": bson.A{"$count", 0}},
+			"comments": 1,
+		}},
+		{"$project": bson.M{
+			"count":    "$count.count",
+			"comments": 1,
+		}},
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Close the cursor once finished
+	defer cur.Close(ctx)
+
+	var data struct {
+		Count    int32            `bson:"count"`
+		Comments []models.Comment `bson:"comments"`
+	}
+
+	if cur.Next(ctx) {
+		if err := cur.Decode(&data); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return data.Comments, data.Count, nil
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExportStore interface {
+	// Create inserts the export and sets its generated ID.
+	Create(ctx context.Context, export *models.Export) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Export, error)
+	// FindLatest returns the most recent export of the user.
+	FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.Export, error)
+	// ListPending returns the exports waiting to be built, oldest first.
+	ListPending(ctx context.Context) ([]models.Export, error)
+	MarkReady(ctx context.Context, id primitive.ObjectID, file string, size int64, readyAt, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) error
+	// ListExpired returns the exports that expired before now.
+	ListExpired(ctx context.Context, now time.Time) ([]models.Export, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// ExpireByUser makes every export of the user expire now, the next cleanup removes their archives.
+	ExpireByUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error
+}
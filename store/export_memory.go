@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryExports struct {
+	db *memoryDB
+}
+
+func cloneExport(export *models.Export) models.Export {
+	e := *export
+	if export.ReadyAt != nil {
+		at := *export.ReadyAt
+		e.ReadyAt = &at
+	}
+	if export.ExpiresAt != nil {
+		at := *export.ExpiresAt
+		e.ExpiresAt = &at
+	}
+	return e
+}
+
+func (m memoryExports) Create(ctx context.Context, export *models.Export) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	id := primitive.NewObjectID()
+	export.ID = id.Hex()
+
+	e := cloneExport(export)
+	m.db.exports[id] = &e
+	return nil
+}
+
+func (m memoryExports) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Export, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	export, ok := m.db.exports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	e := cloneExport(export)
+	return &e, nil
+}
+
+func (m memoryExports) FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.Export, error) {
+	exports := m.listWhere(func(export *models.Export) bool {
+		return export.User == userID
+	})
+
+	if len(exports) == 0 {
+		return nil, ErrNotFound
+	}
+	return &exports[len(exports)-1], nil
+}
+
+func (m memoryExports) ListPending(ctx context.Context) ([]models.Export, error) {
+	return m.listWhere(func(export *models.Export) bool {
+		return export.Status == models.ExportPending
+	}), nil
+}
+
+func (m memoryExports) ListExpired(ctx context.Context, now time.Time) ([]models.Export, error) {
+	return m.listWhere(func(export *models.Export) bool {
+		return export.ExpiresAt != nil && export.ExpiresAt.Before(now)
+	}), nil
+}
+
+// listWhere returns the matching exports, oldest first.
+func (m memoryExports) listWhere(match func(export *models.Export) bool) []models.Export {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	exports := []models.Export{}
+	for _, export := range m.db.exports {
+		if match(export) {
+			exports = append(exports, cloneExport(export))
+		}
+	}
+
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].CreatedAt.Before(exports[j].CreatedAt)
+	})
+	return exports
+}
+
+func (m memoryExports) MarkReady(ctx context.Context, id primitive.ObjectID, file string, size int64, readyAt, expiresAt time.Time) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	export, ok := m.db.exports[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	export.Status = models.ExportReady
+	export.File = file
+	export.Size = size
+	export.ReadyAt = &readyAt
+	export.ExpiresAt = &expiresAt
+	return nil
+}
+
+func (m memoryExports) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	export, ok := m.db.exports[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	export.Status = models.ExportFailed
+	export.Error = reason
+	return nil
+}
+
+func (m memoryExports) Delete(ctx context.Context, id primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.exports[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.db.exports, id)
+	return nil
+}
+
+func (m memoryExports) ExpireByUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, export := range m.db.exports {
+		if export.User == userID {
+			at := now
+			export.ExpiresAt = &at
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoExports struct {
+	coll *mongo.Collection
+}
+
+func (m mongoExports) Create(ctx context.Context, export *models.Export) error {
+	export.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, export)
+
+	if err != nil {
+		return err
+	}
+
+	export.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (m mongoExports) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Export, error) {
+	export := new(models.Export)
+
+	if err := m.coll.FindOne(ctx, bson.M{"_id": id}).Decode(export); err != nil {
+		return nil, mongoError(err)
+	}
+	return export, nil
+}
+
+func (m mongoExports) FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.Export, error) {
+	export := new(models.Export)
+	opts := options.FindOne().SetSort(bson.M{"createdAt": -1})
+
+	if err := m.coll.FindOne(ctx, bson.M{"user": userID}, opts).Decode(export); err != nil {
+		return nil, mongoError(err)
+	}
+	return export, nil
+}
+
+func (m mongoExports) ListPending(ctx context.Context) ([]models.Export, error) {
+	return m.find(ctx, bson.M{"status": models.ExportPending})
+}
+
+func (m mongoExports) ListExpired(ctx context.Context, now time.Time) ([]models.Export, error) {
+	return m.find(ctx, bson.M{"expiresAt": bson.M{"$lt": now}})
+}
+
+func (m mongoExports) find(ctx context.Context, filter bson.M) ([]models.Export, error) {
+	cur, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	exports := []models.Export{}
+	if err := cur.All(ctx, &exports); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (m mongoExports) MarkReady(ctx context.Context, id primitive.ObjectID, file string, size int64, readyAt, expiresAt time.Time) error {
+	return m.updateOne(ctx, id, bson.M{
+		"status":    models.ExportReady,
+		"file":      file,
+		"size":      size,
+		"readyAt":   readyAt,
+		"expiresAt": expiresAt,
+	})
+}
+
+func (m mongoExports) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) error {
+	return m.updateOne(ctx, id, bson.M{"status": models.ExportFailed, "error": reason})
+}
+
+func (m mongoExports) updateOne(ctx context.Context, id primitive.ObjectID, set bson.M) error {
+	result, err := m.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoExports) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := m.coll.DeleteOne(ctx, bson.M{"_id": id})
+
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoExports) ExpireByUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	_, err := m.coll.UpdateMany(ctx, bson.M{"user": userID}, bson.M{"$set": bson.M{"expiresAt": now}})
+	return err
+}
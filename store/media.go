@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MediaStore interface {
+	// Create inserts the media and sets its generated ID.
+	Create(ctx context.Context, media *models.Media) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Media, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Attach marks the media as attached to the post.
+	Attach(ctx context.Context, ids []primitive.ObjectID, postID primitive.ObjectID) error
+	// DeleteByPost removes the media attached to the post and returns them, their files are left to the caller.
+	DeleteByPost(ctx context.Context, postID primitive.ObjectID) ([]models.Media, error)
+	// DeleteUnattached removes the media uploaded before the time and never attached to a post and returns them,
+	// their files are left to the caller.
+	DeleteUnattached(ctx context.Context, before time.Time) ([]models.Media, error)
+	// DeleteByOwner removes the media the user uploaded and returns them, their files are left to the caller.
+	DeleteByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]models.Media, error)
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryMedia struct {
+	db *memoryDB
+}
+
+func (m memoryMedia) Create(ctx context.Context, media *models.Media) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	id := primitive.NewObjectID()
+	media.ID = id.Hex()
+
+	doc := *media
+	m.db.media[id] = &doc
+	return nil
+}
+
+func (m memoryMedia) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Media, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	media, ok := m.db.media[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	doc := *media
+	return &doc, nil
+}
+
+func (m memoryMedia) Delete(ctx context.Context, id primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.media[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.db.media, id)
+	return nil
+}
+
+func (m memoryMedia) Attach(ctx context.Context, ids []primitive.ObjectID, postID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, id := range ids {
+		if media, ok := m.db.media[id]; ok {
+			post := postID
+			media.Post = &post
+		}
+	}
+	return nil
+}
+
+func (m memoryMedia) DeleteByPost(ctx context.Context, postID primitive.ObjectID) ([]models.Media, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	deleted := []models.Media{}
+	for id, media := range m.db.media {
+		if media.Post != nil && *media.Post == postID {
+			deleted = append(deleted, *media)
+			delete(m.db.media, id)
+		}
+	}
+	return deleted, nil
+}
+
+func (m memoryMedia) DeleteByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]models.Media, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	deleted := []models.Media{}
+	for id, media := range m.db.media {
+		if media.Owner == ownerID {
+			deleted = append(deleted, *media)
+			delete(m.db.media, id)
+		}
+	}
+	return deleted, nil
+}
+
+func (m memoryMedia) DeleteUnattached(ctx context.Context, before time.Time) ([]models.Media, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	deleted := []models.Media{}
+	for id, media := range m.db.media {
+		if media.Post == nil && media.CreatedAt.Before(before) {
+			deleted = append(deleted, *media)
+			delete(m.db.media, id)
+		}
+	}
+	return deleted, nil
+}
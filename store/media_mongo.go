@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoMedia struct {
+	coll *mongo.Collection
+}
+
+func (m mongoMedia) Create(ctx context.Context, media *models.Media) error {
+	media.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, media)
+
+	if err != nil {
+		return err
+	}
+
+	media.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (m mongoMedia) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Media, error) {
+	media := new(models.Media)
+
+	if err := m.coll.FindOne(ctx, bson.M{"_id": id}).Decode(media); err != nil {
+		return nil, mongoError(err)
+	}
+	return media, nil
+}
+
+func (m mongoMedia) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := m.coll.DeleteOne(ctx, bson.M{"_id": id})
+
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoMedia) Attach(ctx context.Context, ids []primitive.ObjectID, postID primitive.ObjectID) error {
+	_, err := m.coll.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"post": postID}})
+	return err
+}
+
+func (m mongoMedia) DeleteByPost(ctx context.Context, postID primitive.ObjectID) ([]models.Media, error) {
+	return m.deleteMany(ctx, bson.M{"post": postID})
+}
+
+func (m mongoMedia) DeleteByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]models.Media, error) {
+	return m.deleteMany(ctx, bson.M{"owner": ownerID})
+}
+
+func (m mongoMedia) DeleteUnattached(ctx context.Context, before time.Time) ([]models.Media, error) {
+	filter := bson.M{"post": bson.M{"$exists": false}, "createdAt": bson.M{"$lt": before}}
+
+	// one at a time, a media attached meanwhile must keep its files
+	deleted := []models.Media{}
+	for {
+		media := models.Media{}
+		err := m.coll.FindOneAndDelete(ctx, filter).Decode(&media)
+
+		if err == mongo.ErrNoDocuments {
+			return deleted, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, media)
+	}
+}
+
+// deleteMany removes the media matching the filter and returns them.
+func (m mongoMedia) deleteMany(ctx context.Context, filter bson.M) ([]models.Media, error) {
+	cur, err := m.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	media := []models.Media{}
+	if err := cur.All(ctx, &media); err != nil {
+		return nil, err
+	}
+
+	// only what was found, the files of a media uploaded meanwhile mustn't be orphaned
+	ids := make([]primitive.ObjectID, 0, len(media))
+	for _, doc := range media {
+		if id, err := primitive.ObjectIDFromHex(doc.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
@@ -0,0 +1,258 @@
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryDB is the shared state of the in-memory stores,
+// a single lock guards every collection so cross collection reads (timelines) stay consistent.
+type memoryDB struct {
+	mu            sync.RWMutex
+	users         map[primitive.ObjectID]*models.User
+	posts         map[primitive.ObjectID]*models.Post
+	comments      map[primitive.ObjectID]*models.Comment
+	refreshTokens map[primitive.ObjectID]*models.RefreshToken
+	revocations   map[string]time.Time
+	oneTimeTokens map[primitive.ObjectID]*models.OneTimeToken
+	apiKeys       map[primitive.ObjectID]*models.APIKey
+	sessions      map[primitive.ObjectID]*models.Session
+	exports       map[primitive.ObjectID]*models.Export
+	media         map[primitive.ObjectID]*models.Media
+	suggestions   map[primitive.ObjectID]*models.Suggestions
+}
+
+func newMemoryDB() *memoryDB {
+	return &memoryDB{
+		users:         map[primitive.ObjectID]*models.User{},
+		posts:         map[primitive.ObjectID]*models.Post{},
+		comments:      map[primitive.ObjectID]*models.Comment{},
+		refreshTokens: map[primitive.ObjectID]*models.RefreshToken{},
+		revocations:   map[string]time.Time{},
+		oneTimeTokens: map[primitive.ObjectID]*models.OneTimeToken{},
+		apiKeys:       map[primitive.ObjectID]*models.APIKey{},
+		sessions:      map[primitive.ObjectID]*models.Session{},
+		exports:       map[primitive.ObjectID]*models.Export{},
+		media:         map[primitive.ObjectID]*models.Media{},
+		suggestions:   map[primitive.ObjectID]*models.Suggestions{},
+	}
+}
+
+// canView tells if viewerID is the account or one of its followers, the caller must hold the lock.
+func (db *memoryDB) canView(viewerID, accountID primitive.ObjectID) bool {
+	if viewerID == accountID {
+		return true
+	}
+
+	viewer, ok := db.users[viewerID]
+	return ok && containsID(viewer.Following, accountID)
+}
+
+// canSeePost tells if viewerID can see the post, the posts of private accounts are only shown to
+// themselves and their followers and neither of them may have blocked the other, the caller must hold the lock.
+func (db *memoryDB) canSeePost(viewerID primitive.ObjectID, post *models.Post) bool {
+	authorID, _ := primitive.ObjectIDFromHex(post.Author.ID)
+
+	if viewer, ok := db.users[viewerID]; ok && containsID(viewer.Blocked, authorID) {
+		return false
+	}
+
+	author, ok := db.users[authorID]
+	if ok && containsID(author.Blocked, viewerID) {
+		return false
+	}
+	return !ok || !author.Private || db.canView(viewerID, authorID)
+}
+
+// userNameTaken tells if a user other than exceptID has the username, the caller must hold the lock.
+func (db *memoryDB) userNameTaken(username string, exceptID primitive.ObjectID) bool {
+	for id, user := range db.users {
+		if id != exceptID && user.UserName == username {
+			return true
+		}
+	}
+	return false
+}
+
+// topComments returns the most liked comments of the post, the caller must hold the lock.
+func (db *memoryDB) topComments(post *models.Post, limit int64) []models.Comment {
+	comments := []models.Comment{}
+
+	for _, id := range post.Comments {
+		if comment, ok := db.comments[id]; ok && comment.Parent == nil {
+			comments = append(comments, cloneComment(comment))
+		}
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return len(comments[i].Likes) > len(comments[j].Likes)
+	})
+
+	if int64(len(comments)) > limit {
+		comments = comments[:limit]
+	}
+	return comments
+}
+
+// withComments joins the most liked comments of the post, the caller must hold the lock.
+func (db *memoryDB) withComments(post *models.Post, limit int64, viewerID primitive.ObjectID) models.PostWithComment {
+	p := clonePost(post)
+
+	return models.PostWithComment{
+		ID:          p.ID,
+		Title:       p.Title,
+		Description: p.Description,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Author:      p.Author,
+		Comments:    db.topComments(post, limit),
+		Likes:       p.Likes,
+		Media:       p.Media,
+		RepostOf:    p.RepostOf,
+		QuoteOf:     p.QuoteOf,
+		RepostCount: p.RepostCount,
+		QuoteCount:  p.QuoteCount,
+		Tags:        p.Tags,
+		Mentions:    p.Mentions,
+		Original:    db.original(post, viewerID),
+	}
+}
+
+// original returns the post reposted or quoted by post when viewerID can see it, the caller must hold the lock.
+func (db *memoryDB) original(post *models.Post, viewerID primitive.ObjectID) *models.Post {
+	shared := post.Shared()
+	if shared == nil {
+		return nil
+	}
+
+	original, ok := db.posts[*shared]
+	if !ok || !db.canSeePost(viewerID, original) {
+		return nil
+	}
+
+	p := clonePost(original)
+	return &p
+}
+
+// paginate returns the requested page of a slice of the given length as [start, end) bounds.
+func paginate(length int, page Page) (int, int) {
+	start := length
+	if page.Skip < int64(length) {
+		start = int(page.Skip)
+	}
+
+	// compared before adding so a huge limit can't overflow
+	end := length
+	if page.Limit > 0 && page.Limit < int64(end-start) {
+		end = start + int(page.Limit)
+	}
+	return start, end
+}
+
+func cloneIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	if ids == nil {
+		return nil
+	}
+	return append([]primitive.ObjectID{}, ids...)
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// addID appends id to ids unless it's already there, like $addToSet.
+func addID(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	if containsID(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
+
+// removeID removes every occurrence of id from ids, like $pull.
+func removeID(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	out := ids[:0]
+
+	for _, i := range ids {
+		if i != id {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func cloneUser(u *models.User) models.User {
+	user := *u
+	user.Posts = cloneIDs(u.Posts)
+	user.Following = cloneIDs(u.Following)
+	user.Followers = cloneIDs(u.Followers)
+	user.Blocked = cloneIDs(u.Blocked)
+	user.Muted = cloneIDs(u.Muted)
+	user.FollowRequests = cloneIDs(u.FollowRequests)
+	if u.RecoveryCodes != nil {
+		user.RecoveryCodes = append([]string{}, u.RecoveryCodes...)
+	}
+	if u.DeleteAt != nil {
+		at := *u.DeleteAt
+		user.DeleteAt = &at
+	}
+	if u.Identities != nil {
+		user.Identities = append([]models.Identity{}, u.Identities...)
+	}
+	return user
+}
+
+func clonePost(p *models.Post) models.Post {
+	post := *p
+	post.Comments = cloneIDs(p.Comments)
+	post.Likes = cloneIDs(p.Likes)
+	if p.Media != nil {
+		post.Media = append([]models.Attachment{}, p.Media...)
+	}
+	if p.Tags != nil {
+		post.Tags = append([]string{}, p.Tags...)
+	}
+	if p.Mentions != nil {
+		post.Mentions = append([]models.Mention{}, p.Mentions...)
+	}
+	if p.RepostOf != nil {
+		id := *p.RepostOf
+		post.RepostOf = &id
+	}
+	if p.QuoteOf != nil {
+		id := *p.QuoteOf
+		post.QuoteOf = &id
+	}
+	return post
+}
+
+func cloneComment(c *models.Comment) models.Comment {
+	comment := *c
+	comment.Likes = cloneIDs(c.Likes)
+	if c.Mentions != nil {
+		comment.Mentions = append([]models.Mention{}, c.Mentions...)
+	}
+	if c.Parent != nil {
+		parent := *c.Parent
+		comment.Parent = &parent
+	}
+	return comment
+}
+
+func cloneAPIKey(k *models.APIKey) models.APIKey {
+	key := *k
+	key.Scopes = append([]string{}, k.Scopes...)
+	if k.LastUsedAt != nil {
+		at := *k.LastUsedAt
+		key.LastUsedAt = &at
+	}
+	return key
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoError translates driver errors into the store's errors.
+func mongoError(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if isDuplicateKey(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// isDuplicateKey tells if the write broke a unique index.
+func isDuplicateKey(err error) bool {
+	switch e := err.(type) {
+	case mongo.WriteException:
+		for _, we := range e.WriteErrors {
+			if we.Code == 11000 {
+				return true
+			}
+		}
+	case mongo.CommandError:
+		return e.Code == 11000
+	}
+	return false
+}
+
+// returnUpdated makes FindOneAndUpdate return the document after the update was applied.
+func returnUpdated() *options.FindOneAndUpdateOptions {
+	return options.FindOneAndUpdate().SetReturnDocument(options.After)
+}
+
+// lookupTopComments is the $lookup stage that joins the most liked comments of a post.
+func lookupTopComments(limit int64) bson.M {
+	return bson.M{
+		"$lookup": bson.M{
+			"from": "comments",
+			"let":  bson.M{"comments": "$comments"},
+			"pipeline": bson.A{
+				// the replies stay in the thread
+				bson.M{"$match": bson.M{
+					"$expr":  bson.M{"$in": bson.A{"$_id", "$$comments"}},
+					"parent": bson.M{"$exists": false},
+				}},
+				bson.M{"$project": bson.M{
+					"_id":        1,
+					"message":    1,
+					"post":       1,
+					"user":       1,
+					"createdAt":  1,
+					"likes":      1,
+					"parent":     1,
+					"replyCount": 1,
+					"mentions":   1,
+					"count":      bson.M{"$size": "$likes"},
+				}},
+				bson.M{"$sort": bson.M{"count": -1}},
+				bson.M{"$limit": limit},
+				bson.M{"$project": bson.M{"count": 0}},
+			},
+			"as": "comments",
+		},
+	}
+}
+
+// lookupOriginal are the stages that join the post reposted or quoted as original, as viewer reads it.
+// The original is left out when its author is a private account viewer neither is nor follows,
+// or when either of them blocked the other.
+func lookupOriginal(viewer models.User) []bson.M {
+	visible := bson.A{}
+	for _, id := range viewer.Following {
+		visible = append(visible, id.Hex())
+	}
+
+	blocked := bson.A{}
+	for _, id := range viewer.Blocked {
+		blocked = append(blocked, id.Hex())
+	}
+
+	match := bson.M{"author._id": bson.M{"$nin": blocked}}
+
+	if viewer.ID != "" {
+		visible = append(visible, viewer.ID)
+
+		viewerID, _ := primitive.ObjectIDFromHex(viewer.ID)
+		match["account.blocked"] = bson.M{"$ne": viewerID}
+	}
+
+	match["$or"] = bson.A{
+		bson.M{"account.private": bson.M{"$ne": true}},
+		bson.M{"author._id": bson.M{"$in": visible}},
+	}
+
+	return []bson.M{
+		{"$lookup": bson.M{
+			"from": "posts",
+			"let":  bson.M{"shared": bson.M{"$ifNull": bson.A{"$repostOf", "$quoteOf"}}},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$shared"}}}},
+				lookupAccount(bson.M{"private": 1, "blocked": 1}),
+				bson.M{"$match": match},
+				bson.M{"$project": bson.M{"account": 0}},
+			},
+			"as": "original",
+		}},
+		{"$unwind": bson.M{
+			"path":                       "$original",
+			"preserveNullAndEmptyArrays": true,
+		}},
+	}
+}
+
+// indexes are the indexes the mongo stores rely on, by collection.
+func indexes() map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		"posts": {{
+			// the tag timelines
+			Keys: bson.D{{Key: "tags", Value: 1}, {Key: "createdAt", Value: -1}},
+		}, {
+			// a user reposts a post once, concurrent reposts included
+			Keys: bson.D{{Key: "repostOf", Value: 1}, {Key: "author._id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"repostOf": bson.M{"$exists": true}}),
+		}},
+		"comments": {{
+			// the threads, a level at a time
+			Keys: bson.D{{Key: "post", Value: 1}, {Key: "parent", Value: 1}, {Key: "_id", Value: 1}},
+		}, {
+			Keys: bson.D{{Key: "parent", Value: 1}, {Key: "_id", Value: 1}},
+		}},
+		"users": {{
+			// the profiles and the @mentions find the users by their username
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		"revoked_tokens": {{
+			// a revoked token only has to be remembered until it expires
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		}},
+		"refresh_tokens": {{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}, {
+			// a replayed token revokes its whole family, a password change all the user's tokens
+			Keys: bson.D{{Key: "family", Value: 1}},
+		}, {
+			Keys: bson.D{{Key: "user", Value: 1}},
+		}, {
+			// the expired tokens can't be used nor replayed anymore
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		}},
+		"one_time_tokens": {{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}, {
+			// issuing a token deletes the previous ones of the user
+			Keys: bson.D{{Key: "user", Value: 1}, {Key: "purpose", Value: 1}},
+		}, {
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		}},
+	}
+}
+
+// EnsureIndexes creates the indexes the mongo stores rely on, it's a no-op for the ones that exist already.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for collection, list := range indexes() {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
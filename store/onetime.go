@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OneTimeTokenStore interface {
+	// Create inserts the token and sets its generated ID.
+	Create(ctx context.Context, token *models.OneTimeToken) error
+	// Consume marks the matching unused and unexpired token as used and returns it,
+	// any other token (unknown, used, expired) gives ErrNotFound.
+	Consume(ctx context.Context, purpose, hash string) (*models.OneTimeToken, error)
+	// DeleteUser drops the user's tokens for the purpose, so only the latest link works.
+	DeleteUser(ctx context.Context, purpose string, userID primitive.ObjectID) error
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryOneTimeTokens struct {
+	db *memoryDB
+}
+
+func (m memoryOneTimeTokens) Create(ctx context.Context, token *models.OneTimeToken) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	id := primitive.NewObjectID()
+	token.ID = id.Hex()
+
+	t := *token
+	m.db.oneTimeTokens[id] = &t
+	return nil
+}
+
+func (m memoryOneTimeTokens) Consume(ctx context.Context, purpose, hash string) (*models.OneTimeToken, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	now := time.Now()
+
+	for _, token := range m.db.oneTimeTokens {
+		if token.Hash == hash && token.Purpose == purpose && !token.Used && now.Before(token.ExpiresAt) {
+			token.Used = true
+
+			t := *token
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m memoryOneTimeTokens) DeleteUser(ctx context.Context, purpose string, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for id, token := range m.db.oneTimeTokens {
+		if token.Purpose == purpose && token.User == userID {
+			delete(m.db.oneTimeTokens, id)
+		}
+	}
+	return nil
+}
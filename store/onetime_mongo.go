@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoOneTimeTokens struct {
+	coll *mongo.Collection
+}
+
+func (m mongoOneTimeTokens) Create(ctx context.Context, token *models.OneTimeToken) error {
+	token.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, token)
+
+	if err != nil {
+		return err
+	}
+
+	token.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (m mongoOneTimeTokens) Consume(ctx context.Context, purpose, hash string) (*models.OneTimeToken, error) {
+	token := new(models.OneTimeToken)
+
+	filter := bson.M{
+		"hash":      hash,
+		"purpose":   purpose,
+		"used":      false,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	update := bson.M{"$set": bson.M{"used": true}}
+
+	if err := m.coll.FindOneAndUpdate(ctx, filter, update, returnUpdated()).Decode(token); err != nil {
+		return nil, mongoError(err)
+	}
+	return token, nil
+}
+
+func (m mongoOneTimeTokens) DeleteUser(ctx context.Context, purpose string, userID primitive.ObjectID) error {
+	_, err := m.coll.DeleteMany(ctx, bson.M{"purpose": purpose, "user": userID})
+	return err
+}
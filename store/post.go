@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PostStore interface {
+	// Create inserts the post and sets its generated ID, a repost or a quote bumps the repostCount
+	// or quoteCount of the post it shares. It returns ErrDuplicate when the author already reposted the post.
+	Create(ctx context.Context, post *models.Post) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error)
+	// Update and Delete only touch the post when it belongs to authorID,
+	// Delete removes any post when authorID is the NilObjectID (moderation) and lowers the count Create bumped.
+	Update(ctx context.Context, id, authorID primitive.ObjectID, update PostUpdate) (*models.Post, error)
+	Delete(ctx context.Context, id, authorID primitive.ObjectID) error
+
+	// FindRepost returns the repost of the post by userID.
+	FindRepost(ctx context.Context, postID, userID primitive.ObjectID) (*models.Post, error)
+	// DeleteReposts removes the reposts of a deleted post and returns them.
+	DeleteReposts(ctx context.Context, postID primitive.ObjectID) ([]models.Post, error)
+
+	AddComment(ctx context.Context, postID, commentID primitive.ObjectID) error
+	RemoveComment(ctx context.Context, postID, commentID primitive.ObjectID) error
+
+	IsLiked(ctx context.Context, postID, userID primitive.ObjectID) (bool, error)
+	Like(ctx context.Context, postID, userID primitive.ObjectID) error
+	Unlike(ctx context.Context, postID, userID primitive.ObjectID) error
+
+	// ListByAuthor returns every post of the author, newest first.
+	ListByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]models.Post, error)
+	// ListLikedBy returns every post the user likes, newest first.
+	ListLikedBy(ctx context.Context, userID primitive.ObjectID) ([]models.Post, error)
+	// ActiveAuthors returns up to limit authors who posted since the given time, the most recently active first.
+	ActiveAuthors(ctx context.Context, since time.Time, limit int64) ([]primitive.ObjectID, error)
+
+	// DeleteByAuthor removes every post of the author, lowering the counts of the posts they shared, and returns their IDs.
+	DeleteByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]primitive.ObjectID, error)
+	// UnlikeAll pulls the user out of the likes[] of every post.
+	UnlikeAll(ctx context.Context, userID primitive.ObjectID) error
+	// RemoveComments pulls the comments out of the comments[] of every post.
+	RemoveComments(ctx context.Context, commentIDs []primitive.ObjectID) error
+
+	// The timelines embed the post a repost or a quote shares as its original, the original is left out
+	// when its author is a private account the reader neither is nor follows, or when either of them
+	// blocked the other.
+
+	// ListByTag returns the latest posts of the public accounts carrying the normalized tag
+	// and the total number of them.
+	ListByTag(ctx context.Context, tag string, page Page) ([]models.PostWithComment, int32, error)
+
+	// UserTimeline returns the latest posts and reposts of the author with their most liked comments,
+	// as viewerID reads them (the NilObjectID for an anonymous reader).
+	UserTimeline(ctx context.Context, authorID, viewerID primitive.ObjectID, page Page) ([]models.PostWithComment, error)
+	// HomeTimeline returns the latest posts and reposts of the users followed and not muted by userID,
+	// a post shared several times only shows once, at its latest entry, and the reposts of muted or
+	// blocked authors are left out. When userID is the NilObjectID it returns the latest posts of the
+	// public accounts, without the reposts.
+	// viewerID is who reads the timeline, when it isn't userID the private accounts are only kept
+	// if viewerID is them or follows them (the NilObjectID for an anonymous reader).
+	// The returned count is the total number of posts the timeline has.
+	HomeTimeline(ctx context.Context, userID, viewerID primitive.ObjectID, page Page) ([]models.PostWithComment, int32, error)
+}
+
+// PostUpdate holds the fields to change, nil fields are left untouched.
+type PostUpdate struct {
+	Title       *string
+	Description *string
+	// Tags and Mentions replace the ones of the post, an empty slice clears them
+	Tags     *[]string
+	Mentions *[]models.Mention
+}
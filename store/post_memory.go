@@ -0,0 +1,406 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryPosts struct {
+	db *memoryDB
+}
+
+func (m memoryPosts) Create(ctx context.Context, post *models.Post) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if post.RepostOf != nil {
+		for _, p := range m.db.posts {
+			if p.RepostOf != nil && *p.RepostOf == *post.RepostOf && p.Author.ID == post.Author.ID {
+				return ErrDuplicate
+			}
+		}
+	}
+
+	id := primitive.NewObjectID()
+	post.ID = id.Hex()
+
+	p := clonePost(post)
+	m.db.posts[id] = &p
+	m.countShare(&p, 1)
+	return nil
+}
+
+// countShare adds delta to the repostCount or quoteCount of the post shared by post, the caller must hold the lock.
+func (m memoryPosts) countShare(post *models.Post, delta int) {
+	if post.RepostOf != nil {
+		if original, ok := m.db.posts[*post.RepostOf]; ok {
+			original.RepostCount += delta
+		}
+	}
+	if post.QuoteOf != nil {
+		if original, ok := m.db.posts[*post.QuoteOf]; ok {
+			original.QuoteCount += delta
+		}
+	}
+}
+
+func (m memoryPosts) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	post, ok := m.db.posts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	p := clonePost(post)
+	return &p, nil
+}
+
+func (m memoryPosts) Update(ctx context.Context, id, authorID primitive.ObjectID, update PostUpdate) (*models.Post, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	post, ok := m.db.posts[id]
+	if !ok || post.Author.ID != authorID.Hex() {
+		return nil, ErrNotFound
+	}
+
+	if update.Title != nil {
+		post.Title = *update.Title
+	}
+	if update.Description != nil {
+		post.Description = *update.Description
+	}
+	if update.Tags != nil {
+		post.Tags = append([]string{}, *update.Tags...)
+	}
+	if update.Mentions != nil {
+		post.Mentions = append([]models.Mention{}, *update.Mentions...)
+	}
+
+	p := clonePost(post)
+	return &p, nil
+}
+
+func (m memoryPosts) Delete(ctx context.Context, id, authorID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	post, ok := m.db.posts[id]
+	if !ok || (!authorID.IsZero() && post.Author.ID != authorID.Hex()) {
+		return ErrNotFound
+	}
+
+	delete(m.db.posts, id)
+	m.countShare(post, -1)
+	return nil
+}
+
+func (m memoryPosts) FindRepost(ctx context.Context, postID, userID primitive.ObjectID) (*models.Post, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, post := range m.db.posts {
+		if post.RepostOf != nil && *post.RepostOf == postID && post.Author.ID == userID.Hex() {
+			p := clonePost(post)
+			return &p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m memoryPosts) DeleteReposts(ctx context.Context, postID primitive.ObjectID) ([]models.Post, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	reposts := []models.Post{}
+	for id, post := range m.db.posts {
+		if post.RepostOf != nil && *post.RepostOf == postID {
+			delete(m.db.posts, id)
+			reposts = append(reposts, clonePost(post))
+		}
+	}
+	return reposts, nil
+}
+
+func (m memoryPosts) AddComment(ctx context.Context, postID, commentID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if post, ok := m.db.posts[postID]; ok {
+		post.Comments = append(post.Comments, commentID)
+	}
+	return nil
+}
+
+func (m memoryPosts) RemoveComment(ctx context.Context, postID, commentID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if post, ok := m.db.posts[postID]; ok {
+		post.Comments = removeID(post.Comments, commentID)
+	}
+	return nil
+}
+
+func (m memoryPosts) IsLiked(ctx context.Context, postID, userID primitive.ObjectID) (bool, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	post, ok := m.db.posts[postID]
+	if !ok {
+		return false, nil
+	}
+	return containsID(post.Likes, userID), nil
+}
+
+func (m memoryPosts) Like(ctx context.Context, postID, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if post, ok := m.db.posts[postID]; ok {
+		post.Likes = addID(post.Likes, userID)
+	}
+	return nil
+}
+
+func (m memoryPosts) Unlike(ctx context.Context, postID, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if post, ok := m.db.posts[postID]; ok {
+		post.Likes = removeID(post.Likes, userID)
+	}
+	return nil
+}
+
+func (m memoryPosts) ListByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]models.Post, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	return clonePosts(m.latest(func(post *models.Post) bool {
+		return post.Author.ID == authorID.Hex()
+	})), nil
+}
+
+func (m memoryPosts) ActiveAuthors(ctx context.Context, since time.Time, limit int64) ([]primitive.ObjectID, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	posts := m.latest(func(post *models.Post) bool {
+		return !post.CreatedAt.Before(since)
+	})
+
+	seen := map[string]bool{}
+	authors := []primitive.ObjectID{}
+
+	for _, post := range posts {
+		if seen[post.Author.ID] || int64(len(authors)) >= limit {
+			continue
+		}
+		seen[post.Author.ID] = true
+
+		if id, err := primitive.ObjectIDFromHex(post.Author.ID); err == nil {
+			authors = append(authors, id)
+		}
+	}
+	return authors, nil
+}
+
+func (m memoryPosts) ListLikedBy(ctx context.Context, userID primitive.ObjectID) ([]models.Post, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	return clonePosts(m.latest(func(post *models.Post) bool {
+		return containsID(post.Likes, userID)
+	})), nil
+}
+
+func clonePosts(posts []*models.Post) []models.Post {
+	out := []models.Post{}
+	for _, post := range posts {
+		out = append(out, clonePost(post))
+	}
+	return out
+}
+
+func (m memoryPosts) DeleteByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	ids := []primitive.ObjectID{}
+	for id, post := range m.db.posts {
+		if post.Author.ID == authorID.Hex() {
+			delete(m.db.posts, id)
+			m.countShare(post, -1)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m memoryPosts) UnlikeAll(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, post := range m.db.posts {
+		post.Likes = removeID(post.Likes, userID)
+	}
+	return nil
+}
+
+func (m memoryPosts) RemoveComments(ctx context.Context, commentIDs []primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, post := range m.db.posts {
+		for _, id := range commentIDs {
+			post.Comments = removeID(post.Comments, id)
+		}
+	}
+	return nil
+}
+
+func (m memoryPosts) ListByTag(ctx context.Context, tag string, page Page) ([]models.PostWithComment, int32, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	posts := m.latest(func(post *models.Post) bool {
+		authorID, _ := primitive.ObjectIDFromHex(post.Author.ID)
+		if author, ok := m.db.users[authorID]; ok && author.Private {
+			return false
+		}
+
+		for _, t := range post.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	})
+
+	start, end := paginate(len(posts), page)
+
+	// the tag timelines are public
+	var out []models.PostWithComment
+	for _, post := range posts[start:end] {
+		out = append(out, m.db.withComments(post, page.Limit, primitive.NilObjectID))
+	}
+	return out, int32(len(posts)), nil
+}
+
+func (m memoryPosts) UserTimeline(ctx context.Context, authorID, viewerID primitive.ObjectID, page Page) ([]models.PostWithComment, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	posts := m.latest(func(post *models.Post) bool {
+		return post.Author.ID == authorID.Hex()
+	})
+
+	start, end := paginate(len(posts), page)
+
+	var out []models.PostWithComment
+	for _, post := range posts[start:end] {
+		out = append(out, m.db.withComments(post, page.Limit, viewerID))
+	}
+	return out, nil
+}
+
+func (m memoryPosts) HomeTimeline(ctx context.Context, userID, viewerID primitive.ObjectID, page Page) ([]models.PostWithComment, int32, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	// the latest posts of the system leave the private accounts and the reposts out
+	match := func(post *models.Post) bool {
+		authorID, _ := primitive.ObjectIDFromHex(post.Author.ID)
+		author, ok := m.db.users[authorID]
+		return post.RepostOf == nil && (!ok || !author.Private)
+	}
+
+	if userID != primitive.NilObjectID {
+		user, ok := m.db.users[userID]
+		if !ok {
+			return nil, 0, nil
+		}
+
+		following := map[string]bool{}
+		for _, id := range user.Following {
+			following[id.Hex()] = true
+		}
+		hidden := map[string]bool{}
+		for _, id := range append(cloneIDs(user.Muted), user.Blocked...) {
+			delete(following, id.Hex())
+			hidden[id.Hex()] = true
+		}
+
+		// someone else only sees the private accounts they follow themselves
+		if viewerID != userID {
+			for _, id := range user.Following {
+				if author, ok := m.db.users[id]; ok && author.Private && !m.db.canView(viewerID, id) {
+					delete(following, id.Hex())
+				}
+			}
+		}
+
+		match = func(post *models.Post) bool {
+			if !following[post.Author.ID] {
+				return false
+			}
+			if post.RepostOf == nil {
+				return true
+			}
+			// a repost without an original to show is left out
+			original, ok := m.db.posts[*post.RepostOf]
+			return ok && !hidden[original.Author.ID] && m.db.canSeePost(viewerID, original)
+		}
+	}
+
+	posts := distinct(m.latest(match))
+	start, end := paginate(len(posts), page)
+
+	var out []models.PostWithComment
+	for _, post := range posts[start:end] {
+		out = append(out, m.db.withComments(post, page.Limit, viewerID))
+	}
+	return out, int32(len(posts)), nil
+}
+
+// distinct keeps the first entry of every post among posts and the reposts of it.
+func distinct(posts []*models.Post) []*models.Post {
+	seen := map[string]bool{}
+	out := []*models.Post{}
+
+	for _, post := range posts {
+		key := post.ID
+		if post.RepostOf != nil {
+			key = post.RepostOf.Hex()
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, post)
+		}
+	}
+	return out
+}
+
+// latest returns the matching posts, newest first, the caller must hold the lock.
+func (m memoryPosts) latest(match func(post *models.Post) bool) []*models.Post {
+	var posts []*models.Post
+
+	for _, post := range m.db.posts {
+		if match(post) {
+			posts = append(posts, post)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+	return posts
+}
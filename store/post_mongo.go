@@ -0,0 +1,490 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoPosts struct {
+	coll  *mongo.Collection
+	users *mongo.Collection
+}
+
+func (m mongoPosts) Create(ctx context.Context, post *models.Post) error {
+	post.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, post)
+
+	if err != nil {
+		return mongoError(err)
+	}
+
+	post.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return m.countShare(ctx, *post, 1)
+}
+
+// countShare adds delta to the repostCount or quoteCount of the post shared by post.
+func (m mongoPosts) countShare(ctx context.Context, post models.Post, delta int) error {
+	if post.RepostOf != nil {
+		if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": *post.RepostOf}, bson.M{"$inc": bson.M{"repostCount": delta}}); err != nil {
+			return err
+		}
+	}
+	if post.QuoteOf != nil {
+		if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": *post.QuoteOf}, bson.M{"$inc": bson.M{"quoteCount": delta}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m mongoPosts) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	post := new(models.Post)
+
+	if err := m.coll.FindOne(ctx, bson.M{"_id": id}).Decode(post); err != nil {
+		return nil, mongoError(err)
+	}
+	return post, nil
+}
+
+func (m mongoPosts) Update(ctx context.Context, id, authorID primitive.ObjectID, update PostUpdate) (*models.Post, error) {
+	filter := bson.M{"_id": id, "author._id": authorID.Hex()}
+	set := bson.M{}
+
+	if update.Title != nil {
+		set["title"] = *update.Title
+	}
+	if update.Description != nil {
+		set["description"] = *update.Description
+	}
+	if update.Tags != nil {
+		set["tags"] = append([]string{}, *update.Tags...)
+	}
+	if update.Mentions != nil {
+		set["mentions"] = append([]models.Mention{}, *update.Mentions...)
+	}
+
+	post := new(models.Post)
+	var err error
+
+	if len(set) == 0 {
+		err = m.coll.FindOne(ctx, filter).Decode(post)
+	} else {
+		err = m.coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, returnUpdated()).Decode(post)
+	}
+
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	return post, nil
+}
+
+func (m mongoPosts) Delete(ctx context.Context, id, authorID primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	if !authorID.IsZero() {
+		filter["author._id"] = authorID.Hex()
+	}
+
+	var post models.Post
+
+	if err := m.coll.FindOneAndDelete(ctx, filter).Decode(&post); err != nil {
+		return mongoError(err)
+	}
+	return m.countShare(ctx, post, -1)
+}
+
+func (m mongoPosts) FindRepost(ctx context.Context, postID, userID primitive.ObjectID) (*models.Post, error) {
+	post := new(models.Post)
+
+	if err := m.coll.FindOne(ctx, bson.M{"repostOf": postID, "author._id": userID.Hex()}).Decode(post); err != nil {
+		return nil, mongoError(err)
+	}
+	return post, nil
+}
+
+func (m mongoPosts) DeleteReposts(ctx context.Context, postID primitive.ObjectID) ([]models.Post, error) {
+	reposts, err := m.find(ctx, bson.M{"repostOf": postID})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, bson.M{"repostOf": postID}); err != nil {
+		return nil, err
+	}
+	return reposts, nil
+}
+
+func (m mongoPosts) AddComment(ctx context.Context, postID, commentID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$push": bson.M{"comments": commentID}})
+	return err
+}
+
+func (m mongoPosts) RemoveComment(ctx context.Context, postID, commentID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$pull": bson.M{"comments": commentID}})
+	return err
+}
+
+func (m mongoPosts) IsLiked(ctx context.Context, postID, userID primitive.ObjectID) (bool, error) {
+	count, err := m.coll.CountDocuments(ctx, bson.M{"_id": postID, "likes": userID})
+
+	return count > 0, err
+}
+
+func (m mongoPosts) Like(ctx context.Context, postID, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$addToSet": bson.M{"likes": userID}})
+	return err
+}
+
+func (m mongoPosts) Unlike(ctx context.Context, postID, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$pull": bson.M{"likes": userID}})
+	return err
+}
+
+func (m mongoPosts) ListByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]models.Post, error) {
+	return m.find(ctx, bson.M{"author._id": authorID.Hex()})
+}
+
+func (m mongoPosts) ListLikedBy(ctx context.Context, userID primitive.ObjectID) ([]models.Post, error) {
+	return m.find(ctx, bson.M{"likes": userID})
+}
+
+func (m mongoPosts) ActiveAuthors(ctx context.Context, since time.Time, limit int64) ([]primitive.ObjectID, error) {
+	cur, err := m.coll.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"createdAt": bson.M{"$gte": since}}},
+		{"$group": bson.M{"_id": "$author._id", "lastPost": bson.M{"$max": "$createdAt"}}},
+		{"$sort": bson.M{"lastPost": -1}},
+		{"$limit": limit},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	authors := []primitive.ObjectID{}
+	for _, doc := range docs {
+		if id, err := primitive.ObjectIDFromHex(doc.ID); err == nil {
+			authors = append(authors, id)
+		}
+	}
+	return authors, nil
+}
+
+func (m mongoPosts) find(ctx context.Context, filter bson.M) ([]models.Post, error) {
+	cur, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	posts := []models.Post{}
+	if err := cur.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (m mongoPosts) DeleteByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := bson.M{"author._id": authorID.Hex()}
+
+	cur, err := m.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1, "repostOf": 1, "quoteOf": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []models.Post
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, doc := range docs {
+		id, err := primitive.ObjectIDFromHex(doc.ID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		if err := m.countShare(ctx, doc, -1); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func (m mongoPosts) UnlikeAll(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateMany(ctx, bson.M{"likes": userID}, bson.M{"$pull": bson.M{"likes": userID}})
+	return err
+}
+
+func (m mongoPosts) RemoveComments(ctx context.Context, commentIDs []primitive.ObjectID) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+
+	_, err := m.coll.UpdateMany(ctx,
+		bson.M{"comments": bson.M{"$in": commentIDs}},
+		bson.M{"$pull": bson.M{"comments": bson.M{"$in": commentIDs}}},
+	)
+	return err
+}
+
+func (m mongoPosts) UserTimeline(ctx context.Context, authorID, viewerID primitive.ObjectID, page Page) ([]models.PostWithComment, error) {
+	viewer, err := m.reader(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// get posts of the authorID
+	query := append([]bson.M{
+		{"$match": bson.M{"author._id": authorID.Hex()}},
+		lookupTopComments(page.Limit),
+	}, lookupOriginal(viewer)...)
+
+	cur, err := m.coll.Aggregate(ctx, append(query,
+		bson.M{"$sort": bson.M{"createdAt": -1}},
+		bson.M{"$skip": page.Skip},
+		bson.M{"$limit": page.Limit},
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the cursor once finished
+	defer cur.Close(ctx)
+
+	var posts []models.PostWithComment
+
+	for cur.Next(ctx) {
+		var post models.PostWithComment
+
+		if err := cur.Decode(&post); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, cur.Err()
+}
+
+func (m mongoPosts) HomeTimeline(ctx context.Context, userID, viewerID primitive.ObjectID, page Page) ([]models.PostWithComment, int32, error) {
+	var query []bson.M
+
+	viewer, err := m.reader(ctx, viewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if userID != primitive.NilObjectID {
+		// if userID is provided then get the posts and reposts of this user's followings,
+		// leaving out the muted or blocked ones and the reposts of the muted or blocked authors,
+		// the same as the memory store
+		user := new(models.User)
+
+		err := m.users.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{
+			"following": 1,
+			"muted":     1,
+			"blocked":   1,
+		})).Decode(user)
+
+		if err == mongo.ErrNoDocuments {
+			return nil, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		hidden := bson.A{}
+		for _, id := range append(cloneIDs(user.Muted), user.Blocked...) {
+			hidden = append(hidden, id.Hex())
+		}
+
+		// someone else only sees the private accounts they follow themselves
+		unseen := []primitive.ObjectID{}
+		if viewerID != userID {
+			if unseen, err = m.unseenAccounts(ctx, user.Following, viewerID); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		authors := bson.A{}
+		for _, id := range user.Following {
+			if !containsID(user.Muted, id) && !containsID(user.Blocked, id) && !containsID(unseen, id) {
+				authors = append(authors, id.Hex())
+			}
+		}
+
+		query = append([]bson.M{
+			{"$match": bson.M{"author._id": bson.M{"$in": authors}}},
+			// a post shared several times keeps its latest entry
+			{"$sort": bson.M{"createdAt": -1}},
+			{"$group": bson.M{
+				"_id":  bson.M{"$ifNull": bson.A{"$repostOf", "$_id"}},
+				"post": bson.M{"$first": "$$ROOT"},
+			}},
+			{"$replaceRoot": bson.M{"newRoot": "$post"}},
+		}, lookupOriginal(viewer)...)
+
+		// a repost without an original to show is left out
+		query = append(query,
+			bson.M{"$match": bson.M{"$or": bson.A{
+				bson.M{"repostOf": bson.M{"$exists": false}},
+				bson.M{"original._id": bson.M{"$exists": true}, "original.author._id": bson.M{"$nin": hidden}},
+			}}},
+			lookupTopComments(page.Limit),
+		)
+	} else {
+		// userID is not provided
+		// get the latest posts from system, leaving the private accounts and the reposts out
+
+		query = append([]bson.M{{"$match": bson.M{"repostOf": bson.M{"$exists": false}}}}, publicAuthors()...)
+		query = append(query, lookupTopComments(page.Limit))
+		query = append(query, lookupOriginal(viewer)...)
+	}
+
+	return m.timeline(ctx, query, page)
+}
+
+// unseenAccounts returns the private accounts among ids that viewerID neither is nor follows.
+func (m mongoPosts) unseenAccounts(ctx context.Context, ids []primitive.ObjectID, viewerID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var viewer models.User
+
+	if viewerID != primitive.NilObjectID {
+		err := m.users.FindOne(ctx, bson.M{"_id": viewerID}, options.FindOne().SetProjection(bson.M{"following": 1})).Decode(&viewer)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	cur, err := m.users.Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "private": true},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	unseen := []primitive.ObjectID{}
+	for _, doc := range docs {
+		if doc.ID != viewerID && !containsID(viewer.Following, doc.ID) {
+			unseen = append(unseen, doc.ID)
+		}
+	}
+	return unseen, nil
+}
+
+func (m mongoPosts) ListByTag(ctx context.Context, tag string, page Page) ([]models.PostWithComment, int32, error) {
+	query := append([]bson.M{{"$match": bson.M{"tags": tag}}}, publicAuthors()...)
+	query = append(query, lookupTopComments(page.Limit))
+
+	// the tag timelines are public
+	return m.timeline(ctx, append(query, lookupOriginal(models.User{})...), page)
+}
+
+// publicAuthors are the stages that leave the posts of private accounts out.
+func publicAuthors() []bson.M {
+	return []bson.M{
+		lookupAccount(bson.M{"private": 1}),
+		{"$match": bson.M{"account.private": bson.M{"$ne": true}}},
+		{"$project": bson.M{"account": 0}},
+	}
+}
+
+// lookupAccount is the stage that joins the given fields of the post's author as account.
+func lookupAccount(fields bson.M) bson.M {
+	return bson.M{"$lookup": bson.M{
+		"from": "users",
+		"let":  bson.M{"author": bson.M{"$toObjectId": "$author._id"}},
+		"pipeline": bson.A{
+			bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$author"}}}},
+			bson.M{"$project": fields},
+		},
+		"as": "account",
+	}}
+}
+
+// reader returns what the timelines need to know about the user reading them,
+// an anonymous reader (the NilObjectID) or a missing user has no ID.
+func (m mongoPosts) reader(ctx context.Context, viewerID primitive.ObjectID) (models.User, error) {
+	var viewer models.User
+
+	if viewerID == primitive.NilObjectID {
+		return viewer, nil
+	}
+
+	err := m.users.FindOne(ctx, bson.M{"_id": viewerID}, options.FindOne().SetProjection(bson.M{"following": 1, "blocked": 1})).Decode(&viewer)
+	if err == mongo.ErrNoDocuments {
+		return models.User{}, nil
+	}
+	return viewer, err
+}
+
+// timeline runs the query on the posts and returns the requested page of the latest results
+// with the total number of results.
+func (m mongoPosts) timeline(ctx context.Context, query []bson.M, page Page) ([]models.PostWithComment, int32, error) {
+	paginate := []bson.M{
+		{"$sort": bson.M{"createdAt": -1}},
+		// the count is taken before $skip, a page past the end still has the total
+		{"$facet": bson.M{
+			"count": bson.A{bson.M{"$count": "count"}},
+			"posts": bson.A{bson.M{"$skip": page.Skip}, bson.M{"$limit": page.Limit}},
+		}},
+		{"$project": bson.M{
+			"count": bson.M{"$arrayElemAt": bson.A{"$count", 0}},
+			"posts": 1,
+		}},
+		{"$project": bson.M{
+			"count": "$count.count",
+			"posts": 1,
+		}},
+	}
+
+	cur, err := m.coll.Aggregate(ctx, append(query, paginate...))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Close the cursor once finished
+	defer cur.Close(ctx)
+
+	var data struct {
+		Count int32                    `bson:"count"`
+		Posts []models.PostWithComment `bson:"posts"`
+	}
+
+	if cur.Next(ctx) {
+		if err := cur.Decode(&data); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return data.Posts, data.Count, nil
+}
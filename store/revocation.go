@@ -0,0 +1,13 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// RevocationStore is the denylist of access tokens (by their jti) revoked before they expired.
+type RevocationStore interface {
+	// Revoke denylists the jti, the entry is only needed until the token expires.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type memoryRevocations struct {
+	db *memoryDB
+}
+
+func (m memoryRevocations) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	// drop the entries of tokens that expired anyway
+	now := time.Now()
+	for id, exp := range m.db.revocations {
+		if now.After(exp) {
+			delete(m.db.revocations, id)
+		}
+	}
+
+	m.db.revocations[jti] = expiresAt
+	return nil
+}
+
+func (m memoryRevocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	_, ok := m.db.revocations[jti]
+	return ok, nil
+}
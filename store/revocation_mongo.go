@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoRevocations struct {
+	coll *mongo.Collection
+}
+
+func (m mongoRevocations) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := m.coll.UpdateOne(ctx,
+		bson.M{"_id": jti},
+		bson.M{"$set": bson.M{"expiresAt": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (m mongoRevocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := m.coll.CountDocuments(ctx, bson.M{"_id": jti})
+
+	return count > 0, err
+}
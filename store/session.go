@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionStore interface {
+	// Create inserts the session and sets its generated ID.
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	// ListByUser returns the user's sessions that aren't revoked and were seen after since, most recent first.
+	ListByUser(ctx context.Context, userID primitive.ObjectID, since time.Time) ([]models.Session, error)
+	// Touch records the last time the session was used and from where.
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error
+	// Revoke revokes the session when it belongs to userID.
+	Revoke(ctx context.Context, id, userID primitive.ObjectID) error
+	// RevokeUser revokes every session of the user.
+	RevokeUser(ctx context.Context, userID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySessions struct {
+	db *memoryDB
+}
+
+func (m memorySessions) Create(ctx context.Context, session *models.Session) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	id := primitive.NewObjectID()
+	session.ID = id.Hex()
+
+	s := *session
+	m.db.sessions[id] = &s
+	return nil
+}
+
+func (m memorySessions) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	session, ok := m.db.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	s := *session
+	return &s, nil
+}
+
+func (m memorySessions) ListByUser(ctx context.Context, userID primitive.ObjectID, since time.Time) ([]models.Session, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	sessions := []models.Session{}
+	for _, session := range m.db.sessions {
+		if session.User == userID && !session.Revoked && !session.LastSeen.Before(since) {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+func (m memorySessions) Touch(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if session, ok := m.db.sessions[id]; ok {
+		session.LastSeen = at
+		session.IP = ip
+	}
+	return nil
+}
+
+func (m memorySessions) Revoke(ctx context.Context, id, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	session, ok := m.db.sessions[id]
+	if !ok || session.User != userID {
+		return ErrNotFound
+	}
+
+	session.Revoked = true
+	return nil
+}
+
+func (m memorySessions) RevokeUser(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, session := range m.db.sessions {
+		if session.User == userID {
+			session.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (m memorySessions) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for id, doc := range m.db.sessions {
+		if doc.User == userID {
+			delete(m.db.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoSessions struct {
+	coll *mongo.Collection
+}
+
+func (m mongoSessions) Create(ctx context.Context, session *models.Session) error {
+	session.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, session)
+
+	if err != nil {
+		return err
+	}
+
+	session.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (m mongoSessions) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	session := new(models.Session)
+
+	if err := m.coll.FindOne(ctx, bson.M{"_id": id}).Decode(session); err != nil {
+		return nil, mongoError(err)
+	}
+	return session, nil
+}
+
+func (m mongoSessions) ListByUser(ctx context.Context, userID primitive.ObjectID, since time.Time) ([]models.Session, error) {
+	filter := bson.M{"user": userID, "revoked": false, "lastSeen": bson.M{"$gte": since}}
+
+	cur, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"lastSeen": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []models.Session{}
+	if err := cur.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (m mongoSessions) Touch(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastSeen": at, "ip": ip}})
+	return err
+}
+
+func (m mongoSessions) Revoke(ctx context.Context, id, userID primitive.ObjectID) error {
+	result, err := m.coll.UpdateOne(ctx, bson.M{"_id": id, "user": userID}, bson.M{"$set": bson.M{"revoked": true}})
+
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoSessions) RevokeUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateMany(ctx, bson.M{"user": userID}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+func (m mongoSessions) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound is returned by every store when the requested document doesn't exist
+// (or doesn't belong to the given owner), so handlers don't have to know which backend they talk to.
+var ErrNotFound = errors.New("store: document not found")
+
+// ErrDuplicate is returned when a write would give a user the username of another one
+// or repost a post the user already reposted.
+var ErrDuplicate = errors.New("store: duplicate key")
+
+// Store groups the repositories the handlers depend on.
+type Store struct {
+	Users         UserStore
+	Posts         PostStore
+	Comments      CommentStore
+	RefreshTokens RefreshTokenStore
+	Revocations   RevocationStore
+	OneTimeTokens OneTimeTokenStore
+	APIKeys       APIKeyStore
+	Sessions      SessionStore
+	Exports       ExportStore
+	Media         MediaStore
+	Suggestions   SuggestionStore
+	// LoginAttempts is in-memory with both backends, use NewMongoLoginAttempts
+	// when the API runs on several instances
+	LoginAttempts LoginAttemptStore
+}
+
+// Page describes which slice of a listing should be returned.
+type Page struct {
+	Skip  int64
+	Limit int64
+}
+
+// NewMongo returns a Store backed by the given MongoDB database.
+func NewMongo(db *mongo.Database) Store {
+	return Store{
+		Users:         mongoUsers{coll: db.Collection("users")},
+		Posts:         mongoPosts{coll: db.Collection("posts"), users: db.Collection("users")},
+		Comments:      mongoComments{coll: db.Collection("comments")},
+		RefreshTokens: mongoRefreshTokens{coll: db.Collection("refresh_tokens")},
+		Revocations:   mongoRevocations{coll: db.Collection("revoked_tokens")},
+		OneTimeTokens: mongoOneTimeTokens{coll: db.Collection("one_time_tokens")},
+		APIKeys:       mongoAPIKeys{coll: db.Collection("api_keys")},
+		Sessions:      mongoSessions{coll: db.Collection("sessions")},
+		Exports:       mongoExports{coll: db.Collection("exports")},
+		Media:         mongoMedia{coll: db.Collection("media")},
+		Suggestions:   mongoSuggestions{coll: db.Collection("suggestions")},
+		LoginAttempts: NewMemoryLoginAttempts(),
+	}
+}
+
+// NewMemory returns a Store that keeps everything in process memory,
+// it's fully functional and useful for tests and for running the API without a database.
+func NewMemory() Store {
+	db := newMemoryDB()
+
+	return Store{
+		Users:         memoryUsers{db},
+		Posts:         memoryPosts{db},
+		Comments:      memoryComments{db},
+		RefreshTokens: memoryRefreshTokens{db},
+		Revocations:   memoryRevocations{db},
+		OneTimeTokens: memoryOneTimeTokens{db},
+		APIKeys:       memoryAPIKeys{db},
+		Sessions:      memorySessions{db},
+		Exports:       memoryExports{db},
+		Media:         memoryMedia{db},
+		Suggestions:   memorySuggestions{db},
+		LoginAttempts: NewMemoryLoginAttempts(),
+	}
+}
@@ -0,0 +1,20 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SuggestionStore interface {
+	// Find returns the cached follow suggestions of the user.
+	Find(ctx context.Context, userID primitive.ObjectID) (*models.Suggestions, error)
+	// Save replaces the cached follow suggestions of suggestions.User.
+	Save(ctx context.Context, suggestions *models.Suggestions) error
+	// ListStale returns the users whose suggestions were updated before the given time.
+	ListStale(ctx context.Context, before time.Time) ([]primitive.ObjectID, error)
+	// Delete drops the cached suggestions of the user, if any.
+	Delete(ctx context.Context, userID primitive.ObjectID) error
+}
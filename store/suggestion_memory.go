@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySuggestions struct {
+	db *memoryDB
+}
+
+func cloneSuggestions(s *models.Suggestions) models.Suggestions {
+	suggestions := *s
+	suggestions.Users = append([]models.Suggestion{}, s.Users...)
+	return suggestions
+}
+
+func (m memorySuggestions) Find(ctx context.Context, userID primitive.ObjectID) (*models.Suggestions, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	suggestions, ok := m.db.suggestions[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	s := cloneSuggestions(suggestions)
+	return &s, nil
+}
+
+func (m memorySuggestions) Save(ctx context.Context, suggestions *models.Suggestions) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	s := cloneSuggestions(suggestions)
+	m.db.suggestions[suggestions.User] = &s
+	return nil
+}
+
+func (m memorySuggestions) ListStale(ctx context.Context, before time.Time) ([]primitive.ObjectID, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	users := []primitive.ObjectID{}
+	for userID, suggestions := range m.db.suggestions {
+		if suggestions.UpdatedAt.Before(before) {
+			users = append(users, userID)
+		}
+	}
+	return users, nil
+}
+
+func (m memorySuggestions) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	delete(m.db.suggestions, userID)
+	return nil
+}
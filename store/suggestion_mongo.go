@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoSuggestions struct {
+	coll *mongo.Collection
+}
+
+func (m mongoSuggestions) Find(ctx context.Context, userID primitive.ObjectID) (*models.Suggestions, error) {
+	suggestions := new(models.Suggestions)
+
+	if err := m.coll.FindOne(ctx, bson.M{"_id": userID}).Decode(suggestions); err != nil {
+		return nil, mongoError(err)
+	}
+	return suggestions, nil
+}
+
+func (m mongoSuggestions) Save(ctx context.Context, suggestions *models.Suggestions) error {
+	_, err := m.coll.ReplaceOne(ctx, bson.M{"_id": suggestions.User}, suggestions, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (m mongoSuggestions) ListStale(ctx context.Context, before time.Time) ([]primitive.ObjectID, error) {
+	cur, err := m.coll.Find(ctx, bson.M{"updatedAt": bson.M{"$lt": before}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	users := []primitive.ObjectID{}
+	for _, doc := range docs {
+		users = append(users, doc.ID)
+	}
+	return users, nil
+}
+
+func (m mongoSuggestions) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
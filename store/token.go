@@ -0,0 +1,22 @@
+package store
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RefreshTokenStore interface {
+	// Create inserts the token and sets its generated ID.
+	Create(ctx context.Context, token *models.RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// MarkUsed flags an unused token as used, it returns ErrNotFound when the token was already used
+	// so two concurrent refreshes can't both rotate the same token.
+	MarkUsed(ctx context.Context, id primitive.ObjectID) error
+	// RevokeFamily revokes every token of the family.
+	RevokeFamily(ctx context.Context, family string) error
+	// RevokeUser revokes every token of the user.
+	RevokeUser(ctx context.Context, userID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
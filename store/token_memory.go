@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryRefreshTokens struct {
+	db *memoryDB
+}
+
+func (m memoryRefreshTokens) Create(ctx context.Context, token *models.RefreshToken) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	id := primitive.NewObjectID()
+	token.ID = id.Hex()
+
+	t := *token
+	m.db.refreshTokens[id] = &t
+	return nil
+}
+
+func (m memoryRefreshTokens) FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, token := range m.db.refreshTokens {
+		if token.Hash == hash {
+			t := *token
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m memoryRefreshTokens) MarkUsed(ctx context.Context, id primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	token, ok := m.db.refreshTokens[id]
+	if !ok || token.Used {
+		return ErrNotFound
+	}
+
+	token.Used = true
+	return nil
+}
+
+func (m memoryRefreshTokens) RevokeFamily(ctx context.Context, family string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, token := range m.db.refreshTokens {
+		if token.Family == family {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (m memoryRefreshTokens) RevokeUser(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, token := range m.db.refreshTokens {
+		if token.User == userID {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (m memoryRefreshTokens) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for id, doc := range m.db.refreshTokens {
+		if doc.User == userID {
+			delete(m.db.refreshTokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoRefreshTokens struct {
+	coll *mongo.Collection
+}
+
+func (m mongoRefreshTokens) Create(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, token)
+
+	if err != nil {
+		return err
+	}
+
+	token.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (m mongoRefreshTokens) FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	token := new(models.RefreshToken)
+
+	if err := m.coll.FindOne(ctx, bson.M{"hash": hash}).Decode(token); err != nil {
+		return nil, mongoError(err)
+	}
+	return token, nil
+}
+
+func (m mongoRefreshTokens) MarkUsed(ctx context.Context, id primitive.ObjectID) error {
+	updateResult, err := m.coll.UpdateOne(ctx, bson.M{"_id": id, "used": false}, bson.M{"$set": bson.M{"used": true}})
+
+	if err != nil {
+		return err
+	}
+	if updateResult.ModifiedCount < 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoRefreshTokens) RevokeFamily(ctx context.Context, family string) error {
+	_, err := m.coll.UpdateMany(ctx, bson.M{"family": family}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+func (m mongoRefreshTokens) RevokeUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.UpdateMany(ctx, bson.M{"user": userID}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+func (m mongoRefreshTokens) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := m.coll.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserStore interface {
+	// Create inserts the user and sets its generated ID, it returns ErrDuplicate when the username is taken.
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByUserName(ctx context.Context, username string) (*models.User, error)
+	// FindByUserNames returns the existing users among usernames, in no particular order.
+	FindByUserNames(ctx context.Context, usernames []string) ([]models.User, error)
+	// FindByIDs returns the existing users among ids, in no particular order.
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error)
+	// FindByIdentity returns the user the social login is linked to.
+	FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	// AddIdentity links a social login to the user.
+	AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.Identity) error
+	// List returns the users in signup order and the total number of users.
+	List(ctx context.Context, page Page) ([]models.User, int32, error)
+	// Update returns ErrDuplicate when the new username is taken.
+	Update(ctx context.Context, id primitive.ObjectID, update UserUpdate) (*models.User, error)
+	// IncrementTokenVersion invalidates every access token issued to the user so far.
+	IncrementTokenVersion(ctx context.Context, id primitive.ObjectID) error
+	// RemoveRecoveryCode consumes one of the user's hashed recovery codes,
+	// ErrNotFound means it was already used.
+	RemoveRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) error
+	// UseTOTPStep records the time step of an accepted TOTP code,
+	// ErrNotFound means a code of that step or a later one was already used.
+	UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) error
+
+	// ScheduleDeletion marks the account to be deleted at the given time, CancelDeletion clears the mark.
+	ScheduleDeletion(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	CancelDeletion(ctx context.Context, id primitive.ObjectID) error
+	// ListDeletionDue returns the users whose deletion was scheduled before now.
+	ListDeletionDue(ctx context.Context, now time.Time) ([]models.User, error)
+	// Delete removes the user and pulls its ID out of the other users' following[], followers[],
+	// followRequests[], blocked[] and muted[].
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	IsFollowing(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error)
+	// Follow adds targetID to the user's following[] and userID to the target's followers[].
+	Follow(ctx context.Context, userID, targetID primitive.ObjectID) error
+	Unfollow(ctx context.Context, userID, targetID primitive.ObjectID) error
+
+	// IsFollowRequested tells if userID asked to follow the private account targetID.
+	IsFollowRequested(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error)
+	// RequestFollow adds userID to the target's followRequests[], RemoveFollowRequest takes it out
+	// once the request was approved, rejected or cancelled.
+	RequestFollow(ctx context.Context, userID, targetID primitive.ObjectID) error
+	RemoveFollowRequest(ctx context.Context, userID, targetID primitive.ObjectID) error
+
+	// IsBlocked tells if userID blocked targetID.
+	IsBlocked(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error)
+	// Block adds targetID to the user's blocked[] and removes the follow edges and requests between them both ways.
+	Block(ctx context.Context, userID, targetID primitive.ObjectID) error
+	Unblock(ctx context.Context, userID, targetID primitive.ObjectID) error
+	// Mute adds targetID to the user's muted[], their posts are left out of the user's home timeline.
+	Mute(ctx context.Context, userID, targetID primitive.ObjectID) error
+	Unmute(ctx context.Context, userID, targetID primitive.ObjectID) error
+
+	AddPost(ctx context.Context, userID, postID primitive.ObjectID) error
+	RemovePost(ctx context.Context, userID, postID primitive.ObjectID) error
+}
+
+// UserUpdate holds the fields to change, nil fields are left untouched.
+type UserUpdate struct {
+	UserName *string
+	Password *string
+	Verified *bool
+	Private  *bool
+
+	// profile fields, an empty string clears the field
+	DisplayName *string
+	Bio         *string
+	Location    *string
+	Website     *string
+	Avatar      *string
+	Banner      *string
+
+	Role      *string
+	Suspended *bool
+
+	MFAEnabled *bool
+	TOTPSecret *string
+	// RecoveryCodes replaces the hashed recovery codes when not nil
+	RecoveryCodes []string
+}
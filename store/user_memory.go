@@ -0,0 +1,460 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUsers struct {
+	db *memoryDB
+}
+
+func (m memoryUsers) Create(ctx context.Context, user *models.User) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if m.db.userNameTaken(user.UserName, primitive.NilObjectID) {
+		return ErrDuplicate
+	}
+
+	id := primitive.NewObjectID()
+	user.ID = id.Hex()
+
+	u := cloneUser(user)
+	m.db.users[id] = &u
+	return nil
+}
+
+func (m memoryUsers) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	u := cloneUser(user)
+	return &u, nil
+}
+
+func (m memoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, user := range m.db.users {
+		if user.Email == email {
+			u := cloneUser(user)
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m memoryUsers) FindByUserName(ctx context.Context, username string) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, user := range m.db.users {
+		if user.UserName == username {
+			u := cloneUser(user)
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m memoryUsers) FindByUserNames(ctx context.Context, usernames []string) ([]models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, username := range usernames {
+		wanted[username] = true
+	}
+
+	users := []models.User{}
+	for _, user := range m.db.users {
+		if wanted[user.UserName] {
+			users = append(users, cloneUser(user))
+		}
+	}
+	return users, nil
+}
+
+func (m memoryUsers) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	users := []models.User{}
+	for _, id := range ids {
+		if user, ok := m.db.users[id]; ok {
+			users = append(users, cloneUser(user))
+		}
+	}
+	return users, nil
+}
+
+func (m memoryUsers) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, user := range m.db.users {
+		for _, identity := range user.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				u := cloneUser(user)
+				return &u, nil
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m memoryUsers) AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.Identity) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, i := range user.Identities {
+		if i == identity {
+			return nil
+		}
+	}
+	user.Identities = append(user.Identities, identity)
+	return nil
+}
+
+func (m memoryUsers) List(ctx context.Context, page Page) ([]models.User, int32, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range m.db.users {
+		users = append(users, cloneUser(user))
+	}
+
+	// ObjectIDs start with their creation time
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	start, end := paginate(len(users), page)
+	return users[start:end], int32(len(users)), nil
+}
+
+func (m memoryUsers) Update(ctx context.Context, id primitive.ObjectID, update UserUpdate) (*models.User, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if update.UserName != nil && m.db.userNameTaken(*update.UserName, id) {
+		return nil, ErrDuplicate
+	}
+
+	if update.UserName != nil {
+		user.UserName = *update.UserName
+	}
+	if update.Password != nil {
+		user.Password = *update.Password
+	}
+	if update.Verified != nil {
+		user.Verified = *update.Verified
+	}
+	if update.Private != nil {
+		user.Private = *update.Private
+	}
+	if update.DisplayName != nil {
+		user.DisplayName = *update.DisplayName
+	}
+	if update.Bio != nil {
+		user.Bio = *update.Bio
+	}
+	if update.Location != nil {
+		user.Location = *update.Location
+	}
+	if update.Website != nil {
+		user.Website = *update.Website
+	}
+	if update.Avatar != nil {
+		user.Avatar = *update.Avatar
+	}
+	if update.Banner != nil {
+		user.Banner = *update.Banner
+	}
+	if update.Role != nil {
+		user.Role = *update.Role
+	}
+	if update.Suspended != nil {
+		user.Suspended = *update.Suspended
+	}
+	if update.MFAEnabled != nil {
+		user.MFAEnabled = *update.MFAEnabled
+	}
+	if update.TOTPSecret != nil {
+		user.TOTPSecret = *update.TOTPSecret
+	}
+	if update.RecoveryCodes != nil {
+		user.RecoveryCodes = append([]string{}, update.RecoveryCodes...)
+	}
+
+	u := cloneUser(user)
+	return &u, nil
+}
+
+func (m memoryUsers) IncrementTokenVersion(ctx context.Context, id primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[id]; ok {
+		user.TokenVersion++
+	}
+	return nil
+}
+
+func (m memoryUsers) RemoveRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for i, code := range user.RecoveryCodes {
+		if code == hash {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m memoryUsers) UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[id]
+	if !ok || user.LastTOTPStep >= step {
+		return ErrNotFound
+	}
+
+	user.LastTOTPStep = step
+	return nil
+}
+
+func (m memoryUsers) ScheduleDeletion(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.DeleteAt = &at
+	return nil
+}
+
+func (m memoryUsers) CancelDeletion(ctx context.Context, id primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.DeleteAt = nil
+	return nil
+}
+
+func (m memoryUsers) ListDeletionDue(ctx context.Context, now time.Time) ([]models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range m.db.users {
+		if user.DeleteAt != nil && !user.DeleteAt.After(now) {
+			users = append(users, cloneUser(user))
+		}
+	}
+	return users, nil
+}
+
+func (m memoryUsers) Delete(ctx context.Context, id primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.db.users, id)
+
+	for _, user := range m.db.users {
+		user.Following = removeID(user.Following, id)
+		user.Followers = removeID(user.Followers, id)
+		user.FollowRequests = removeID(user.FollowRequests, id)
+		user.Blocked = removeID(user.Blocked, id)
+		user.Muted = removeID(user.Muted, id)
+	}
+	return nil
+}
+
+func (m memoryUsers) IsFollowing(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	target, ok := m.db.users[targetID]
+	if !ok {
+		return false, nil
+	}
+	return containsID(target.Followers, userID), nil
+}
+
+func (m memoryUsers) Follow(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Following = addID(user.Following, targetID)
+	}
+	if target, ok := m.db.users[targetID]; ok {
+		target.Followers = addID(target.Followers, userID)
+	}
+	return nil
+}
+
+func (m memoryUsers) Unfollow(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Following = removeID(user.Following, targetID)
+	}
+	if target, ok := m.db.users[targetID]; ok {
+		target.Followers = removeID(target.Followers, userID)
+	}
+	return nil
+}
+
+func (m memoryUsers) IsFollowRequested(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	target, ok := m.db.users[targetID]
+	if !ok {
+		return false, nil
+	}
+	return containsID(target.FollowRequests, userID), nil
+}
+
+func (m memoryUsers) RequestFollow(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if target, ok := m.db.users[targetID]; ok {
+		target.FollowRequests = addID(target.FollowRequests, userID)
+	}
+	return nil
+}
+
+func (m memoryUsers) RemoveFollowRequest(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if target, ok := m.db.users[targetID]; ok {
+		target.FollowRequests = removeID(target.FollowRequests, userID)
+	}
+	return nil
+}
+
+func (m memoryUsers) IsBlocked(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	user, ok := m.db.users[userID]
+	if !ok {
+		return false, nil
+	}
+	return containsID(user.Blocked, targetID), nil
+}
+
+func (m memoryUsers) Block(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Blocked = addID(user.Blocked, targetID)
+		user.Following = removeID(user.Following, targetID)
+		user.Followers = removeID(user.Followers, targetID)
+		user.FollowRequests = removeID(user.FollowRequests, targetID)
+	}
+	if target, ok := m.db.users[targetID]; ok {
+		target.Following = removeID(target.Following, userID)
+		target.Followers = removeID(target.Followers, userID)
+		target.FollowRequests = removeID(target.FollowRequests, userID)
+	}
+	return nil
+}
+
+func (m memoryUsers) Unblock(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Blocked = removeID(user.Blocked, targetID)
+	}
+	return nil
+}
+
+func (m memoryUsers) Mute(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Muted = addID(user.Muted, targetID)
+	}
+	return nil
+}
+
+func (m memoryUsers) Unmute(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Muted = removeID(user.Muted, targetID)
+	}
+	return nil
+}
+
+func (m memoryUsers) AddPost(ctx context.Context, userID, postID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Posts = append(user.Posts, postID)
+	}
+	return nil
+}
+
+func (m memoryUsers) RemovePost(ctx context.Context, userID, postID primitive.ObjectID) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if user, ok := m.db.users[userID]; ok {
+		user.Posts = removeID(user.Posts, postID)
+	}
+	return nil
+}
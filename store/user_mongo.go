@@ -0,0 +1,333 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/kiranbhalerao123/gotter/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoUsers struct {
+	coll *mongo.Collection
+}
+
+func (m mongoUsers) Create(ctx context.Context, user *models.User) error {
+	// force MongoDB to always set its own generated ObjectIDs
+	user.ID = ""
+	insertionResult, err := m.coll.InsertOne(ctx, user)
+
+	if err != nil {
+		return mongoError(err)
+	}
+
+	user.ID = insertionResult.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (m mongoUsers) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return m.findOne(ctx, bson.M{"_id": id})
+}
+
+func (m mongoUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return m.findOne(ctx, bson.M{"email": email})
+}
+
+func (m mongoUsers) FindByUserName(ctx context.Context, username string) (*models.User, error) {
+	return m.findOne(ctx, bson.M{"username": username})
+}
+
+func (m mongoUsers) FindByUserNames(ctx context.Context, usernames []string) ([]models.User, error) {
+	return m.find(ctx, bson.M{"username": bson.M{"$in": usernames}})
+}
+
+func (m mongoUsers) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	return m.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (m mongoUsers) find(ctx context.Context, filter bson.M) ([]models.User, error) {
+	cur, err := m.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	users := []models.User{}
+	if err := cur.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (m mongoUsers) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	return m.findOne(ctx, bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}})
+}
+
+func (m mongoUsers) AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.Identity) error {
+	result, err := m.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{"identities": identity}})
+
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoUsers) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	user := new(models.User)
+
+	if err := m.coll.FindOne(ctx, filter).Decode(user); err != nil {
+		return nil, mongoError(err)
+	}
+	return user, nil
+}
+
+func (m mongoUsers) List(ctx context.Context, page Page) ([]models.User, int32, error) {
+	count, err := m.coll.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetSkip(page.Skip)
+	if page.Limit > 0 {
+		opts.SetLimit(page.Limit)
+	}
+
+	cur, err := m.coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users := []models.User{}
+	if err := cur.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, int32(count), nil
+}
+
+func (m mongoUsers) Update(ctx context.Context, id primitive.ObjectID, update UserUpdate) (*models.User, error) {
+	set := bson.M{}
+
+	if update.UserName != nil {
+		set["username"] = *update.UserName
+	}
+	if update.Password != nil {
+		set["password"] = *update.Password
+	}
+	if update.Verified != nil {
+		set["verified"] = *update.Verified
+	}
+	if update.Private != nil {
+		set["private"] = *update.Private
+	}
+	if update.DisplayName != nil {
+		set["displayName"] = *update.DisplayName
+	}
+	if update.Bio != nil {
+		set["bio"] = *update.Bio
+	}
+	if update.Location != nil {
+		set["location"] = *update.Location
+	}
+	if update.Website != nil {
+		set["website"] = *update.Website
+	}
+	if update.Avatar != nil {
+		set["avatar"] = *update.Avatar
+	}
+	if update.Banner != nil {
+		set["banner"] = *update.Banner
+	}
+	if update.Role != nil {
+		set["role"] = *update.Role
+	}
+	if update.Suspended != nil {
+		set["suspended"] = *update.Suspended
+	}
+	if update.MFAEnabled != nil {
+		set["mfaEnabled"] = *update.MFAEnabled
+	}
+	if update.TOTPSecret != nil {
+		set["totpSecret"] = *update.TOTPSecret
+	}
+	if update.RecoveryCodes != nil {
+		set["recoveryCodes"] = update.RecoveryCodes
+	}
+
+	if len(set) == 0 {
+		return m.FindByID(ctx, id)
+	}
+
+	user := new(models.User)
+	err := m.coll.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": set}, returnUpdated()).Decode(user)
+
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	return user, nil
+}
+
+func (m mongoUsers) IncrementTokenVersion(ctx context.Context, id primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"tokenVersion": 1}})
+	return err
+}
+
+func (m mongoUsers) RemoveRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) error {
+	// matching on the code makes the $pull atomic, only one request can use it
+	result, err := m.coll.UpdateOne(ctx, bson.M{"_id": id, "recoveryCodes": hash}, bson.M{"$pull": bson.M{"recoveryCodes": hash}})
+
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoUsers) UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) error {
+	// the filter makes it atomic, only one request can move past a step
+	result, err := m.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "lastTotpStep": bson.M{"$not": bson.M{"$gte": step}}},
+		bson.M{"$set": bson.M{"lastTotpStep": step}},
+	)
+
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoUsers) ScheduleDeletion(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return m.updateOne(ctx, id, bson.M{"$set": bson.M{"deleteAt": at}})
+}
+
+func (m mongoUsers) CancelDeletion(ctx context.Context, id primitive.ObjectID) error {
+	return m.updateOne(ctx, id, bson.M{"$unset": bson.M{"deleteAt": ""}})
+}
+
+func (m mongoUsers) updateOne(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	result, err := m.coll.UpdateOne(ctx, bson.M{"_id": id}, update)
+
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoUsers) ListDeletionDue(ctx context.Context, now time.Time) ([]models.User, error) {
+	return m.find(ctx, bson.M{"deleteAt": bson.M{"$lte": now}})
+}
+
+func (m mongoUsers) Delete(ctx context.Context, id primitive.ObjectID) error {
+	// the references go first, once the user is gone a retry couldn't tell they're left
+	if _, err := m.coll.UpdateMany(ctx,
+		bson.M{"$or": bson.A{bson.M{"following": id}, bson.M{"followers": id}, bson.M{"followRequests": id}, bson.M{"blocked": id}, bson.M{"muted": id}}},
+		bson.M{"$pull": bson.M{"following": id, "followers": id, "followRequests": id, "blocked": id, "muted": id}},
+	); err != nil {
+		return err
+	}
+
+	result, err := m.coll.DeleteOne(ctx, bson.M{"_id": id})
+
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoUsers) IsFollowing(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error) {
+	count, err := m.coll.CountDocuments(ctx, bson.M{"_id": targetID, "followers": userID})
+
+	return count > 0, err
+}
+
+func (m mongoUsers) Follow(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$addToSet": bson.M{"following": targetID}}); err != nil {
+		return err
+	}
+
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$addToSet": bson.M{"followers": userID}})
+	return err
+}
+
+func (m mongoUsers) Unfollow(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$pull": bson.M{"following": targetID}}); err != nil {
+		return err
+	}
+
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$pull": bson.M{"followers": userID}})
+	return err
+}
+
+func (m mongoUsers) IsFollowRequested(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error) {
+	count, err := m.coll.CountDocuments(ctx, bson.M{"_id": targetID, "followRequests": userID})
+
+	return count > 0, err
+}
+
+func (m mongoUsers) RequestFollow(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$addToSet": bson.M{"followRequests": userID}})
+	return err
+}
+
+func (m mongoUsers) RemoveFollowRequest(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$pull": bson.M{"followRequests": userID}})
+	return err
+}
+
+func (m mongoUsers) IsBlocked(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error) {
+	count, err := m.coll.CountDocuments(ctx, bson.M{"_id": userID, "blocked": targetID})
+
+	return count > 0, err
+}
+
+func (m mongoUsers) Block(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$addToSet": bson.M{"blocked": targetID},
+		"$pull":     bson.M{"following": targetID, "followers": targetID, "followRequests": targetID},
+	}); err != nil {
+		return err
+	}
+
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$pull": bson.M{"following": userID, "followers": userID, "followRequests": userID}})
+	return err
+}
+
+func (m mongoUsers) Unblock(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$pull": bson.M{"blocked": targetID}})
+	return err
+}
+
+func (m mongoUsers) Mute(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$addToSet": bson.M{"muted": targetID}})
+	return err
+}
+
+func (m mongoUsers) Unmute(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$pull": bson.M{"muted": targetID}})
+	return err
+}
+
+func (m mongoUsers) AddPost(ctx context.Context, userID, postID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$push": bson.M{"posts": postID}})
+	return err
+}
+
+func (m mongoUsers) RemovePost(ctx context.Context, userID, postID primitive.ObjectID) error {
+	_, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$pull": bson.M{"posts": postID}})
+	return err
+}
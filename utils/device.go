@@ -0,0 +1,54 @@
+package utils
+
+import "strings"
+
+// userAgentBrowsers and userAgentSystems are matched in order, the more specific tokens first
+// (Edge and Opera also say Chrome, Chrome also says Safari, iOS also says Mac OS X...).
+var userAgentBrowsers = [][2]string{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+	{"Go-http-client/", "Go client"},
+}
+
+var userAgentSystems = [][2]string{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceLabel names the device of a session from its user agent, like "Firefox on Linux".
+func DeviceLabel(userAgent string) string {
+	browser, system := "", ""
+
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b[0]) {
+			browser = b[1]
+			break
+		}
+	}
+
+	for _, s := range userAgentSystems {
+		if strings.Contains(userAgent, s[0]) {
+			system = s[1]
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return "Unknown device"
+}
@@ -2,8 +2,30 @@ package utils
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 func GoDotEnvVariable(key string) string {
 	return os.Getenv(key)
 }
+
+// GoDotEnvInt reads a positive number from the env, fallback is used when it's missing or invalid.
+func GoDotEnvInt(key string, fallback int) int {
+	n, err := strconv.Atoi(GoDotEnvVariable(key))
+
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+// GoDotEnvDuration reads a duration like "15m" from the env, fallback is used when it's missing or invalid.
+func GoDotEnvDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(GoDotEnvVariable(key))
+
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
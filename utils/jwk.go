@@ -0,0 +1,335 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// JWTKey is a key access tokens are signed or verified with, tokens carry its ID in the kid header.
+type JWTKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	// signKey is the HMAC secret or the private key, nil when the key only verifies tokens
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewJWTKey wraps a HMAC secret ([]byte), an RSA, ECDSA P-256 or Ed25519 private key,
+// or the public key of one of them to only verify tokens. The kid defaults to the key's thumbprint.
+func NewJWTKey(key interface{}, kid string) (*JWTKey, error) {
+	k := &JWTKey{ID: kid}
+
+	switch key := key.(type) {
+	case []byte:
+		if len(key) == 0 {
+			return nil, errors.New("jwt: empty HMAC secret")
+		}
+		k.Method, k.signKey, k.verifyKey = jwt.SigningMethodHS256, key, key
+	case *rsa.PrivateKey:
+		k.Method, k.signKey, k.verifyKey = jwt.SigningMethodRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.Method, k.verifyKey = jwt.SigningMethodRS256, key
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("jwt: only P-256 ECDSA keys are supported")
+		}
+		k.Method, k.signKey, k.verifyKey = jwt.SigningMethodES256, key, &key.PublicKey
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("jwt: only P-256 ECDSA keys are supported")
+		}
+		k.Method, k.verifyKey = jwt.SigningMethodES256, key
+	case ed25519.PrivateKey:
+		k.Method, k.signKey, k.verifyKey = SigningMethodEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.Method, k.verifyKey = SigningMethodEdDSA, key
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %T", key)
+	}
+
+	if k.ID == "" {
+		k.ID = k.thumbprint()
+	}
+	return k, nil
+}
+
+// publicJWK returns the members of the public key as a JWK (RFC 7517), nil for HMAC secrets which are never published.
+func (k *JWTKey) publicJWK() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch key := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"n":   b64(key.N.Bytes()),
+			"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		// coordinates are padded to the curve size
+		size := (key.Curve.Params().BitSize + 7) / 8
+
+		return map[string]string{
+			"kty": "EC",
+			"crv": key.Curve.Params().Name,
+			"x":   b64(padBytes(key.X.Bytes(), size)),
+			"y":   b64(padBytes(key.Y.Bytes(), size)),
+		}
+	case ed25519.PublicKey:
+		return map[string]string{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   b64(key),
+		}
+	}
+	return nil
+}
+
+// thumbprint is the RFC 7638 thumbprint of the public key, or a hash of the HMAC secret.
+func (k *JWTKey) thumbprint() string {
+	jwk := k.publicJWK()
+
+	if jwk == nil {
+		sum := sha256.Sum256(k.verifyKey.([]byte))
+		return "hs-" + hex.EncodeToString(sum[:8])
+	}
+
+	// encoding/json sorts the map keys, which is the canonical form the RFC asks for
+	b, _ := json.Marshal(jwk)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWTKeySet holds the key new tokens are signed with and every key tokens are still accepted from,
+// keeping the previous keys around lets them rotate without logging everybody out.
+type JWTKeySet struct {
+	signing *JWTKey
+	keys    map[string]*JWTKey
+}
+
+func NewJWTKeySet(signing *JWTKey, previous ...*JWTKey) (*JWTKeySet, error) {
+	if signing == nil || signing.signKey == nil {
+		return nil, errors.New("jwt: the signing key needs a secret or a private key")
+	}
+
+	ks := &JWTKeySet{signing: signing, keys: map[string]*JWTKey{}}
+
+	for _, key := range append([]*JWTKey{signing}, previous...) {
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+	return ks, nil
+}
+
+// Sign signs the claims with the signing key.
+func (ks *JWTKeySet) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Parse verifies the signature and expiry of the token with the key named by its kid and returns its claims.
+func (ks *JWTKeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		// the tokens issued before they had a kid aren't accepted anymore, a new login signs them
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+
+		// never let the token pick the algorithm
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return key.verifyKey, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// JWKS returns the public keys as a JWK Set, the signing key first.
+func (ks *JWTKeySet) JWKS() map[string]interface{} {
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		if id != ks.signing.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	keys := []map[string]string{}
+	for _, id := range append([]string{ks.signing.ID}, ids...) {
+		key := ks.keys[id]
+
+		jwk := key.publicJWK()
+		if jwk == nil {
+			continue
+		}
+
+		jwk["kid"] = key.ID
+		jwk["use"] = "sig"
+		jwk["alg"] = key.Method.Alg()
+		keys = append(keys, jwk)
+	}
+
+	return map[string]interface{}{"keys": keys}
+}
+
+// LoadJWTKeys builds the key set from the env:
+//   - JWT_ALG picks the signing algorithm, HS256 (default), RS256, ES256 or EdDSA
+//   - JWT_SECRET is the HS256 secret
+//   - JWT_PRIVATE_KEY (PEM) or JWT_PRIVATE_KEY_FILE is the private key of the other algorithms
+//   - JWT_KID overrides the kid of the signing key, it defaults to the key's thumbprint
+//   - JWT_VERIFY_KEY_FILES (comma separated PEM files) and JWT_PREVIOUS_SECRETS (comma separated)
+//     are the other keys tokens are accepted from, the previous ones until their tokens expired
+//     or the next one so it's published before it signs anything
+func LoadJWTKeys() (*JWTKeySet, error) {
+	alg := GoDotEnvVariable("JWT_ALG")
+	if alg == "" {
+		alg = jwt.SigningMethodHS256.Alg()
+	}
+
+	var signing *JWTKey
+	var err error
+
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		secret := GoDotEnvVariable("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("JWT_SECRET not provided")
+		}
+
+		signing, err = NewJWTKey([]byte(secret), GoDotEnvVariable("JWT_KID"))
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(), SigningMethodEdDSA.Alg():
+		data := []byte(GoDotEnvVariable("JWT_PRIVATE_KEY"))
+
+		if len(data) == 0 {
+			if data, err = readEnvFile("JWT_PRIVATE_KEY_FILE"); err != nil {
+				return nil, err
+			}
+		}
+
+		key, err := ParsePEMKey(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if signing, err = NewJWTKey(key, GoDotEnvVariable("JWT_KID")); err != nil {
+			return nil, err
+		}
+
+		if signing.Method.Alg() != alg {
+			return nil, fmt.Errorf("JWT_ALG is %s but the private key is a %s key", alg, signing.Method.Alg())
+		}
+	default:
+		return nil, fmt.Errorf("JWT_ALG %q is not supported", alg)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []*JWTKey
+
+	for _, file := range splitEnvList("JWT_VERIFY_KEY_FILES") {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParsePEMKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		k, err := NewJWTKey(key, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		previous = append(previous, k)
+	}
+
+	for _, secret := range splitEnvList("JWT_PREVIOUS_SECRETS") {
+		k, err := NewJWTKey([]byte(secret), "")
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, k)
+	}
+
+	return NewJWTKeySet(signing, previous...)
+}
+
+// ParsePEMKey parses a PEM encoded private (PKCS#1, PKCS#8, SEC 1) or public (PKIX, PKCS#1) key.
+func ParsePEMKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM key found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("jwt: unsupported PEM block %q", block.Type)
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func readEnvFile(key string) ([]byte, error) {
+	path := GoDotEnvVariable(key)
+	if path == "" {
+		return nil, fmt.Errorf("%s not provided", key)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func splitEnvList(key string) []string {
+	var list []string
+
+	for _, item := range strings.Split(GoDotEnvVariable(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -1,9 +1,56 @@
 package utils
 
 import (
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/dgrijalva/jwt-go"
 )
 
+// AccessTokenTTL is how long the JWT returned by /login stays valid, ACCESS_TOKEN_TTL (default 15m).
+func AccessTokenTTL() time.Duration {
+	return GoDotEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+// RefreshTokenTTL is how long a refresh token stays valid, REFRESH_TOKEN_TTL (default 30 days).
+func RefreshTokenTTL() time.Duration {
+	return GoDotEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// AccessTokenAudience is the aud of the access tokens, they're only good for this API.
+func AccessTokenAudience() string {
+	return AppURL() + "/api"
+}
+
+var jwtKeys struct {
+	sync.Mutex
+	set *JWTKeySet
+}
+
+// JWTKeys returns the keys tokens are signed and verified with, loaded by LoadJWTKeys on first use.
+func JWTKeys() (*JWTKeySet, error) {
+	jwtKeys.Lock()
+	defer jwtKeys.Unlock()
+
+	if jwtKeys.set == nil {
+		set, err := LoadJWTKeys()
+		if err != nil {
+			return nil, err
+		}
+		jwtKeys.set = set
+	}
+	return jwtKeys.set, nil
+}
+
+// SetJWTKeys replaces the keys tokens are signed and verified with, nil goes back to LoadJWTKeys.
+func SetJWTKeys(set *JWTKeySet) {
+	jwtKeys.Lock()
+	defer jwtKeys.Unlock()
+
+	jwtKeys.set = set
+}
+
 func CreateJWTToken(data map[string]interface{}) (string, error) {
 	mapClaims := make(jwt.MapClaims, len(data))
 
@@ -11,9 +58,44 @@ func CreateJWTToken(data map[string]interface{}) (string, error) {
 		mapClaims[key] = val
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
-	// Sign and get the complete encoded token as a string using the secret
-	tokenSecret := GoDotEnvVariable("JWT_SECRET")
+	keys, err := JWTKeys()
+	if err != nil {
+		return "", err
+	}
+
+	// Sign and get the complete encoded token as a string using the signing key
+	return keys.Sign(mapClaims)
+}
+
+// CreateAccessToken creates a JWT with the given claims which expires after ttl.
+func CreateAccessToken(data map[string]interface{}, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := make(map[string]interface{}, len(data)+2)
+
+	for key, val := range data {
+		claims[key] = val
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
 
-	return token.SignedString([]byte(tokenSecret))
+	return CreateJWTToken(claims)
+}
+
+// ParseJWTToken verifies the signature and expiry of a token created by CreateAccessToken and returns its claims.
+func ParseJWTToken(tokenString string) (jwt.MapClaims, error) {
+	keys, err := JWTKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := keys.Parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// jwt-go only checks exp when it's there, a token without one would never expire
+	if _, ok := claims["exp"].(float64); !ok {
+		return nil, errors.New("jwt: the token has no expiry")
+	}
+	return claims, nil
 }
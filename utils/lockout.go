@@ -0,0 +1,19 @@
+package utils
+
+import "time"
+
+// LoginMaxFailures is how many failed logins lock an account, LOGIN_MAX_FAILURES (default 5).
+func LoginMaxFailures() int {
+	return GoDotEnvInt("LOGIN_MAX_FAILURES", 5)
+}
+
+// LoginMaxIPFailures is how many failed logins block an IP address, LOGIN_MAX_IP_FAILURES (default 20).
+func LoginMaxIPFailures() int {
+	return GoDotEnvInt("LOGIN_MAX_IP_FAILURES", 20)
+}
+
+// LoginLockout is how long a locked account or blocked IP address waits, LOGIN_LOCKOUT (default 15m).
+// The failure counters also restart once the last failure is that old.
+func LoginLockout() time.Duration {
+	return GoDotEnvDuration("LOGIN_LOCKOUT", 15*time.Minute)
+}
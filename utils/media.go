@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MediaMaxBytes is the largest file that can be uploaded, MEDIA_MAX_BYTES (default 5MB).
+func MediaMaxBytes() int {
+	return GoDotEnvInt("MEDIA_MAX_BYTES", 5*1024*1024)
+}
+
+// MediaUnattachedTTL is how long an upload that isn't attached to a post is kept, MEDIA_UNATTACHED_TTL (default 24h).
+func MediaUnattachedTTL() time.Duration {
+	return GoDotEnvDuration("MEDIA_UNATTACHED_TTL", 24*time.Hour)
+}
+
+// BlobDir is where the local blob store keeps the uploads, BLOB_DIR (default gotter-blobs in the temp directory).
+func BlobDir() string {
+	if dir := GoDotEnvVariable("BLOB_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "gotter-blobs")
+}
@@ -0,0 +1,9 @@
+package utils
+
+import "time"
+
+// SuggestionsTTL is how long the cached follow suggestions of a user are served before
+// the background job refreshes them, SUGGESTIONS_TTL (default 1h).
+func SuggestionsTTL() time.Duration {
+	return GoDotEnvDuration("SUGGESTIONS_TTL", time.Hour)
+}
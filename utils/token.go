@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PasswordResetTTL is how long a password reset link stays valid, PASSWORD_RESET_TTL (default 1h).
+func PasswordResetTTL() time.Duration {
+	return GoDotEnvDuration("PASSWORD_RESET_TTL", time.Hour)
+}
+
+// EmailVerificationTTL is how long an email verification link stays valid, EMAIL_VERIFICATION_TTL (default 24h).
+func EmailVerificationTTL() time.Duration {
+	return GoDotEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+}
+
+// OAuthStateTTL is how long the user has to log in at the OpenID Connect provider, OAUTH_STATE_TTL (default 10m).
+func OAuthStateTTL() time.Duration {
+	return GoDotEnvDuration("OAUTH_STATE_TTL", 10*time.Minute)
+}
+
+// AppURL is the public URL of the app used to build the links we email, APP_URL (default http://localhost:3000).
+func AppURL() string {
+	url := GoDotEnvVariable("APP_URL")
+	if url == "" {
+		url = "http://localhost:3000"
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+// MediaURL is the public URL the blob is served from by GET /media/*.
+func MediaURL(key string) string {
+	return AppURL() + "/api/v1/media/" + key
+}
+
+// RandomToken returns a url safe random string with 256 bits of entropy,
+// used for opaque tokens (refresh tokens, reset links...) that are stored hashed.
+func RandomToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken hashes an opaque token for storage, unlike passwords these tokens are random
+// so a fast hash is enough and lets us look them up by their hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AccountDeletionGrace is how long a deleted account can still be restored, ACCOUNT_DELETION_GRACE (default 14 days).
+func AccountDeletionGrace() time.Duration {
+	return GoDotEnvDuration("ACCOUNT_DELETION_GRACE", 14*24*time.Hour)
+}
+
+// ExportTTL is how long the archive of a personal data export can be downloaded, EXPORT_TTL (default 48h).
+func ExportTTL() time.Duration {
+	return GoDotEnvDuration("EXPORT_TTL", 48*time.Hour)
+}
+
+// ExportDir is where the archives of the personal data exports are kept, EXPORT_DIR
+// (default gotter-exports in the temp directory).
+func ExportDir() string {
+	if dir := GoDotEnvVariable("EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "gotter-exports")
+}